@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"path"
+	"syscall"
 	"time"
 
 	"github.com/layer5io/meshery/helpers"
@@ -35,6 +36,15 @@ func main() {
 
 	viper.SetDefault("PORT", 8080)
 	viper.SetDefault("ADAPTER_URLS", "")
+	viper.SetDefault("LOAD_TEST_WORKER_URLS", "")
+	viper.SetDefault("LOAD_TEST_JOB_IMAGE", helpers.DefaultLoadTestJobImage)
+	// LOAD_TEST_MAX_CONCURRENCY is the number of load tests run at once, the others wait in the run queue, and
+	// LOAD_TEST_MAX_PER_USER the number of tests a user can have running or queued, 0 for no limit
+	viper.SetDefault("LOAD_TEST_MAX_CONCURRENCY", helpers.DefaultLoadTestMaxConcurrency)
+	viper.SetDefault("LOAD_TEST_MAX_PER_USER", 0)
+	viper.SetDefault("RESULTS_JANITOR_INTERVAL", time.Hour)
+	// STORAGE_BACKEND is either bitcask or sqlite, the bitcask stores are migrated to sqlite with cmd/migrate-bitcask
+	viper.SetDefault("STORAGE_BACKEND", "bitcask")
 
 	home, err := os.UserHomeDir()
 	if viper.GetString("USER_DATA_FOLDER") == "" {
@@ -62,6 +72,9 @@ func main() {
 
 	adapterTracker := helpers.NewAdaptersTracker(adapterURLs)
 	queryTracker := helpers.NewUUIDQueryTracker()
+	loadTestJobTracker := helpers.NewLoadTestJobTracker()
+	loadTestQueue := helpers.NewLoadTestQueue(viper.GetInt("LOAD_TEST_MAX_CONCURRENCY"), viper.GetInt("LOAD_TEST_MAX_PER_USER"))
+	loadTestWorkersTracker := helpers.NewLoadTestWorkersTracker(viper.GetStringSlice("LOAD_TEST_WORKER_URLS"))
 
 	// Uncomment line below to generate a new UID and force the user to login every time Meshery is started.
 	// fileSessionStore := sessions.NewFilesystemStore("", []byte(uuid.NewV4().Bytes()))
@@ -73,6 +86,30 @@ func main() {
 		Name: "loadTestReporterQueue",
 	})
 
+	loadGenerators := map[string]models.LoadGenerator{}
+	for _, lg := range []models.LoadGenerator{
+		helpers.NewFortioLoadGenerator(),
+		helpers.NewWRK2LoadGenerator(),
+		helpers.NewNighthawkLoadGenerator(viper.GetString("NIGHTHAWK_LOCATION"), viper.GetString("NIGHTHAWK_SERVICE")),
+	} {
+		loadGenerators[lg.Name()] = lg
+	}
+
+	// run as a load test job, Meshery only runs the load test it is given and exits
+	if options := viper.GetString(helpers.LoadTestJobOptionsEnv); options != "" {
+		jobCtx, cancel := context.WithCancel(ctx)
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-stop
+			cancel()
+		}()
+		if err := helpers.RunLoadTestJob(jobCtx, options, loadGenerators, os.Stdout); err != nil {
+			logrus.Fatal(err)
+		}
+		return
+	}
+
 	provs := map[string]models.Provider{}
 
 	var cookieSessionStore *sessions.CookieStore
@@ -83,11 +120,57 @@ func main() {
 	}
 	defer preferencePersister.ClosePersister()
 
-	resultPersister, err := models.NewBitCaskResultsPersister(viper.GetString("USER_DATA_FOLDER"))
+	var (
+		resultPersister      models.ResultsPersister
+		cPreferencePersister models.PreferencePersister
+	)
+	switch viper.GetString("STORAGE_BACKEND") {
+	case "sqlite":
+		db, err := models.OpenSQLiteDatabase(viper.GetString("USER_DATA_FOLDER"))
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		defer func() {
+			_ = db.Close()
+		}()
+		resultPersister = models.NewSQLiteResultsPersister(db)
+		cPreferencePersister = models.NewSQLitePreferencePersister(db)
+	case "bitcask":
+		bResultPersister, err := models.NewBitCaskResultsPersister(viper.GetString("USER_DATA_FOLDER"))
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		defer bResultPersister.CloseResultPersister()
+		resultPersister = bResultPersister
+
+		bPreferencePersister, err := models.NewBitCaskPreferencePersister(viper.GetString("USER_DATA_FOLDER"))
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		defer bPreferencePersister.ClosePersister()
+		cPreferencePersister = bPreferencePersister
+	default:
+		logrus.Fatalf("unknown storage backend: %s, expecting bitcask or sqlite", viper.GetString("STORAGE_BACKEND"))
+	}
+	logrus.Infof("Using the %s storage backend", viper.GetString("STORAGE_BACKEND"))
+
+	performanceProfilesPersister, err := models.NewBitCaskPerformanceProfilesPersister(viper.GetString("USER_DATA_FOLDER"))
 	if err != nil {
 		logrus.Fatal(err)
 	}
-	defer resultPersister.CloseResultPersister()
+	defer performanceProfilesPersister.ClosePerformanceProfilesPersister()
+
+	schedulePersister, err := models.NewBitCaskSchedulePersister(viper.GetString("USER_DATA_FOLDER"))
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	defer schedulePersister.CloseSchedulePersister()
+
+	experimentPersister, err := models.NewBitCaskExperimentPersister(viper.GetString("USER_DATA_FOLDER"))
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	defer experimentPersister.CloseExperimentPersister()
 
 	// randID, _ := uuid.NewV4()
 	// cookieSessionStore = sessions.NewCookieStore(randID.Bytes())
@@ -102,14 +185,22 @@ func main() {
 		// SessionStore:           cookieSessionStore,
 		MapPreferencePersister: preferencePersister,
 		ResultPersister:        resultPersister,
+
+		PerformanceProfilesPersister: performanceProfilesPersister,
 	}
 	provs[lProv.Name()] = lProv
 
-	cPreferencePersister, err := models.NewBitCaskPreferencePersister(viper.GetString("USER_DATA_FOLDER"))
-	if err != nil {
-		logrus.Fatal(err)
+	// RESULTS_MAX_AGE is a duration like 720h, RESULTS_MAX_BYTES a size like 512MB
+	retention := models.ResultsRetention{
+		MaxAge:   viper.GetDuration("RESULTS_MAX_AGE"),
+		MaxCount: viper.GetInt("RESULTS_MAX_COUNT"),
+		MaxBytes: int64(viper.GetSizeInBytes("RESULTS_MAX_BYTES")),
 	}
-	defer preferencePersister.ClosePersister()
+	if retention.Enabled() {
+		logrus.Infof("Results retention: max age: %s, max count: %d, max bytes: %d", retention.MaxAge, retention.MaxCount, retention.MaxBytes)
+	}
+	lProv.StartResultsJanitor(retention, viper.GetDuration("RESULTS_JANITOR_INTERVAL"))
+	defer lProv.StopResultsJanitor()
 
 	cookieSessionStore = sessions.NewCookieStore([]byte("Meshery"))
 	// saasBaseURL := viper.GetString("SAAS_BASE_URL")
@@ -121,10 +212,10 @@ func main() {
 		RefCookieName: "meshery_ref",
 		SessionName:   "meshery",
 		// SessionStore: fileSessionStore,
-		SessionStore:               cookieSessionStore,
-		SaaSTokenName:              "meshery_saas",
-		LoginCookieDuration:        1 * time.Hour,
-		BitCaskPreferencePersister: cPreferencePersister,
+		SessionStore:        cookieSessionStore,
+		SaaSTokenName:       "meshery_saas",
+		LoginCookieDuration: 1 * time.Hour,
+		PreferencePersister: cPreferencePersister,
 	}
 	cp.SyncPreferences()
 	defer cp.StopSyncPreferences()
@@ -138,6 +229,19 @@ func main() {
 		AdapterTracker: adapterTracker,
 		QueryTracker:   queryTracker,
 
+		LoadTestJobTracker: loadTestJobTracker,
+		LoadTestQueue:      loadTestQueue,
+		LoadGenerators:     loadGenerators,
+
+		LoadTestWorkersTracker: loadTestWorkersTracker,
+		LoadTestWorkerToken:    viper.GetString("LOAD_TEST_WORKER_TOKEN"),
+		LoadTestJobImage:       viper.GetString("LOAD_TEST_JOB_IMAGE"),
+
+		SchedulePersister:   schedulePersister,
+		ExperimentPersister: experimentPersister,
+
+		ResultPersister: resultPersister,
+
 		Queue: mainQueue,
 
 		KubeConfigFolder: viper.GetString("KUBECONFIG_FOLDER"),
@@ -0,0 +1,41 @@
+// Command migrate-bitcask copies the results and the preferences stored in the bitcask stores of the
+// user data folder to the SQLite database used when Meshery is run with STORAGE_BACKEND=sqlite.
+// Meshery must be stopped while it runs.
+package main
+
+import (
+	"os"
+	"path"
+
+	"github.com/layer5io/meshery/models"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+func main() {
+	viper.AutomaticEnv()
+
+	if viper.GetString("USER_DATA_FOLDER") == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			logrus.Fatalf("unable to retrieve the user's home directory: %v", err)
+		}
+		viper.SetDefault("USER_DATA_FOLDER", path.Join(home, ".meshery", "config"))
+	}
+	folder := viper.GetString("USER_DATA_FOLDER")
+	logrus.Infof("Migrating the bitcask stores of '%s' to SQLite", folder)
+
+	db, err := models.OpenSQLiteDatabase(folder)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	results, preferences, err := models.MigrateBitCaskToSQLite(folder, db)
+	if err != nil {
+		logrus.Fatalf("migration failed after %d results and %d preferences: %v", results, preferences, err)
+	}
+	logrus.Infof("Migrated %d results and %d preferences, start Meshery with STORAGE_BACKEND=sqlite to use them", results, preferences)
+}
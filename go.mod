@@ -19,6 +19,7 @@ require (
 	github.com/grafana-tools/sdk v0.0.0-20190705114053-83ac18ae3b6c
 	github.com/jinzhu/copier v0.0.0-20190924061706-b57f9002281a
 	github.com/layer5io/gowrk2 v0.0.0-20191111234958-a4c9071c0f87
+	github.com/mattn/go-sqlite3 v1.14.6
 	github.com/opencontainers/go-digest v1.0.0-rc1 // indirect
 	github.com/pkg/errors v0.9.1
 	github.com/prologic/bitcask v0.3.5
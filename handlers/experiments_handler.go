@@ -0,0 +1,475 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/helpers"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// experimentInput is the part of an experiment which is set through the API
+type experimentInput struct {
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Profiles    []string                `json:"profiles"`
+	Meshes      []models.ExperimentMesh `json:"meshes"`
+	QPS         []float64               `json:"qps"`
+}
+
+// ExperimentsHandler is used for listing, creating and deleting the experiments of the user, an experiment starts
+// as soon as it is created, /api/experiments/<id>/cancel stops it and /api/experiments/<id>/report returns its report
+func (h *Handler) ExperimentsHandler(w http.ResponseWriter, req *http.Request, _ *sessions.Session, _ *models.Preference, user *models.User, provider models.Provider) {
+	if h.config.ExperimentPersister == nil {
+		http.Error(w, "experiments are not available", http.StatusNotFound)
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, "/api/experiments"), "/"), "/")
+
+	if parts[0] == "" {
+		switch req.Method {
+		case http.MethodGet:
+			h.experimentLock.Lock()
+			all, err := h.config.ExperimentPersister.GetExperiments()
+			h.experimentLock.Unlock()
+			if err != nil {
+				logrus.Error(err)
+				http.Error(w, "unable to get the experiments", http.StatusInternalServerError)
+				return
+			}
+			experiments := []*models.Experiment{}
+			for _, e := range all {
+				if e.UserID == user.UserID {
+					experiments = append(experiments, e)
+				}
+			}
+			sort.Slice(experiments, func(i, j int) bool {
+				return experiments[i].CreatedAt.After(experiments[j].CreatedAt)
+			})
+			h.writeExperimentJSON(w, http.StatusOK, experiments)
+		case http.MethodPost:
+			experiment, ok := h.createExperiment(w, req, user, provider)
+			if !ok {
+				return
+			}
+			go h.runExperiment(experiment.ID)
+			h.writeExperimentJSON(w, http.StatusCreated, experiment)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+		return
+	}
+
+	id := uuid.FromStringOrNil(parts[0])
+	h.experimentLock.Lock()
+	experiment, err := h.config.ExperimentPersister.GetExperiment(id)
+	if err != nil || experiment.UserID != user.UserID {
+		h.experimentLock.Unlock()
+		http.Error(w, "experiment not found", http.StatusNotFound)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "report" && req.Method == http.MethodGet {
+		// the results are fetched from the provider without holding the lock
+		h.experimentLock.Unlock()
+		h.writeExperimentJSON(w, http.StatusOK, experimentReport(req, provider, experiment))
+		return
+	}
+	defer h.experimentLock.Unlock()
+
+	switch {
+	case len(parts) == 1 && req.Method == http.MethodGet:
+		h.writeExperimentJSON(w, http.StatusOK, experiment)
+	case len(parts) == 1 && req.Method == http.MethodDelete:
+		if !experiment.Status.Done() {
+			http.Error(w, "the experiment is running, please cancel it first", http.StatusConflict)
+			return
+		}
+		if err := h.config.ExperimentPersister.DeleteExperiment(id); err != nil {
+			logrus.Error(err)
+			http.Error(w, "unable to delete the experiment", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) == 2 && parts[1] == "cancel" && req.Method == http.MethodPost:
+		if experiment.Status.Done() {
+			http.Error(w, "the experiment is not running", http.StatusConflict)
+			return
+		}
+		// the runner stops once the current run ends
+		experiment.Status = models.LoadTestJobCancelled
+		if err := h.config.ExperimentPersister.WriteExperiment(experiment); err != nil {
+			logrus.Error(err)
+			http.Error(w, "unable to cancel the experiment", http.StatusInternalServerError)
+			return
+		}
+		for _, run := range experiment.Runs {
+			if run.Status == models.LoadTestJobRunning && run.JobID != "" {
+				_ = h.config.LoadTestJobTracker.CancelJob(req.Context(), run.JobID)
+			}
+		}
+		h.writeExperimentJSON(w, http.StatusOK, experiment)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// createExperiment validates the experiment of the request, plans its runs and persists it,
+// it returns false when it already wrote an error to the client
+func (h *Handler) createExperiment(w http.ResponseWriter, req *http.Request, user *models.User, provider models.Provider) (*models.Experiment, bool) {
+	input := &experimentInput{}
+	if err := json.NewDecoder(req.Body).Decode(input); err != nil {
+		logrus.Errorf("Error: unable to parse the experiment: %v", err)
+		http.Error(w, "unable to parse the experiment", http.StatusBadRequest)
+		return nil, false
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		http.Error(w, "please provide a name for the experiment", http.StatusBadRequest)
+		return nil, false
+	}
+	if len(input.Profiles) == 0 {
+		http.Error(w, "please provide at least a performance profile", http.StatusBadRequest)
+		return nil, false
+	}
+	for _, mesh := range input.Meshes {
+		if mesh.URL == "" {
+			continue
+		}
+		// the URL is either an HTTP or a gRPC one, depending on the profiles
+		if validateLoadTestURL(mesh.URL, false) != nil && validateLoadTestURL(mesh.URL, true) != nil {
+			http.Error(w, fmt.Sprintf("invalid load test URL for the mesh %q", mesh.Name), http.StatusBadRequest)
+			return nil, false
+		}
+	}
+	for _, qps := range input.QPS {
+		if qps < 0 {
+			http.Error(w, "please provide valid load levels", http.StatusBadRequest)
+			return nil, false
+		}
+	}
+
+	if err := checkBackgroundLoadTestProvider(provider); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	now := time.Now()
+	experiment := &models.Experiment{
+		ID:          uuid.Must(uuid.NewV4()),
+		Name:        input.Name,
+		Description: input.Description,
+		UserID:      user.UserID,
+		Provider:    provider.Name(),
+		Meshes:      input.Meshes,
+		QPS:         input.QPS,
+		Status:      models.LoadTestJobRunning,
+		CreatedAt:   now,
+	}
+	// the runs are pinned to the current version of the profiles
+	profiles := []*models.PerformanceProfile{}
+	for _, profileID := range input.Profiles {
+		profile, err := h.getPerformanceProfile(req, provider, profileID, "")
+		if err != nil {
+			logrus.Error(err)
+			http.Error(w, fmt.Sprintf("performance profile %s not found", profileID), http.StatusBadRequest)
+			return nil, false
+		}
+		experiment.Profiles = append(experiment.Profiles, profile.ID)
+		profiles = append(profiles, profile)
+	}
+	experiment.PlanRuns(profiles)
+
+	h.experimentLock.Lock()
+	defer h.experimentLock.Unlock()
+	if err := h.config.ExperimentPersister.WriteExperiment(experiment); err != nil {
+		logrus.Error(err)
+		http.Error(w, "unable to save the experiment", http.StatusInternalServerError)
+		return nil, false
+	}
+	return experiment, true
+}
+
+// runExperiment runs the pending runs of the experiment one after the other, until it is cancelled
+func (h *Handler) runExperiment(id uuid.UUID) {
+	for i := 0; ; i++ {
+		h.experimentLock.Lock()
+		experiment, err := h.config.ExperimentPersister.GetExperiment(id)
+		if err != nil {
+			h.experimentLock.Unlock()
+			logrus.Debugf("stopping the deleted experiment %s", id)
+			return
+		}
+		if i >= len(experiment.Runs) || experiment.Status.Done() {
+			h.finishExperiment(experiment)
+			h.experimentLock.Unlock()
+			return
+		}
+		run := experiment.Runs[i]
+		if run.Status != models.ExperimentRunPending {
+			h.experimentLock.Unlock()
+			continue
+		}
+
+		// the run is marked as started and the load test is started without holding the lock, so that the
+		// other requests on the experiments are not held up by it
+		now := time.Now()
+		run.StartTime = &now
+		run.Status = models.LoadTestJobRunning
+		if err := h.config.ExperimentPersister.WriteExperiment(experiment); err != nil {
+			logrus.Error(err)
+		}
+		params := experimentRunParams(experiment, run)
+		h.experimentLock.Unlock()
+
+		job, startErr := h.startLoadTestForUser(params, experiment.UserID, experiment.Provider)
+
+		h.experimentLock.Lock()
+		experiment, err = h.config.ExperimentPersister.GetExperiment(id)
+		if err != nil {
+			h.experimentLock.Unlock()
+			if job != nil {
+				_ = h.config.LoadTestJobTracker.CancelJob(context.Background(), job.ID)
+			}
+			return
+		}
+		run = experiment.Runs[i]
+		if startErr != nil {
+			logrus.Errorf("unable to start the run %d of the experiment %s: %v", i+1, id, startErr)
+			end := time.Now()
+			run.Status = models.LoadTestJobFailed
+			run.Error = startErr.Error()
+			run.EndTime = &end
+		} else {
+			run.JobID = job.ID
+			// the experiment may have been cancelled before the job was known
+			if experiment.Status.Done() {
+				_ = h.config.LoadTestJobTracker.CancelJob(context.Background(), job.ID)
+			}
+		}
+		if err := h.config.ExperimentPersister.WriteExperiment(experiment); err != nil {
+			logrus.Error(err)
+		}
+		h.experimentLock.Unlock()
+		if job == nil {
+			continue
+		}
+
+		job = h.waitLoadTestJob(job.ID)
+		h.experimentLock.Lock()
+		experiment, err = h.config.ExperimentPersister.GetExperiment(id)
+		if err != nil {
+			h.experimentLock.Unlock()
+			return
+		}
+		run = experiment.Runs[i]
+		end := time.Now()
+		run.EndTime = &end
+		if job == nil {
+			run.Status = models.LoadTestJobFailed
+			run.Error = "the load test job is no longer tracked"
+		} else {
+			run.Status = job.Status
+			run.ResultID = job.ResultID
+			if job.Status != models.LoadTestJobCompleted {
+				run.Error = job.Message
+			} else if job.ResultID == "" {
+				// a run without a stored result has nothing to be compared with
+				run.Status = models.LoadTestJobFailed
+				run.Error = "the result of the run was not stored: " + job.Message
+			}
+		}
+		if err := h.config.ExperimentPersister.WriteExperiment(experiment); err != nil {
+			logrus.Error(err)
+		}
+		h.experimentLock.Unlock()
+	}
+}
+
+// finishExperiment records the outcome of the experiment once it ran all its runs or was cancelled, the runs which
+// were not started are cancelled
+func (h *Handler) finishExperiment(experiment *models.Experiment) {
+	now := time.Now()
+	experiment.EndTime = &now
+	if experiment.Status != models.LoadTestJobCancelled {
+		experiment.Status = models.LoadTestJobCompleted
+	}
+	failed := 0
+	for _, run := range experiment.Runs {
+		switch run.Status {
+		case models.ExperimentRunPending:
+			run.Status = models.LoadTestJobCancelled
+		case models.LoadTestJobFailed:
+			failed++
+		}
+	}
+	if failed > 0 && experiment.Status == models.LoadTestJobCompleted {
+		experiment.Status = models.LoadTestJobFailed
+		experiment.Error = fmt.Sprintf("%d of the %d runs failed", failed, len(experiment.Runs))
+	}
+	if err := h.config.ExperimentPersister.WriteExperiment(experiment); err != nil {
+		logrus.Error(err)
+	}
+}
+
+// resumeExperiments restarts the runner of the experiments which were running when Meshery stopped, the runs
+// which were interrupted are recorded as failed
+func (h *Handler) resumeExperiments() {
+	h.experimentLock.Lock()
+	defer h.experimentLock.Unlock()
+
+	experiments, err := h.config.ExperimentPersister.GetExperiments()
+	if err != nil {
+		logrus.Errorf("unable to load the experiments: %v", err)
+		return
+	}
+	for _, experiment := range experiments {
+		if experiment.Status.Done() {
+			continue
+		}
+		for _, run := range experiment.Runs {
+			if run.Status == models.LoadTestJobRunning {
+				run.Status = models.LoadTestJobFailed
+				run.Error = "the load test was interrupted by a restart of Meshery"
+			}
+		}
+		if err := h.config.ExperimentPersister.WriteExperiment(experiment); err != nil {
+			logrus.Error(err)
+			continue
+		}
+		go h.runExperiment(experiment.ID)
+	}
+}
+
+// experimentRunParams returns the parameters of /api/load-test for the run of the experiment
+func experimentRunParams(experiment *models.Experiment, run *models.ExperimentRun) url.Values {
+	mesh := run.Mesh
+	if mesh == "" {
+		mesh = "no mesh"
+	}
+	params := url.Values{}
+	params.Set("name", fmt.Sprintf("%s - %s - %s - %s", experiment.Name, run.ProfileName, mesh, experimentQPSName(run.QPS)))
+	params.Set("profile", run.Profile.String())
+	params.Set("profileVersion", strconv.Itoa(run.ProfileVersion))
+	params.Set("experiment", experiment.ID.String())
+	// the mesh and the load level of the run take precedence over the ones of the profile
+	params.Set("mesh", run.Mesh)
+	params.Set("qps", strconv.FormatFloat(run.QPS, 'f', -1, 64))
+	if run.URL != "" {
+		params.Set("url", run.URL)
+	}
+	return params
+}
+
+func experimentQPSName(qps float64) string {
+	if qps == 0 {
+		return "max qps"
+	}
+	return strconv.FormatFloat(qps, 'f', -1, 64) + " qps"
+}
+
+// experimentReport returns the figures of the results of the runs of the experiment along with the comparisons of
+// its meshes, for each profile and load level, with the first mesh as the baseline
+func experimentReport(req *http.Request, provider models.Provider, experiment *models.Experiment) *models.ExperimentReport {
+	report := &models.ExperimentReport{
+		Experiment:  experiment,
+		Runs:        []*models.ExperimentRunReport{},
+		Comparisons: []*models.ExperimentComparison{},
+	}
+	results := map[*models.ExperimentRun]*models.MesheryResult{}
+	for _, run := range experiment.Runs {
+		runReport := &models.ExperimentRunReport{ExperimentRun: run}
+		report.Runs = append(report.Runs, runReport)
+		resultID := uuid.FromStringOrNil(run.ResultID)
+		if resultID == uuid.Nil {
+			if run.Status == models.LoadTestJobCompleted {
+				runReport.Missing = "the result of the run was not stored"
+			}
+			continue
+		}
+		result, err := provider.GetResult(req, resultID)
+		if err != nil {
+			logrus.Warn(errors.Wrapf(err, "unable to get the result %s of the experiment %s", resultID, experiment.ID))
+			runReport.Missing = fmt.Sprintf("unable to get the result %s of the run", resultID)
+			continue
+		}
+		results[run] = result
+		if summary, err := helpers.SummarizeResult(result); err == nil {
+			summary.Histogram = nil
+			runReport.Summary = summary
+		}
+		if spec, err := result.ConvertToSpec(); err == nil && spec.Metrics != nil && *spec.Metrics != (models.Metrics{}) {
+			runReport.Metrics = spec.Metrics
+		}
+	}
+
+	if len(experiment.Meshes) < 2 {
+		return report
+	}
+	type cell struct {
+		profile uuid.UUID
+		qps     float64
+	}
+	baselines := map[cell]*models.ExperimentRun{}
+	candidates := map[cell][]*models.ExperimentRun{}
+	cells := []cell{}
+	for _, run := range experiment.Runs {
+		c := cell{run.Profile, run.QPS}
+		if _, ok := results[run]; !ok {
+			continue
+		}
+		if run.Mesh == experiment.Meshes[0].Name {
+			if baselines[c] == nil {
+				baselines[c] = run
+				cells = append(cells, c)
+			}
+			continue
+		}
+		candidates[c] = append(candidates[c], run)
+	}
+	for _, c := range cells {
+		if len(candidates[c]) == 0 {
+			continue
+		}
+		base := baselines[c]
+		cands := []*models.MesheryResult{}
+		for _, run := range candidates[c] {
+			cands = append(cands, results[run])
+		}
+		comparison, err := helpers.CompareResults(results[base], cands, models.ResultComparisonOptions{})
+		if err != nil {
+			logrus.Warn(errors.Wrapf(err, "unable to compare the results of the experiment %s", experiment.ID))
+			continue
+		}
+		comparison.Baseline.Histogram = nil
+		for _, diff := range comparison.Candidates {
+			diff.Candidate.Histogram = nil
+		}
+		report.Comparisons = append(report.Comparisons, &models.ExperimentComparison{
+			Profile:      c.profile,
+			ProfileName:  base.ProfileName,
+			QPS:          c.qps,
+			BaselineMesh: base.Mesh,
+			Comparison:   comparison,
+		})
+	}
+	return report
+}
+
+func (h *Handler) writeExperimentJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		logrus.Errorf("error: unable to marshal experiment: %v", err)
+	}
+}
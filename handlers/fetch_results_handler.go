@@ -6,9 +6,10 @@ import (
 
 	"github.com/gofrs/uuid"
 	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/helpers"
 	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
-	"gopkg.in/yaml.v2"
 )
 
 // FetchResultsHandler fetchs pages of results from SaaS and presents it to the UI
@@ -36,9 +37,9 @@ func (h *Handler) FetchResultsHandler(w http.ResponseWriter, req *http.Request,
 	_, _ = w.Write(bdr)
 }
 
-// GetResultHandler gets an individual result from provider
-func (h *Handler) GetResultHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, _ *models.Preference, user *models.User, p models.Provider) {
-	if req.Method != http.MethodGet {
+// GetResultHandler gets an individual result from provider, or deletes it
+func (h *Handler) GetResultHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *models.Preference, user *models.User, p models.Provider) {
+	if req.Method != http.MethodGet && req.Method != http.MethodDelete {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
@@ -56,23 +57,67 @@ func (h *Handler) GetResultHandler(w http.ResponseWriter, req *http.Request, ses
 		return
 	}
 
-	bdr, err := p.GetResult(req, key)
-	if err != nil {
-		http.Error(w, "error while getting load test results", http.StatusInternalServerError)
+	if req.Method == http.MethodDelete {
+		h.deleteResult(w, req, key, prefObj, user, p)
 		return
 	}
-	sp, err := bdr.ConvertToSpec()
+
+	bdr, err := p.GetResult(req, key)
 	if err != nil {
 		http.Error(w, "error while getting load test results", http.StatusInternalServerError)
 		return
 	}
-	w.Header().Set("content-type", "application/yaml")
-	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="result_%s.yaml"`, bdr.ID))
-	b, err := yaml.Marshal(sp)
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format = "yaml"
+	}
+	b, contentType, extension, err := helpers.ExportResult(bdr, format)
 	if err != nil {
-		logrus.Errorf("Error: unable to marshal result: %v", err)
+		logrus.Errorf("Error: unable to export result: %v", err)
+		if errors.Cause(err) == helpers.ErrResultExportUnsupported || !isResultExportFormat(format) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, "error while getting test result", http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("content-type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="result_%s.%s"`, bdr.ID, extension))
 	_, _ = w.Write(b)
 }
+
+func isResultExportFormat(format string) bool {
+	for _, f := range helpers.ResultExportFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteResult deletes the result along with the baselines referring to it
+func (h *Handler) deleteResult(w http.ResponseWriter, req *http.Request, key uuid.UUID, prefObj *models.Preference, user *models.User, p models.Provider) {
+	if err := p.DeleteResult(req, key); err != nil {
+		logrus.Errorf("Error: unable to delete result: %v", err)
+		if err == models.ErrResultNotFound {
+			http.Error(w, "result not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "error while deleting the test result", http.StatusInternalServerError)
+		return
+	}
+
+	updated := false
+	for name, id := range prefObj.ResultBaselines {
+		if id == key.String() {
+			delete(prefObj.ResultBaselines, name)
+			updated = true
+		}
+	}
+	if updated {
+		if err := p.RecordPreferences(req, user.UserID, prefObj); err != nil {
+			logrus.Errorf("Error: unable to remove the baselines of the deleted result: %v", err)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
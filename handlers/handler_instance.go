@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"sync"
+
 	"github.com/layer5io/meshery/models"
 	"github.com/vmihailenco/taskq"
 )
@@ -9,6 +11,11 @@ import (
 type Handler struct {
 	config *models.HandlerConfig
 	task   *taskq.Task
+
+	scheduleTask *taskq.Task
+	scheduleLock *sync.Mutex
+
+	experimentLock *sync.Mutex
 }
 
 // NewHandlerInstance returns a Handler instance
@@ -16,7 +23,9 @@ func NewHandlerInstance(
 	handlerConfig *models.HandlerConfig,
 ) models.HandlerInterface {
 	h := &Handler{
-		config: handlerConfig,
+		config:         handlerConfig,
+		scheduleLock:   &sync.Mutex{},
+		experimentLock: &sync.Mutex{},
 	}
 
 	h.task = handlerConfig.Queue.NewTask(&taskq.TaskOptions{
@@ -24,5 +33,17 @@ func NewHandlerInstance(
 		Handler: h.CollectStaticMetrics,
 	})
 
+	h.scheduleTask = handlerConfig.Queue.NewTask(&taskq.TaskOptions{
+		Name:       "runScheduledLoadTest",
+		Handler:    h.runScheduledLoadTest,
+		RetryLimit: 1,
+	})
+	if handlerConfig.SchedulePersister != nil {
+		h.armLoadTestSchedules()
+	}
+	if handlerConfig.ExperimentPersister != nil {
+		h.resumeExperiments()
+	}
+
 	return h
 }
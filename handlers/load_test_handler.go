@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -74,17 +76,52 @@ func (h *Handler) LoadTestUsingSMPSHandler(w http.ResponseWriter, req *http.Requ
 		loadTestOptions.HTTPNumThreads = benchMark.Client.Connections
 		loadTestOptions.HTTPQPS = benchMark.Client.Rps
 
+		switch strings.ToLower(benchMark.Client.Protocol) {
+		case "", "http", "https":
+		case "grpc":
+			loadTestOptions.IsGRPC = true
+		default:
+			logrus.Errorf("Error: unsupported protocol %s", benchMark.Client.Protocol)
+			http.Error(w, "unsupported protocol, please use http or grpc", http.StatusBadRequest)
+			return
+		}
+		if g := benchMark.Client.GRPC; g != nil {
+			loadTestOptions.GRPCDoPing = g.Ping
+			loadTestOptions.GRPCHealthSvc = g.HealthService
+			loadTestOptions.GRPCStreamsCount = g.Streams
+			if g.PingDelay != "" {
+				if loadTestOptions.GRPCPingDelay, err = time.ParseDuration(g.PingDelay); err != nil {
+					logrus.Errorf("Error: unable to parse the gRPC ping delay: %v", err)
+					http.Error(w, "invalid gRPC ping delay", http.StatusBadRequest)
+					return
+				}
+			}
+		}
+		if t := benchMark.Client.TLS; t != nil {
+			loadTestOptions.CACert = t.CACert
+			loadTestOptions.Cert = t.Cert
+			loadTestOptions.Key = t.Key
+			loadTestOptions.CertOverride = t.ServerName
+		}
 	}
 
 	if loadTestOptions.HTTPNumThreads < 1 {
 		loadTestOptions.HTTPNumThreads = 1
 	}
+	if benchMark.Client != nil {
+		if err = setLoadTestModel(loadTestOptions, benchMark.Client.LoadModel, benchMark.Client.WarmUp); err != nil {
+			logrus.Error(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 
-	ltURL, err := url.Parse(loadTestOptions.URL)
-	if err != nil || !ltURL.IsAbs() {
-		logrus.Errorf("unable to parse the provided load test url: %v", err)
-		http.Error(w, "invalid load test URL", http.StatusBadRequest)
-		return
+	if loadTestOptions.URL != "" || q.Get("scenario") == "" {
+		if err = validateLoadTestURL(loadTestOptions.URL, loadTestOptions.IsGRPC); err != nil {
+			logrus.Errorf("unable to parse the provided load test url: %v", err)
+			http.Error(w, "invalid load test URL", http.StatusBadRequest)
+			return
+		}
 	}
 	loadTestOptions.Name = testName
 
@@ -92,16 +129,62 @@ func (h *Handler) LoadTestUsingSMPSHandler(w http.ResponseWriter, req *http.Requ
 		loadTestOptions.HTTPQPS = 0
 	}
 
-	// loadGenerator := q.Get("loadGenerator")
+	if benchMark.Client != nil && benchMark.Client.Request != nil {
+		r := benchMark.Client.Request
+		if loadTestOptions.IsGRPC {
+			logrus.Error("Error: HTTP request customizations provided for a gRPC load test")
+			http.Error(w, "HTTP request customizations do not apply to gRPC load tests", http.StatusBadRequest)
+			return
+		}
+		headers := http.Header{}
+		for k, v := range r.Headers {
+			headers.Add(k, v)
+		}
+		loadTestOptions.HTTP10 = r.HTTP10
+		loadTestOptions.Compression = r.Compression
+		loadTestOptions.DisableKeepAlive = r.DisableKeepAlive
+		if err = setLoadTestHTTPRequest(loadTestOptions, r.Method, headers, r.ContentType, []byte(r.Body)); err != nil {
+			logrus.Error(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 
-	// switch loadGenerator {
-	// case "wrk2":
-	// 	loadTestOptions.LoadGenerator = models.Wrk2LG
-	// default:
-	loadTestOptions.LoadGenerator = models.FortioLG
-	// }
+	if q.Get("scenario") != "" {
+		if err = setLoadTestScenario(loadTestOptions, []byte(q.Get("scenario"))); err != nil {
+			logrus.Error(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// an internal client generates the load from within the cluster
+	if benchMark.Client != nil && benchMark.Client.Internal {
+		if err = h.setInClusterLoadTest(loadTestOptions, q, prefObj); err != nil {
+			logrus.Error(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if loadTestOptions.SLOs, err = parseLoadTestSLOs(q["slo"], prefObj); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if loadTestOptions.Guardrails, err = parseLoadTestGuardrails(q["guardrail"], loadTestOptions, prefObj); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	h.loadTestHelperHandler(w, req, testName, meshName, testUUID, prefObj, loadTestOptions, provider)
+	if err = h.setLoadGenerator(q.Get("loadGenerator"), loadTestOptions); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.loadTestHelperHandler(w, req, testName, meshName, testUUID, prefObj, user, loadTestOptions, provider)
 }
 
 // LoadTestHandler runs the load test with the given parameters
@@ -110,25 +193,86 @@ func (h *Handler) LoadTestHandler(w http.ResponseWriter, req *http.Request, sess
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
+	lt, status, err := h.parseLoadTestRequest(req, prefObj, provider)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	// q.Set("json", "on")
+
+	// client := &http.Client{}
+	// fortioURL, err := url.Parse(h.config.FortioURL)
+	// if err != nil {
+	// 	logrus.Errorf("unable to parse the provided fortio url: %v", err)
+	// 	http.Error(w, "error while running load test", http.StatusInternalServerError)
+	// 	return
+	// }
+	// fortioURL.RawQuery = q.Encode()
+	// logrus.Infof("load test constructed url: %s", fortioURL.String())
+	// fortioResp, err := client.Get(fortioURL.String())
+	h.loadTestHelperHandler(w, req, lt.testName, lt.meshName, lt.testUUID, prefObj, user, lt.options, provider)
+}
+
+// loadTestRequest is a load test built from the parameters of a request to /api/load-test
+type loadTestRequest struct {
+	testName string
+	meshName string
+	testUUID string
+	options  *models.LoadTestOptions
+}
 
-	err := req.ParseForm()
+// parseLoadTestRequest builds the load test from the parameters of the request, the returned status is the one
+// to answer with when they are invalid
+func (h *Handler) parseLoadTestRequest(req *http.Request, prefObj *models.Preference, provider models.Provider) (*loadTestRequest, int, error) {
+	var err error
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data") {
+		err = req.ParseMultipartForm(maxLoadTestPayloadSize)
+	} else {
+		err = req.ParseForm()
+	}
 	if err != nil {
 		logrus.Errorf("Error: unable to parse form: %v", err)
-		http.Error(w, "unable to process the received data", http.StatusForbidden)
-		return
+		return nil, http.StatusForbidden, errors.New("unable to process the received data")
 	}
 	q := req.URL.Query()
 
-	testName := q.Get("name")
-	if testName == "" {
+	var profile *models.PerformanceProfile
+	if q.Get("profile") != "" {
+		profile, err = h.getPerformanceProfile(req, provider, q.Get("profile"), q.Get("profileVersion"))
+		if err != nil {
+			logrus.Error(err)
+			return nil, http.StatusNotFound, errors.New("performance profile not found")
+		}
+		// the parameters of the request take precedence over the ones of the profile
+		for k, v := range profile.LoadTestParams() {
+			if _, ok := req.Form[k]; !ok {
+				q[k] = v
+				req.Form[k] = v
+			}
+		}
+	}
+
+	lt := &loadTestRequest{
+		testName: q.Get("name"),
+		meshName: q.Get("mesh"),
+		testUUID: q.Get("uuid"),
+	}
+	if lt.testName == "" {
 		logrus.Errorf("Error: name field is blank")
-		http.Error(w, "Provide a name for the test.", http.StatusForbidden)
-		return
+		return nil, http.StatusForbidden, errors.New("Provide a name for the test.")
 	}
-	meshName := q.Get("mesh")
-	testUUID := q.Get("uuid")
 
 	loadTestOptions := &models.LoadTestOptions{}
+	loadTestOptions.PerformanceProfile = profile
+	if q.Get("experiment") != "" {
+		experimentID, err := uuid.FromString(q.Get("experiment"))
+		if err != nil {
+			logrus.Errorf("Error: invalid experiment: %v", err)
+			return nil, http.StatusBadRequest, errors.New("invalid experiment")
+		}
+		loadTestOptions.ExperimentGroup = &experimentID
+	}
 
 	tt, _ := strconv.Atoi(q.Get("t"))
 	if tt < 1 {
@@ -147,11 +291,10 @@ func (h *Handler) LoadTestHandler(w http.ResponseWriter, req *http.Request, sess
 	loadTestOptions.Duration, err = time.ParseDuration(fmt.Sprintf("%d%s", tt, dur))
 	if err != nil {
 		logrus.Errorf("Error: unable to parse load test duration: %v", err)
-		http.Error(w, "unable to process the received data", http.StatusForbidden)
-		return
+		return nil, http.StatusForbidden, errors.New("unable to process the received data")
 	}
 
-	loadTestOptions.IsGRPC = false
+	loadTestOptions.IsGRPC, _ = strconv.ParseBool(q.Get("grpc"))
 
 	cc, _ := strconv.Atoi(q.Get("c"))
 	if cc < 1 {
@@ -159,15 +302,21 @@ func (h *Handler) LoadTestHandler(w http.ResponseWriter, req *http.Request, sess
 	}
 	loadTestOptions.HTTPNumThreads = cc
 
+	scenario, err := readLoadTestFormValue(req, "scenario", "scenarioFile")
+	if err != nil {
+		logrus.Error(err)
+		return nil, http.StatusBadRequest, err
+	}
+	// the url of a scenario test defaults to the one of its first endpoint
 	loadTestURL := q.Get("url")
-	ltURL, err := url.Parse(loadTestURL)
-	if err != nil || !ltURL.IsAbs() {
-		logrus.Errorf("unable to parse the provided load test url: %v", err)
-		http.Error(w, "invalid load test URL", http.StatusBadRequest)
-		return
+	if loadTestURL != "" || len(scenario) == 0 {
+		if err = validateLoadTestURL(loadTestURL, loadTestOptions.IsGRPC); err != nil {
+			logrus.Errorf("unable to parse the provided load test url: %v", err)
+			return nil, http.StatusBadRequest, errors.New("invalid load test URL")
+		}
 	}
 	loadTestOptions.URL = loadTestURL
-	loadTestOptions.Name = testName
+	loadTestOptions.Name = lt.testName
 
 	qps, _ := strconv.ParseFloat(q.Get("qps"), 64)
 	if qps < 0 {
@@ -175,32 +324,595 @@ func (h *Handler) LoadTestHandler(w http.ResponseWriter, req *http.Request, sess
 	}
 	loadTestOptions.HTTPQPS = qps
 
-	loadGenerator := q.Get("loadGenerator")
+	loadTestOptions.HTTP2, _ = strconv.ParseBool(q.Get("http2"))
+
+	if err = setLoadTestModel(loadTestOptions, q.Get("loadModel"), q.Get("warmUp")); err != nil {
+		logrus.Error(err)
+		return nil, http.StatusBadRequest, err
+	}
+
+	// the request customizations are also accepted in the form body, as they can get too large for the query
+	headers, err := parseLoadTestHeaders(req.Form["headers"])
+	if err != nil {
+		logrus.Error(err)
+		return nil, http.StatusBadRequest, err
+	}
+	payload, err := readLoadTestFormValue(req, "body", "payload")
+	if err != nil {
+		logrus.Error(err)
+		return nil, http.StatusBadRequest, err
+	}
+	if loadTestOptions.IsGRPC {
+		if len(headers) > 0 || req.FormValue("method") != "" || req.FormValue("contentType") != "" ||
+			req.FormValue("http10") != "" || req.FormValue("compression") != "" || req.FormValue("keepAlive") != "" {
+			logrus.Error("Error: HTTP request customizations provided for a gRPC load test")
+			return nil, http.StatusBadRequest, errors.New("HTTP request customizations do not apply to gRPC load tests")
+		}
+		loadTestOptions.GRPCDoPing, _ = strconv.ParseBool(q.Get("grpcPing"))
+		loadTestOptions.GRPCHealthSvc = q.Get("grpcHealthSvc")
+		loadTestOptions.GRPCStreamsCount, _ = strconv.Atoi(q.Get("grpcStreams"))
+		if q.Get("grpcPingDelay") != "" {
+			if loadTestOptions.GRPCPingDelay, err = time.ParseDuration(q.Get("grpcPingDelay")); err != nil {
+				logrus.Errorf("Error: unable to parse the gRPC ping delay: %v", err)
+				return nil, http.StatusBadRequest, errors.New("invalid gRPC ping delay")
+			}
+		}
+		// the body is sent along with the pings
+		if len(payload) > 0 {
+			loadTestOptions.Payload = payload
+		}
+	} else {
+		loadTestOptions.HTTP10, _ = strconv.ParseBool(req.FormValue("http10"))
+		loadTestOptions.Compression, _ = strconv.ParseBool(req.FormValue("compression"))
+		if ka := req.FormValue("keepAlive"); ka != "" {
+			keepAlive, _ := strconv.ParseBool(ka)
+			loadTestOptions.DisableKeepAlive = !keepAlive
+		}
+		if err = setLoadTestHTTPRequest(loadTestOptions, req.FormValue("method"), headers, req.FormValue("contentType"), payload); err != nil {
+			logrus.Error(err)
+			return nil, http.StatusBadRequest, err
+		}
+	}
+
+	// the TLS material is either given inline or as uploaded files
+	for _, tls := range []struct {
+		field string
+		value *string
+	}{
+		{"caCert", &loadTestOptions.CACert},
+		{"cert", &loadTestOptions.Cert},
+		{"key", &loadTestOptions.Key},
+	} {
+		pem, err := readLoadTestFormValue(req, tls.field, tls.field)
+		if err != nil {
+			logrus.Error(err)
+			return nil, http.StatusBadRequest, err
+		}
+		*tls.value = string(pem)
+	}
+	if (loadTestOptions.Cert == "") != (loadTestOptions.Key == "") {
+		logrus.Error("Error: only one of the client certificate and key was provided")
+		return nil, http.StatusBadRequest, errors.New("please provide both the client certificate and key")
+	}
+	loadTestOptions.CertOverride = q.Get("certOverride")
+
+	if len(scenario) > 0 {
+		if err = setLoadTestScenario(loadTestOptions, scenario); err != nil {
+			logrus.Error(err)
+			return nil, http.StatusBadRequest, err
+		}
+	}
+
+	if q.Get("stages") != "" {
+		loadTestOptions.Stages, err = parseLoadTestStages(q.Get("stages"))
+		if err != nil {
+			logrus.Error(err)
+			return nil, http.StatusBadRequest, err
+		}
+		loadTestOptions.Duration = loadTestOptions.TotalDuration()
+	}
+
+	loadTestOptions.Distributed, _ = strconv.ParseBool(q.Get("distributed"))
+	if loadTestOptions.Distributed && len(loadTestOptions.Stages) > 0 {
+		logrus.Error("Error: multi-stage load profiles are not supported in distributed mode")
+		return nil, http.StatusBadRequest, errors.New("multi-stage load profiles are not supported in distributed mode")
+	}
+	if loadTestOptions.Distributed && len(h.config.LoadTestWorkersTracker.GetWorkers(req.Context())) == 0 {
+		logrus.Error("Error: distributed load test requested without any registered workers")
+		return nil, http.StatusBadRequest, errors.New("no load test workers are registered")
+	}
+
+	if inCluster, _ := strconv.ParseBool(q.Get("inCluster")); inCluster {
+		if err = h.setInClusterLoadTest(loadTestOptions, q, prefObj); err != nil {
+			logrus.Error(err)
+			return nil, http.StatusBadRequest, err
+		}
+	}
+
+	if q.Get("percentiles") != "" {
+		for _, ps := range strings.Split(q.Get("percentiles"), ",") {
+			p, err := strconv.ParseFloat(strings.TrimSpace(ps), 64)
+			if err != nil || p <= 0 || p >= 100 {
+				logrus.Errorf("invalid percentile: %s", ps)
+				return nil, http.StatusBadRequest, errors.New("please provide valid percentiles")
+			}
+			loadTestOptions.Percentiles = append(loadTestOptions.Percentiles, p)
+		}
+	}
+
+	if loadTestOptions.SLOs, err = parseLoadTestSLOs(q["slo"], prefObj); err != nil {
+		logrus.Error(err)
+		return nil, http.StatusBadRequest, err
+	}
+	if loadTestOptions.Guardrails, err = parseLoadTestGuardrails(q["guardrail"], loadTestOptions, prefObj); err != nil {
+		logrus.Error(err)
+		return nil, http.StatusBadRequest, err
+	}
+
+	if err = h.setLoadGenerator(q.Get("loadGenerator"), loadTestOptions); err != nil {
+		logrus.Error(err)
+		return nil, http.StatusBadRequest, err
+	}
+	lt.options = loadTestOptions
+	return lt, http.StatusOK, nil
+}
+
+// maxLoadTestPayloadSize is the largest request body accepted for the requests of a load test
+const maxLoadTestPayloadSize = 10 << 20
+
+// parseLoadTestHeaders parses headers given in the "Key: Value" form
+func parseLoadTestHeaders(hs []string) (http.Header, error) {
+	headers := http.Header{}
+	for _, hdr := range hs {
+		kv := strings.SplitN(hdr, ":", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid header %q, expecting Key: Value", hdr)
+		}
+		headers.Add(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+	}
+	return headers, nil
+}
+
+// readLoadTestFormValue returns the value given inline in the form field or uploaded in the file field
+func readLoadTestFormValue(req *http.Request, field, fileField string) ([]byte, error) {
+	value := []byte(req.FormValue(field))
+	if req.MultipartForm == nil || len(req.MultipartForm.File[fileField]) == 0 {
+		return value, nil
+	}
+	if len(value) > 0 {
+		return nil, fmt.Errorf("please provide %s either inline or as a file", field)
+	}
+	file, _, err := req.FormFile(fileField)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open the %s file", fileField)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	value, err = ioutil.ReadAll(io.LimitReader(file, maxLoadTestPayloadSize+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the %s file", fileField)
+	}
+	if len(value) > maxLoadTestPayloadSize {
+		return nil, fmt.Errorf("the %s file is larger than %d bytes", fileField, maxLoadTestPayloadSize)
+	}
+	return value, nil
+}
+
+// parseLoadTestSLOs parses the SLO assertions, the Prometheus ones need Prometheus to be configured
+func parseLoadTestSLOs(exprs []string, prefObj *models.Preference) ([]*models.SLOAssertion, error) {
+	slos := []*models.SLOAssertion{}
+	for _, expr := range exprs {
+		a, err := models.ParseSLOAssertion(expr)
+		if err != nil {
+			return nil, err
+		}
+		if (a.Metric == models.SLOPrometheusMax || a.Metric == models.SLOPrometheusAvg) &&
+			(prefObj.Prometheus == nil || prefObj.Prometheus.PrometheusURL == "") {
+			return nil, fmt.Errorf("SLO assertion %q needs Prometheus to be configured", expr)
+		}
+		slos = append(slos, a)
+	}
+	return slos, nil
+}
+
+// parseLoadTestGuardrails parses the guardrails of the load test, which are only checked when the load is generated
+// by Meshery itself, the Prometheus ones need Prometheus to be configured
+func parseLoadTestGuardrails(exprs []string, loadTestOptions *models.LoadTestOptions, prefObj *models.Preference) ([]*models.LoadTestGuardrail, error) {
+	guardrails := []*models.LoadTestGuardrail{}
+	for _, expr := range exprs {
+		g, err := models.ParseLoadTestGuardrail(expr)
+		if err != nil {
+			return nil, err
+		}
+		if g.IsPrometheus() && (prefObj.Prometheus == nil || prefObj.Prometheus.PrometheusURL == "") {
+			return nil, fmt.Errorf("guardrail %q needs Prometheus to be configured", expr)
+		}
+		guardrails = append(guardrails, g)
+	}
+	if len(guardrails) > 0 && (loadTestOptions.Distributed || loadTestOptions.InCluster != nil) {
+		return nil, errors.New("guardrails are not supported for distributed and in-cluster load tests")
+	}
+	return guardrails, nil
+}
+
+// validateLoadTestURL checks the load test target, which for gRPC can also be given as host:port
+func validateLoadTestURL(loadTestURL string, grpc bool) error {
+	if grpc && !strings.Contains(loadTestURL, "://") {
+		host, port, err := net.SplitHostPort(loadTestURL)
+		if err != nil {
+			return err
+		}
+		if host == "" || port == "" {
+			return fmt.Errorf("invalid gRPC destination %s", loadTestURL)
+		}
+		return nil
+	}
+	ltURL, err := url.Parse(loadTestURL)
+	if err != nil {
+		return err
+	}
+	if !ltURL.IsAbs() || ltURL.Host == "" {
+		return fmt.Errorf("%s is not an absolute URL", loadTestURL)
+	}
+	return nil
+}
 
-	switch loadGenerator {
-	case models.Wrk2LG.Name():
-		loadTestOptions.LoadGenerator = models.Wrk2LG
+// setLoadTestHTTPRequest validates and sets the customizations of the requests sent during the load test,
+// the method defaults to POST when a body is given and to GET otherwise
+func setLoadTestHTTPRequest(loadTestOptions *models.LoadTestOptions, method string, headers http.Header, contentType string, payload []byte) error {
+	method = strings.ToUpper(strings.TrimSpace(method))
+	switch method {
+	case "":
+		if len(payload) > 0 || contentType != "" {
+			method = http.MethodPost
+		}
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodTrace:
 	default:
-		loadTestOptions.LoadGenerator = models.FortioLG
+		return fmt.Errorf("invalid HTTP method %s", method)
+	}
+	if len(payload) > maxLoadTestPayloadSize {
+		return fmt.Errorf("the request body is larger than %d bytes", maxLoadTestPayloadSize)
+	}
+	if len(headers) > 0 {
+		loadTestOptions.HTTPHeaders = headers
 	}
+	loadTestOptions.HTTPMethod = method
+	loadTestOptions.ContentType = contentType
+	loadTestOptions.Payload = payload
+	if len(payload) == 0 {
+		loadTestOptions.Payload = nil
+	}
+	return nil
+}
 
-	// q.Set("json", "on")
+// setLoadTestModel sets the load model, either closed or open, and the duration of the warm-up like 30s on the
+// options, an empty model leaves it to the load generator
+func setLoadTestModel(loadTestOptions *models.LoadTestOptions, loadModel, warmUp string) error {
+	switch models.LoadModel(strings.ToLower(loadModel)) {
+	case "":
+	case models.ClosedLoadModel:
+		loadTestOptions.LoadModel = models.ClosedLoadModel
+	case models.OpenLoadModel:
+		loadTestOptions.LoadModel = models.OpenLoadModel
+	default:
+		return fmt.Errorf("unknown load model: %s, expecting closed or open", loadModel)
+	}
+	if warmUp != "" {
+		d, err := time.ParseDuration(warmUp)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid warm-up duration: %s", warmUp)
+		}
+		loadTestOptions.WarmUp = d
+	}
+	return nil
+}
 
-	// client := &http.Client{}
-	// fortioURL, err := url.Parse(h.config.FortioURL)
-	// if err != nil {
-	// 	logrus.Errorf("unable to parse the provided fortio url: %v", err)
-	// 	http.Error(w, "error while running load test", http.StatusInternalServerError)
-	// 	return
-	// }
-	// fortioURL.RawQuery = q.Encode()
-	// logrus.Infof("load test constructed url: %s", fortioURL.String())
-	// fortioResp, err := client.Get(fortioURL.String())
-	h.loadTestHelperHandler(w, req, testName, meshName, testUUID, prefObj, loadTestOptions, provider)
+// loadTestStageInput is the form in which the stages of a load profile are received,
+// eg. [{"name": "ramp-up", "qps": 100, "c": 10, "ramp": "30s", "t": "1m"}]
+type loadTestStageInput struct {
+	Name string  `json:"name,omitempty"`
+	QPS  float64 `json:"qps"`
+	C    int     `json:"c"`
+	Ramp string  `json:"ramp,omitempty"`
+	T    string  `json:"t,omitempty"`
+}
+
+func parseLoadTestStages(stagesJSON string) ([]*models.LoadTestStage, error) {
+	inputs := []*loadTestStageInput{}
+	if err := json.Unmarshal([]byte(stagesJSON), &inputs); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the load profile stages")
+	}
+	stages := []*models.LoadTestStage{}
+	for i, in := range inputs {
+		stage := &models.LoadTestStage{
+			Name:        in.Name,
+			QPS:         in.QPS,
+			Connections: in.C,
+		}
+		var err error
+		if in.Ramp != "" {
+			if stage.Ramp, err = time.ParseDuration(in.Ramp); err != nil {
+				return nil, errors.Wrapf(err, "unable to parse the ramp of stage %d", i+1)
+			}
+		}
+		if in.T != "" {
+			if stage.Duration, err = time.ParseDuration(in.T); err != nil {
+				return nil, errors.Wrapf(err, "unable to parse the duration of stage %d", i+1)
+			}
+		}
+		if stage.QPS < 0 || stage.Ramp < 0 || stage.Duration < 0 {
+			return nil, fmt.Errorf("stage %d has negative values", i+1)
+		}
+		if stage.QPS == 0 && stage.Duration > 0 {
+			return nil, fmt.Errorf("stage %d holds a load of 0 qps, only a ramp is allowed to 0 qps", i+1)
+		}
+		if stage.Connections < 1 {
+			stage.Connections = 1
+		}
+		stages = append(stages, stage)
+	}
+	if len(stages) == 0 {
+		return nil, errors.New("the load profile does not contain any stages")
+	}
+	return stages, nil
+}
+
+// loadTestEndpointInput is the form in which the endpoints of a scenario are received, eg.
+// [{"name": "catalog", "weight": 70, "url": "http://shop/catalog"},
+// {"name": "item", "weight": 20, "url": "http://shop/item/{id}", "values": ["1", "2"]},
+// {"name": "cart", "weight": 10, "url": "http://shop/cart", "method": "POST", "body": "{}"}]
+type loadTestEndpointInput struct {
+	Name        string            `json:"name,omitempty"`
+	Weight      float64           `json:"weight"`
+	URL         string            `json:"url"`
+	Values      []string          `json:"values,omitempty"`
+	Method      string            `json:"method,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+	Body        string            `json:"body,omitempty"`
+}
+
+// setLoadTestScenario parses the endpoints of the scenario the requests of the load test are spread across, the
+// requests to each endpoint being customized by the endpoint instead of the options of the test
+func setLoadTestScenario(loadTestOptions *models.LoadTestOptions, scenarioJSON []byte) error {
+	if loadTestOptions.IsGRPC {
+		return errors.New("scenarios are not supported for gRPC load tests")
+	}
+	if loadTestOptions.HTTPMethod != "" || len(loadTestOptions.Payload) > 0 || loadTestOptions.ContentType != "" {
+		return errors.New("the method and body of the requests of a scenario are given by its endpoints")
+	}
+	inputs := []*loadTestEndpointInput{}
+	if err := json.Unmarshal(scenarioJSON, &inputs); err != nil {
+		return errors.Wrap(err, "unable to parse the scenario")
+	}
+	names := map[string]bool{}
+	endpoints := []*models.LoadTestEndpoint{}
+	for i, in := range inputs {
+		headers := http.Header{}
+		for k, v := range in.Headers {
+			headers.Add(k, v)
+		}
+		reqOpts := &models.LoadTestOptions{}
+		if err := setLoadTestHTTPRequest(reqOpts, in.Method, headers, in.ContentType, []byte(in.Body)); err != nil {
+			return errors.Wrapf(err, "invalid endpoint %d", i+1)
+		}
+		e := &models.LoadTestEndpoint{
+			Name:        in.Name,
+			Weight:      in.Weight,
+			URL:         strings.TrimSpace(in.URL),
+			Values:      in.Values,
+			HTTPMethod:  reqOpts.HTTPMethod,
+			HTTPHeaders: reqOpts.HTTPHeaders,
+			ContentType: reqOpts.ContentType,
+			Payload:     reqOpts.Payload,
+		}
+		if e.Name == "" {
+			e.Name = e.Method() + " " + e.URL
+		}
+		if names[e.Name] {
+			return fmt.Errorf("the scenario has several endpoints named %s", e.Name)
+		}
+		names[e.Name] = true
+		if e.Weight <= 0 {
+			return fmt.Errorf("endpoint %s needs a positive weight", e.Name)
+		}
+		if len(e.Values) == 0 && helpers.ScenarioEndpointURL(e, "") != e.URL {
+			return fmt.Errorf("endpoint %s needs values for the placeholders of its url", e.Name)
+		}
+		value := ""
+		if len(e.Values) > 0 {
+			value = e.Values[0]
+		}
+		if err := validateLoadTestURL(helpers.ScenarioEndpointURL(e, value), false); err != nil {
+			return errors.Wrapf(err, "invalid url for endpoint %s", e.Name)
+		}
+		endpoints = append(endpoints, e)
+	}
+	if len(endpoints) == 0 {
+		return errors.New("the scenario does not contain any endpoints")
+	}
+	loadTestOptions.Scenario = endpoints
+	if loadTestOptions.URL == "" {
+		value := ""
+		if len(endpoints[0].Values) > 0 {
+			value = endpoints[0].Values[0]
+		}
+		loadTestOptions.URL = helpers.ScenarioEndpointURL(endpoints[0], value)
+	}
+	return nil
+}
+
+// setInClusterLoadTest has the load generated by a Kubernetes job in the namespace given by the namespace parameter,
+// with the sidecar of the mesh injected when the injectSidecar parameter is set
+func (h *Handler) setInClusterLoadTest(loadTestOptions *models.LoadTestOptions, q url.Values, prefObj *models.Preference) error {
+	if loadTestOptions.Distributed {
+		return errors.New("in-cluster load generation is not supported in distributed mode")
+	}
+	if prefObj.K8SConfig == nil || (len(prefObj.K8SConfig.Config) == 0 && !prefObj.K8SConfig.InClusterConfig) {
+		return errors.New("please configure the kubernetes cluster the load is generated from")
+	}
+	loadTestOptions.InCluster = &models.InClusterLoadTest{
+		Namespace: q.Get("namespace"),
+		Image:     h.config.LoadTestJobImage,
+	}
+	loadTestOptions.InCluster.InjectSidecar, _ = strconv.ParseBool(q.Get("injectSidecar"))
+	return nil
+}
+
+// getLoadGenerator returns the registered load generator with the given name, defaulting to fortio
+func (h *Handler) getLoadGenerator(name string) (models.LoadGenerator, error) {
+	if name == "" {
+		name = models.FortioLG.Name()
+	}
+	lg, ok := h.config.LoadGenerators[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown load generator: %s", name)
+	}
+	return lg, nil
+}
+
+// setLoadGenerator sets the load generator on the options after checking it supports them
+func (h *Handler) setLoadGenerator(name string, loadTestOptions *models.LoadTestOptions) error {
+	lg, err := h.getLoadGenerator(name)
+	if err != nil {
+		return err
+	}
+	loadTestOptions.LoadGenerator = models.LoadGeneratorType(lg.Name())
+	capabilities := lg.Capabilities()
+	if loadTestOptions.LoadModel == "" {
+		loadTestOptions.LoadModel = capabilities.DefaultLoadModel()
+	}
+	return capabilities.ValidateOptions(loadTestOptions)
 }
 
 func (h *Handler) loadTestHelperHandler(w http.ResponseWriter, req *http.Request, testName, meshName, testUUID string,
-	prefObj *models.Preference, loadTestOptions *models.LoadTestOptions, provider models.Provider) {
+	prefObj *models.Preference, user *models.User, loadTestOptions *models.LoadTestOptions, provider models.Provider) {
+	log := logrus.WithField("file", "load_test_handler")
+
+	job, err := h.startLoadTestJob(req, testName, meshName, testUUID, prefObj, user, loadTestOptions, provider, false)
+	if err != nil {
+		log.Error(err)
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	if async, _ := strconv.ParseBool(req.URL.Query().Get("async")); async {
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		if err := json.NewEncoder(w).Encode(job); err != nil {
+			log.Errorf("error: unable to marshal load test job: %v", err)
+		}
+		return
+	}
+	h.streamLoadTestJob(w, req, job.ID)
+}
+
+// startLoadTestJob queues the load test as a job run in the background for the user and returns it, the results
+// are published to the provider with the given request. The test is unattended when it is run on behalf of a user
+// who is not around, its results then being stored whatever the sharing preferences of the user
+func (h *Handler) startLoadTestJob(req *http.Request, testName, meshName, testUUID string, prefObj *models.Preference,
+	user *models.User, loadTestOptions *models.LoadTestOptions, provider models.Provider, unattended bool) (*models.LoadTestJob, error) {
+	log := logrus.WithField("file", "load_test_handler")
+
+	// the load test is run as a job which outlives the client connection, it is only stopped by an explicit cancel
+	ctx, cancel := context.WithCancel(context.Background())
+	jobID := uuid.Must(uuid.NewV4()).String()
+	job := &models.LoadTestJob{
+		ID:     jobID,
+		Name:   testName,
+		Mesh:   meshName,
+		UserID: user.UserID,
+		Status: models.LoadTestJobQueued,
+	}
+	// the tests wait in the run queue for their turn, so that they do not compete with each other for the CPU
+	// and skew each other's results
+	if err := h.config.LoadTestQueue.Enqueue(req.Context(), job); err != nil {
+		cancel()
+		return nil, err
+	}
+	h.config.LoadTestJobTracker.AddJob(ctx, job, cancel)
+	log.Debugf("created load test job: %s", jobID)
+
+	respChan := make(chan *models.LoadTestResponse, 100)
+	go func() {
+		defer cancel()
+		for data := range respChan {
+			h.config.LoadTestJobTracker.Publish(context.Background(), jobID, data)
+		}
+		h.config.LoadTestJobTracker.FinishJob(context.Background(), jobID)
+		log.Debugf("load test job %s finished", jobID)
+	}()
+	go func() {
+		defer close(respChan)
+		defer h.config.LoadTestQueue.Release(context.Background(), jobID)
+		err := h.config.LoadTestQueue.Wait(ctx, jobID, func(position int) {
+			respChan <- &models.LoadTestResponse{
+				Status:        models.LoadTestQueued,
+				Message:       fmt.Sprintf("Waiting for the load tests ahead in the run queue, position %d", position),
+				QueuePosition: position,
+			}
+		})
+		if err != nil {
+			logrus.Error(errors.Wrap(err, "load test cancelled while queued"))
+			respChan <- &models.LoadTestResponse{
+				Status:  models.LoadTestError,
+				Message: "load test cancelled",
+			}
+			return
+		}
+		h.config.LoadTestJobTracker.StartJob(context.Background(), jobID)
+		h.executeLoadTest(ctx, req, testName, meshName, testUUID, prefObj, provider, loadTestOptions, unattended, respChan)
+	}()
+
+	job, _ = h.config.LoadTestJobTracker.GetJob(req.Context(), jobID)
+	return job, nil
+}
+
+// startLoadTestForUser starts a load test in the background on behalf of a user who is not around to make the
+// request, with the parameters of /api/load-test, the preferences of the user and none of their credentials
+func (h *Handler) startLoadTestForUser(params url.Values, userID, providerName string) (*models.LoadTestJob, error) {
+	provider, ok := h.config.Providers[providerName]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q", providerName)
+	}
+	if err := checkBackgroundLoadTestProvider(provider); err != nil {
+		return nil, err
+	}
+	prefObj, err := provider.ReadFromPersister(userID)
+	if err != nil {
+		logrus.Warnf("unable to read the preferences of the user %s: %v", userID, err)
+	}
+	if prefObj == nil {
+		prefObj = &models.Preference{
+			AnonymousUsageStats:  true,
+			AnonymousPerfResults: true,
+		}
+	}
+
+	// the request only carries the parameters of the test, the provider identifies the user by itself
+	req, err := http.NewRequest(http.MethodGet, "/api/load-test?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	lt, _, err := h.parseLoadTestRequest(req, prefObj, provider)
+	if err != nil {
+		return nil, err
+	}
+	return h.startLoadTestJob(req, lt.testName, lt.meshName, lt.testUUID, prefObj, &models.User{UserID: userID}, lt.options, provider, true)
+}
+
+// checkBackgroundLoadTestProvider checks that the load tests of the users of the provider can be run without them,
+// the remote provider needs the session of the user to fetch the profiles and to publish the results
+func checkBackgroundLoadTestProvider(provider models.Provider) error {
+	if provider.GetProviderType() != models.LocalProviderType {
+		return fmt.Errorf("scheduled load tests and experiments are only supported with the local provider: the %s provider publishes the results with the session of the user, which unattended runs do not have", provider.Name())
+	}
+	return nil
+}
+
+// streamLoadTestJob streams the responses of a load test job as server sent events until the job ends or the client goes away
+func (h *Handler) streamLoadTestJob(w http.ResponseWriter, req *http.Request, jobID string) {
 	log := logrus.WithField("file", "load_test_handler")
 
 	flusher, ok := w.(http.Flusher)
@@ -209,71 +921,167 @@ func (h *Handler) loadTestHelperHandler(w http.ResponseWriter, req *http.Request
 		http.Error(w, "Event streaming is not supported at the moment.", http.StatusInternalServerError)
 		return
 	}
+
+	respChan, unsubscribe, err := h.config.LoadTestJobTracker.Subscribe(req.Context(), jobID)
+	if err != nil {
+		log.Error(err)
+		http.Error(w, "load test job not found", http.StatusNotFound)
+		return
+	}
+	defer unsubscribe()
+
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	notify := w.(http.CloseNotifier).CloseNotify()
-	respChan := make(chan *models.LoadTestResponse, 100)
-	endChan := make(chan struct{})
-	defer close(endChan)
-
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Errorf("Recovered from panic: %v.", r)
+	for {
+		select {
+		case <-notify:
+			log.Debugf("client went away, load test job %s continues to run", jobID)
+			return
+		case data, ok := <-respChan:
+			if !ok {
+				// the stream of a subscriber which fell behind also ends here, after a dropped response
+				log.Debugf("event stream of load test job %s ended", jobID)
+				return
 			}
-		}()
-		for data := range respChan {
 			bd, err := json.Marshal(data)
 			if err != nil {
 				logrus.Errorf("error: unable to marshal meshery result for shipping: %v", err)
-				http.Error(w, "error while running load test", http.StatusInternalServerError)
 				return
 			}
 
 			log.Debug("received new data on response channel")
 			_, _ = fmt.Fprintf(w, "data: %s\n\n", bd)
-			if flusher != nil {
-				flusher.Flush()
-				log.Debugf("Flushed the messages on the wire...")
+			flusher.Flush()
+			log.Debugf("Flushed the messages on the wire...")
+		}
+	}
+}
+
+// LoadTestJobHandler is used for listing, polling, cancelling and re-attaching to load test jobs
+func (h *Handler) LoadTestJobHandler(w http.ResponseWriter, req *http.Request, _ *sessions.Session, _ *models.Preference, user *models.User, _ models.Provider) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, "/api/load-test/"), "/"), "/")
+	jobID := parts[0]
+
+	if jobID == "" {
+		if req.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		jobs := []*models.LoadTestJob{}
+		for _, job := range h.config.LoadTestJobTracker.GetJobs(req.Context()) {
+			if job.UserID == user.UserID {
+				jobs = append(jobs, job)
 			}
 		}
-		endChan <- struct{}{}
-		log.Debug("response channel closed")
-	}()
-	go func() {
-		h.executeLoadTest(req, testName, meshName, testUUID, prefObj, provider, loadTestOptions, respChan)
-		close(respChan)
-	}()
-	select {
-	case <-notify:
-		log.Debugf("received signal to close connection and channels")
-		break
-	case <-endChan:
-		log.Debugf("load test completed")
+		h.writeLoadTestJobJSON(w, jobs)
+		return
+	}
+
+	job, ok := h.config.LoadTestJobTracker.GetJob(req.Context(), jobID)
+	if !ok || job.UserID != user.UserID {
+		http.Error(w, "load test job not found", http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && req.Method == http.MethodGet:
+		h.writeLoadTestJobJSON(w, job)
+	case len(parts) == 1 && req.Method == http.MethodDelete:
+		if err := h.config.LoadTestJobTracker.CancelJob(req.Context(), jobID); err != nil {
+			logrus.Error(err)
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		job, _ = h.config.LoadTestJobTracker.GetJob(req.Context(), jobID)
+		h.writeLoadTestJobJSON(w, job)
+	case len(parts) == 2 && parts[1] == "events" && req.Method == http.MethodGet:
+		h.streamLoadTestJob(w, req, jobID)
+	default:
+		w.WriteHeader(http.StatusNotFound)
 	}
 }
 
-func (h *Handler) executeLoadTest(req *http.Request, testName, meshName, testUUID string, prefObj *models.Preference, provider models.Provider, loadTestOptions *models.LoadTestOptions, respChan chan *models.LoadTestResponse) {
+// LoadTestQueueHandler lists the load tests running and waiting in the run queue, those of all the users as they
+// all share the same queue
+func (h *Handler) LoadTestQueueHandler(w http.ResponseWriter, req *http.Request, _ *sessions.Session, _ *models.Preference, _ *models.User, _ models.Provider) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	h.writeLoadTestJobJSON(w, h.config.LoadTestQueue.GetEntries(req.Context()))
+}
+
+func (h *Handler) writeLoadTestJobJSON(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		logrus.Errorf("error: unable to marshal load test job: %v", err)
+		http.Error(w, "unable to marshal load test job", http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) executeLoadTest(ctx context.Context, req *http.Request, testName, meshName, testUUID string, prefObj *models.Preference, provider models.Provider,
+	loadTestOptions *models.LoadTestOptions, unattended bool, respChan chan *models.LoadTestResponse) {
 	respChan <- &models.LoadTestResponse{
 		Status:  models.LoadTestInfo,
 		Message: "Initiating load test . . . ",
 	}
-	// resultsMap, resultInst, err := helpers.FortioLoadTest(loadTestOptions)
 	var (
 		resultsMap map[string]interface{}
 		resultInst *periodic.RunnerResults
-		err        error
+		abort      *models.LoadTestAbort
 	)
-	if loadTestOptions.LoadGenerator == models.Wrk2LG {
-		resultsMap, resultInst, err = helpers.WRK2LoadTest(loadTestOptions)
-	} else {
-		resultsMap, resultInst, err = helpers.FortioLoadTest(loadTestOptions)
+	var promURL string
+	if prefObj.Prometheus != nil {
+		promURL = prefObj.Prometheus.PrometheusURL
+	}
+	if loadTestOptions.WarmUp > 0 {
+		respChan <- &models.LoadTestResponse{
+			Status:  models.LoadTestInfo,
+			Message: fmt.Sprintf("Warming up for %v, the samples of the warm-up are left out of the results", loadTestOptions.WarmUp),
+		}
+	}
+	lg, err := h.getLoadGenerator(loadTestOptions.LoadGenerator.Name())
+	if err == nil {
+		switch {
+		case loadTestOptions.InCluster != nil:
+			respChan <- &models.LoadTestResponse{
+				Status:  models.LoadTestInfo,
+				Message: "Running the load generator as a Kubernetes job",
+			}
+			stopProgress := publishLoadTestElapsedTime(respChan)
+			resultsMap, resultInst, err = helpers.InClusterLoadTest(ctx, prefObj.K8SConfig.Config, prefObj.K8SConfig.ContextName, loadTestOptions, func(line string) {
+				respChan <- &models.LoadTestResponse{
+					Status:  models.LoadTestInfo,
+					Message: line,
+				}
+			})
+			stopProgress()
+		case loadTestOptions.Distributed:
+			workers := h.config.LoadTestWorkersTracker.GetWorkers(ctx)
+			respChan <- &models.LoadTestResponse{
+				Status:  models.LoadTestInfo,
+				Message: fmt.Sprintf("Distributing the load test across %d workers", len(workers)),
+			}
+			stopProgress := publishLoadTestElapsedTime(respChan)
+			resultsMap, resultInst, err = helpers.DistributedLoadTest(ctx, workers, h.config.LoadTestWorkerToken, loadTestOptions)
+			stopProgress()
+		default:
+			resultsMap, resultInst, abort, err = h.runLoadGenerator(ctx, lg, loadTestOptions, promURL, respChan)
+		}
 	}
 	if err != nil {
 		msg := "error: unable to perform load test"
+		switch {
+		case abort != nil:
+			// the load generators stopped through the context of the run leave no results
+			msg = fmt.Sprintf("Load test aborted by the guardrail %q before any results: %s", abort.Guardrail, abort.Reason)
+		case ctx.Err() != nil:
+			msg = "load test cancelled"
+		}
 		err = errors.Wrap(err, msg)
 		logrus.Error(err)
 		respChan <- &models.LoadTestResponse{
@@ -283,10 +1091,24 @@ func (h *Handler) executeLoadTest(req *http.Request, testName, meshName, testUUI
 		return
 	}
 
+	if abort != nil {
+		resultsMap[models.AbortResultKey] = abort
+		respChan <- &models.LoadTestResponse{
+			Status:  models.LoadTestInfo,
+			Message: fmt.Sprintf("Load test aborted by the guardrail %q: %s", abort.Guardrail, abort.Reason),
+		}
+	}
 	respChan <- &models.LoadTestResponse{
 		Status:  models.LoadTestInfo,
 		Message: "Load test completed, fetching metadata now",
 	}
+	resultsMap["load-generator"] = loadTestOptions.LoadGenerator.Name()
+	resultsMap[models.LoadModelResultKey] = string(loadTestOptions.LoadModel)
+	if loadTestOptions.WarmUp > 0 {
+		resultsMap[models.WarmUpResultKey] = loadTestOptions.WarmUp.String()
+	}
+
+	var meshIntrospection *helpers.MeshIntrospection
 
 	if prefObj.K8SConfig != nil {
 		nodesChan := make(chan []*models.K8SNode)
@@ -294,9 +1116,10 @@ func (h *Handler) executeLoadTest(req *http.Request, testName, meshName, testUUI
 		installedMeshesChan := make(chan map[string][]v1.Deployment)
 
 		go func() {
-			var nodes []*models.K8SNode
+			// the nodes already known for the cluster are kept, only fetched when there are none
+			nodes := prefObj.K8SConfig.Nodes
 			var err error
-			if len(prefObj.K8SConfig.Nodes) == 0 {
+			if len(nodes) == 0 {
 				nodes, err = helpers.FetchKubernetesNodes(prefObj.K8SConfig.Config, prefObj.K8SConfig.ContextName)
 				if err != nil {
 					err = errors.Wrap(err, "unable to ping kubernetes")
@@ -308,9 +1131,9 @@ func (h *Handler) executeLoadTest(req *http.Request, testName, meshName, testUUI
 			nodesChan <- nodes
 		}()
 		go func() {
-			var serverVersion string
+			serverVersion := prefObj.K8SConfig.ServerVersion
 			var err error
-			if prefObj.K8SConfig.ServerVersion == "" {
+			if serverVersion == "" {
 				serverVersion, err = helpers.FetchKubernetesVersion(prefObj.K8SConfig.Config, prefObj.K8SConfig.ContextName)
 				if err != nil {
 					err = errors.Wrap(err, "unable to ping kubernetes")
@@ -342,6 +1165,10 @@ func (h *Handler) executeLoadTest(req *http.Request, testName, meshName, testUUI
 		installedMeshes := <-installedMeshesChan
 		if len(installedMeshes) > 0 {
 			resultsMap["detected-meshes"] = installedMeshes
+			meshIntrospection, err = helpers.IntrospectMesh(prefObj.K8SConfig.Config, prefObj.K8SConfig.ContextName, meshName, installedMeshes)
+			if err != nil {
+				logrus.Warn(err)
+			}
 		}
 	}
 	respChan <- &models.LoadTestResponse{
@@ -357,11 +1184,62 @@ func (h *Handler) executeLoadTest(req *http.Request, testName, meshName, testUUI
 	// 	return
 	// }
 
+	var verdict *models.SLOVerdict
+	if len(loadTestOptions.SLOs) > 0 {
+		verdict = helpers.EvaluateSLOs(ctx, loadTestOptions, resultsMap, resultInst, h.config.PrometheusClient, promURL)
+		msg := "All the SLO assertions passed"
+		if !verdict.Passed {
+			msg = "Some of the SLO assertions failed"
+		}
+		respChan <- &models.LoadTestResponse{
+			Status:  models.LoadTestInfo,
+			Message: msg,
+		}
+	}
+
+	var smpMetrics *models.Metrics
+	if promURL != "" {
+		respChan <- &models.LoadTestResponse{
+			Status:  models.LoadTestInfo,
+			Message: "Collecting the mesh metrics from Prometheus",
+		}
+		smpMetrics, err = helpers.CollectSMPMetrics(ctx, h.config.PrometheusClient, promURL, meshName, loadTestOptions.URL,
+			resultInst.StartTime, resultInst.StartTime.Add(resultInst.ActualDuration))
+		if err != nil {
+			logrus.Warn(err)
+		}
+	}
+
+	if ctx.Err() != nil {
+		respChan <- &models.LoadTestResponse{
+			Status:  models.LoadTestError,
+			Message: "load test cancelled",
+		}
+		return
+	}
+
 	result := &models.MesheryResult{
-		Name:   testName,
-		Mesh:   meshName,
-		Result: resultsMap,
+		Name:    testName,
+		Mesh:    meshName,
+		Result:  resultsMap,
+		Verdict: verdict,
 	}
+	if smpMetrics != nil {
+		if err := result.SetSpecMetrics(smpMetrics); err != nil {
+			logrus.Warn(err)
+		}
+	}
+	if meshIntrospection != nil {
+		if err := result.SetSpecMesh(meshIntrospection.MeshBuild, meshIntrospection.ProxyBuild, meshIntrospection.Config); err != nil {
+			logrus.Warn(err)
+		}
+	}
+	if p := loadTestOptions.PerformanceProfile; p != nil {
+		profileID := p.ID
+		result.PerformanceProfile = &profileID
+		result.PerformanceProfileVersion = p.Version
+	}
+	result.ExperimentGroup = loadTestOptions.ExperimentGroup
 
 	resultID, err := provider.PublishResults(req, result)
 	if err != nil {
@@ -377,14 +1255,35 @@ func (h *Handler) executeLoadTest(req *http.Request, testName, meshName, testUUI
 		}
 		return
 	}
-	respChan <- &models.LoadTestResponse{
-		Status:  models.LoadTestInfo,
-		Message: "Done persisting the load test results.",
+	if resultID == "" && unattended && provider.GetProviderType() == models.LocalProviderType {
+		// the local provider only stores the results which are shared, while nobody would get the results of an
+		// unattended test if they were not stored
+		resultID, err = h.storeLocalResult(result)
+		if err != nil {
+			msg := "error: unable to persist the load test results"
+			logrus.Error(errors.Wrap(err, msg))
+			respChan <- &models.LoadTestResponse{
+				Status:  models.LoadTestError,
+				Message: msg,
+			}
+			return
+		}
 	}
-
-	var promURL string
-	if prefObj.Prometheus != nil {
-		promURL = prefObj.Prometheus.PrometheusURL
+	notPersisted := ""
+	if resultID == "" {
+		notPersisted = "The load test results were not persisted by the provider."
+		if provider.GetProviderType() == models.LocalProviderType {
+			notPersisted = "The load test results were not persisted, as the anonymous performance results are not shared."
+		}
+		respChan <- &models.LoadTestResponse{
+			Status:  models.LoadTestInfo,
+			Message: notPersisted,
+		}
+	} else {
+		respChan <- &models.LoadTestResponse{
+			Status:  models.LoadTestInfo,
+			Message: "Done persisting the load test results.",
+		}
 	}
 
 	tokenVal, _ := provider.GetProviderToken(req)
@@ -411,9 +1310,91 @@ func (h *Handler) executeLoadTest(req *http.Request, testName, meshName, testUUI
 	result.ID = key
 	// w.Write(bd)
 	respChan <- &models.LoadTestResponse{
-		Status: models.LoadTestSuccess,
-		Result: result,
+		Status:          models.LoadTestSuccess,
+		Message:         notPersisted,
+		Result:          result,
+		ResultPersisted: resultID != "",
+	}
+}
+
+// runLoadGenerator runs the load test in the Meshery process, publishing the live metrics of the test and ending
+// it early once any of its guardrails trips, in which case the reason of the abort is returned along with the results
+func (h *Handler) runLoadGenerator(ctx context.Context, lg models.LoadGenerator, loadTestOptions *models.LoadTestOptions, promURL string,
+	respChan chan *models.LoadTestResponse) (map[string]interface{}, *periodic.RunnerResults, *models.LoadTestAbort, error) {
+	// the tests of the load generators without live metrics are reported with their elapsed time only
+	progress := helpers.NewLoadTestProgressRecorder(lg.Capabilities().LiveMetrics)
+	stopProgress := publishLoadTestProgress(progress, respChan)
+	defer stopProgress()
+	var recorder models.LoadTestRecorder = progress
+	loadTestOptions.Recorder = recorder
+	if len(loadTestOptions.Guardrails) == 0 {
+		resultsMap, resultInst, err := helpers.RunLoadGenerator(ctx, lg, loadTestOptions)
+		return resultsMap, resultInst, nil, err
+	}
+
+	// without the outcome of the requests, only the Prometheus guardrails are checked
+	guard := helpers.NewLoadTestGuard(loadTestOptions.Guardrails, recorder, h.config.PrometheusClient, promURL)
+	loadTestOptions.Recorder = guard
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	defer stopWatching()
+	go guard.Watch(watchCtx)
+
+	// the load generators which ignore the recorder of the test are stopped through the context of the run instead
+	runCtx := ctx
+	if !lg.Capabilities().LiveMetrics {
+		var cancelRun context.CancelFunc
+		runCtx, cancelRun = context.WithCancel(ctx)
+		defer cancelRun()
+		go func() {
+			select {
+			case <-guard.Stopped():
+				cancelRun()
+			case <-runCtx.Done():
+			}
+		}()
 	}
+	resultsMap, resultInst, err := helpers.RunLoadGenerator(runCtx, lg, loadTestOptions)
+	return resultsMap, resultInst, guard.Abort(), err
+}
+
+// loadTestProgressInterval is the interval between the snapshots of the live metrics of a running load test
+const loadTestProgressInterval = 5 * time.Second
+
+// publishLoadTestProgress ships a snapshot of the live metrics of the test every loadTestProgressInterval until
+// the returned func is called
+func publishLoadTestProgress(recorder *helpers.LoadTestProgressRecorder, respChan chan *models.LoadTestResponse) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(loadTestProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if snapshot := recorder.Snapshot(); snapshot != nil {
+					respChan <- &models.LoadTestResponse{
+						Status:   models.LoadTestProgress,
+						Progress: snapshot,
+					}
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// publishLoadTestElapsedTime ships the elapsed time of a test run out of the Meshery process, whose requests are not
+// recorded as it runs, every loadTestProgressInterval until the returned func is called
+func publishLoadTestElapsedTime(respChan chan *models.LoadTestResponse) func() {
+	progress := helpers.NewLoadTestProgressRecorder(false)
+	progress.Start()
+	return publishLoadTestProgress(progress, respChan)
 }
 
 // CollectStaticMetrics is used for collecting static metrics from prometheus and submitting it to SaaS
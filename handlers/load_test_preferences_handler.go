@@ -49,14 +49,7 @@ func (h *Handler) LoadTestPrefencesHandler(w http.ResponseWriter, req *http.Requ
 		return
 	}
 	gen := req.FormValue("gen")
-	genTrack := false
-	// TODO: after we have interfaces for load generators in place, we need to make a generic check, for now using a hard coded one
-	for _, lg := range []models.LoadGenerator{models.FortioLG, models.Wrk2LG} {
-		if lg.Name() == gen {
-			genTrack = true
-		}
-	}
-	if !genTrack {
+	if _, ok := h.config.LoadGenerators[gen]; !ok {
 		logrus.Error("invalid value for gen")
 		http.Error(w, "please provide a valid value for gen (load generator)", http.StatusBadRequest)
 		return
@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/helpers"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/vmihailenco/taskq"
+)
+
+// loadTestScheduleInput is the part of a schedule which is set through the API
+type loadTestScheduleInput struct {
+	Name     string     `json:"name"`
+	Cron     string     `json:"cron"`
+	Timezone string     `json:"timezone"`
+	Enabled  *bool      `json:"enabled"`
+	Params   url.Values `json:"params"`
+}
+
+// LoadTestSchedulesHandler is used for listing, creating, updating and deleting the load test schedules of the user.
+// The schedules are only supported with the local provider, which stores the results of their runs whatever the
+// sharing preferences of the user, creating or updating one with another provider is rejected with a 400
+func (h *Handler) LoadTestSchedulesHandler(w http.ResponseWriter, req *http.Request, _ *sessions.Session, _ *models.Preference, user *models.User, provider models.Provider) {
+	if h.config.SchedulePersister == nil {
+		http.Error(w, "load test schedules are not available", http.StatusNotFound)
+		return
+	}
+	scheduleID := strings.Trim(strings.TrimPrefix(req.URL.Path, "/api/load-test-schedules"), "/")
+
+	h.scheduleLock.Lock()
+	defer h.scheduleLock.Unlock()
+
+	if scheduleID == "" {
+		switch req.Method {
+		case http.MethodGet:
+			all, err := h.config.SchedulePersister.GetSchedules()
+			if err != nil {
+				logrus.Error(err)
+				http.Error(w, "unable to get the load test schedules", http.StatusInternalServerError)
+				return
+			}
+			schedules := []*models.LoadTestSchedule{}
+			for _, s := range all {
+				if s.UserID == user.UserID {
+					schedules = append(schedules, s)
+				}
+			}
+			sort.Slice(schedules, func(i, j int) bool {
+				return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+			})
+			h.writeLoadTestScheduleJSON(w, http.StatusOK, schedules)
+		case http.MethodPost:
+			now := time.Now()
+			schedule := &models.LoadTestSchedule{
+				ID:        uuid.Must(uuid.NewV4()).String(),
+				UserID:    user.UserID,
+				Enabled:   true,
+				CreatedAt: now,
+			}
+			if !h.saveLoadTestSchedule(w, req, schedule, provider) {
+				return
+			}
+			h.writeLoadTestScheduleJSON(w, http.StatusCreated, schedule)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+		return
+	}
+
+	schedule, err := h.config.SchedulePersister.GetSchedule(scheduleID)
+	if err != nil || schedule.UserID != user.UserID {
+		http.Error(w, "load test schedule not found", http.StatusNotFound)
+		return
+	}
+	switch req.Method {
+	case http.MethodGet:
+		h.writeLoadTestScheduleJSON(w, http.StatusOK, schedule)
+	case http.MethodPut:
+		if !h.saveLoadTestSchedule(w, req, schedule, provider) {
+			return
+		}
+		h.writeLoadTestScheduleJSON(w, http.StatusOK, schedule)
+	case http.MethodDelete:
+		// the run already armed in the queue is dropped when it finds the schedule gone
+		if err := h.config.SchedulePersister.DeleteSchedule(scheduleID); err != nil {
+			logrus.Error(err)
+			http.Error(w, "unable to delete the load test schedule", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// saveLoadTestSchedule applies the settings of the request to the schedule, arms its next run and persists it,
+// it returns false when it already wrote an error to the client
+func (h *Handler) saveLoadTestSchedule(w http.ResponseWriter, req *http.Request, schedule *models.LoadTestSchedule, provider models.Provider) bool {
+	input := &loadTestScheduleInput{}
+	if err := json.NewDecoder(req.Body).Decode(input); err != nil {
+		logrus.Errorf("Error: unable to parse the load test schedule: %v", err)
+		http.Error(w, "unable to parse the load test schedule", http.StatusBadRequest)
+		return false
+	}
+	if err := checkBackgroundLoadTestProvider(provider); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	if err := h.validateLoadTestSchedule(input); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	schedule.Name = input.Name
+	schedule.Cron = input.Cron
+	schedule.Timezone = input.Timezone
+	schedule.Params = input.Params
+	if input.Enabled != nil {
+		schedule.Enabled = *input.Enabled
+	}
+
+	schedule.Provider = provider.Name()
+
+	now := time.Now()
+	schedule.UpdatedAt = now
+	if err := h.armLoadTestSchedule(schedule, now); err != nil {
+		logrus.Error(err)
+		http.Error(w, "unable to schedule the load test", http.StatusInternalServerError)
+		return false
+	}
+	if err := h.config.SchedulePersister.WriteSchedule(schedule); err != nil {
+		logrus.Error(err)
+		http.Error(w, "unable to save the load test schedule", http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+func (h *Handler) validateLoadTestSchedule(input *loadTestScheduleInput) error {
+	if strings.TrimSpace(input.Name) == "" {
+		return errors.New("please provide a name for the schedule")
+	}
+	if _, err := nextLoadTestScheduleRun(input.Cron, input.Timezone, time.Now()); err != nil {
+		return err
+	}
+	if input.Params == nil {
+		input.Params = url.Values{}
+	}
+	// the runs are always started in the background and tracked as jobs
+	input.Params.Del("async")
+	input.Params.Del("uuid")
+
+	grpc, _ := strconv.ParseBool(input.Params.Get("grpc"))
+	if err := validateLoadTestURL(input.Params.Get("url"), grpc); err != nil {
+		return errors.Wrap(err, "invalid load test URL")
+	}
+	if _, err := h.getLoadGenerator(input.Params.Get("loadGenerator")); err != nil {
+		return err
+	}
+	return nil
+}
+
+// nextLoadTestScheduleRun returns the first activation of the cron expression after the given time, in the timezone of the schedule
+func nextLoadTestScheduleRun(cron, timezone string, after time.Time) (time.Time, error) {
+	cs, err := helpers.ParseCronSchedule(cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc := time.Local
+	if timezone != "" {
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return time.Time{}, fmt.Errorf("invalid timezone %q", timezone)
+		}
+	}
+	next := cs.Next(after.In(loc))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("the cron expression %q never fires", cron)
+	}
+	return next, nil
+}
+
+// armLoadTestSchedule computes the next run of an enabled schedule after the given time and adds it to the task queue
+// as a delayed message, the message carries the time of the run so that it is dropped if the schedule changed meanwhile
+// or if the run was armed more than once
+func (h *Handler) armLoadTestSchedule(schedule *models.LoadTestSchedule, after time.Time) error {
+	schedule.NextRun = nil
+	if !schedule.Enabled {
+		return nil
+	}
+	next, err := nextLoadTestScheduleRun(schedule.Cron, schedule.Timezone, after)
+	if err != nil {
+		return err
+	}
+	schedule.NextRun = &next
+
+	msg := taskq.NewMessage(schedule.ID, next.Unix())
+	msg.Delay = time.Until(next)
+	if err := h.scheduleTask.AddMessage(msg); err != nil {
+		return errors.Wrapf(err, "unable to queue the next run of the load test schedule %s", schedule.ID)
+	}
+	return nil
+}
+
+// armLoadTestSchedules arms the next run of all the persisted schedules, the runs missed while Meshery was down are skipped
+func (h *Handler) armLoadTestSchedules() {
+	h.scheduleLock.Lock()
+	defer h.scheduleLock.Unlock()
+
+	schedules, err := h.config.SchedulePersister.GetSchedules()
+	if err != nil {
+		logrus.Errorf("unable to load the load test schedules: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, schedule := range schedules {
+		if schedule.LastStatus == models.LoadTestJobRunning || schedule.LastStatus == models.LoadTestJobQueued {
+			schedule.LastStatus = models.LoadTestJobFailed
+			schedule.LastError = "the load test was interrupted by a restart of Meshery"
+		}
+		if err := h.armLoadTestSchedule(schedule, now); err != nil {
+			logrus.Error(err)
+			schedule.LastError = err.Error()
+		}
+		if err := h.config.SchedulePersister.WriteSchedule(schedule); err != nil {
+			logrus.Error(err)
+		}
+	}
+}
+
+// runScheduledLoadTest is run by the task queue when a schedule is due, it starts the load test and arms the next run
+func (h *Handler) runScheduledLoadTest(scheduleID string, runAt int64) error {
+	h.scheduleLock.Lock()
+	defer h.scheduleLock.Unlock()
+
+	schedule, err := h.config.SchedulePersister.GetSchedule(scheduleID)
+	if err != nil {
+		logrus.Debugf("dropping the run of the deleted load test schedule %s", scheduleID)
+		return nil
+	}
+	if !schedule.Enabled || schedule.NextRun == nil || schedule.NextRun.Unix() != runAt {
+		logrus.Debugf("dropping a stale run of the load test schedule %s", scheduleID)
+		return nil
+	}
+
+	now := time.Now()
+	schedule.LastRun = &now
+	schedule.LastError = ""
+	if job, ok := h.config.LoadTestJobTracker.GetJob(context.Background(), schedule.LastJobID); ok && !job.Status.Done() {
+		schedule.LastError = fmt.Sprintf("the run was skipped as the previous load test job %s is still running", job.ID)
+	} else if job, err := h.startScheduledLoadTest(schedule); err != nil {
+		logrus.Errorf("unable to run the load test schedule %s: %v", scheduleID, err)
+		schedule.LastStatus = models.LoadTestJobFailed
+		schedule.LastError = err.Error()
+	} else {
+		schedule.LastJobID = job.ID
+		schedule.LastStatus = job.Status
+		schedule.LastResultID = ""
+		go h.trackScheduledLoadTest(schedule.ID, job.ID)
+	}
+
+	after := time.Unix(runAt, 0)
+	if now.After(after) {
+		after = now
+	}
+	if err := h.armLoadTestSchedule(schedule, after); err != nil {
+		logrus.Error(err)
+		schedule.LastError = err.Error()
+	}
+	if err := h.config.SchedulePersister.WriteSchedule(schedule); err != nil {
+		logrus.Error(err)
+	}
+	// the errors are recorded on the schedule rather than returned, as a retry would run the load test again
+	return nil
+}
+
+// startScheduledLoadTest starts the load test of the schedule on behalf of the user
+func (h *Handler) startScheduledLoadTest(schedule *models.LoadTestSchedule) (*models.LoadTestJob, error) {
+	params := url.Values{}
+	for k, v := range schedule.Params {
+		params[k] = v
+	}
+	if params.Get("name") == "" {
+		params.Set("name", schedule.Name)
+	}
+	return h.startLoadTestForUser(params, schedule.UserID, schedule.Provider)
+}
+
+// trackScheduledLoadTest waits for the load test job of a scheduled run to end and records its outcome on the schedule
+func (h *Handler) trackScheduledLoadTest(scheduleID, jobID string) {
+	job := h.waitLoadTestJob(jobID)
+	if job == nil {
+		return
+	}
+
+	h.scheduleLock.Lock()
+	defer h.scheduleLock.Unlock()
+	schedule, err := h.config.SchedulePersister.GetSchedule(scheduleID)
+	if err != nil || schedule.LastJobID != jobID {
+		return
+	}
+	schedule.LastStatus = job.Status
+	schedule.LastResultID = job.ResultID
+	if job.Status != models.LoadTestJobCompleted || job.ResultID == "" {
+		schedule.LastError = job.Message
+	}
+	if err := h.config.SchedulePersister.WriteSchedule(schedule); err != nil {
+		logrus.Error(err)
+	}
+}
+
+// waitLoadTestJob waits for the load test job to end and returns it, or nil if the job is no longer tracked
+func (h *Handler) waitLoadTestJob(jobID string) *models.LoadTestJob {
+	for {
+		respChan, unsubscribe, err := h.config.LoadTestJobTracker.Subscribe(context.Background(), jobID)
+		if err != nil {
+			logrus.Error(err)
+			return nil
+		}
+		for range respChan {
+		}
+		unsubscribe()
+		// the channel is also closed when the subscriber is dropped, in which case it has to re-attach
+		job, ok := h.config.LoadTestJobTracker.GetJob(context.Background(), jobID)
+		if !ok {
+			return nil
+		}
+		if job.Status.Done() {
+			return job
+		}
+	}
+}
+
+func (h *Handler) writeLoadTestScheduleJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		logrus.Errorf("error: unable to marshal load test schedule: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/helpers"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// LoadTestWorkersHandler is used for listing the load test workers in use, and for putting the configured ones in
+// and out of use
+func (h *Handler) LoadTestWorkersHandler(w http.ResponseWriter, req *http.Request, _ *sessions.Session, _ *models.Preference, _ *models.User, _ models.Provider) {
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		// only the workers configured by the administrator can be added back, as they are sent the worker token
+		if err := h.config.LoadTestWorkersTracker.AddWorker(req.Context(), req.FormValue("workerURL")); err != nil {
+			logrus.Error(err)
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	case http.MethodDelete:
+		h.config.LoadTestWorkersTracker.RemoveWorker(req.Context(), req.FormValue("workerURL"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.config.LoadTestWorkersTracker.GetWorkers(req.Context())); err != nil {
+		logrus.Errorf("error: unable to marshal load test workers: %v", err)
+		http.Error(w, "unable to marshal load test workers", http.StatusInternalServerError)
+	}
+}
+
+// LoadTestWorkerHandler runs the share of a distributed load test sent by a coordinating Meshery instance, or responds
+// with 429 when this instance is already running load tests
+func (h *Handler) LoadTestWorkerHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	token := req.Header.Get(helpers.WorkerTokenHeader)
+	if h.config.LoadTestWorkerToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.config.LoadTestWorkerToken)) != 1 {
+		logrus.Error("Error: rejected a load test from an unauthenticated coordinator")
+		http.Error(w, "worker mode is not enabled or the token is invalid", http.StatusUnauthorized)
+		return
+	}
+
+	wReq := &models.WorkerLoadTestRequest{}
+	if err := json.NewDecoder(req.Body).Decode(wReq); err != nil || wReq.Options == nil {
+		logrus.Errorf("Error: unable to parse the worker load test request: %v", err)
+		http.Error(w, "unable to parse the provided input", http.StatusBadRequest)
+		return
+	}
+	loadTestOptions := wReq.Options
+	// a worker never distributes its share any further
+	loadTestOptions.Distributed = false
+	if err := h.setLoadGenerator(loadTestOptions.LoadGenerator.Name(), loadTestOptions); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	lg, _ := h.getLoadGenerator(loadTestOptions.LoadGenerator.Name())
+
+	// the share takes its turn in the run queue of this instance, so that it does not run alongside the tests of this
+	// instance, it is turned down when the queue is busy as waiting for a turn would put it out of sync with the
+	// shares of the other workers
+	job := &models.LoadTestJob{
+		ID:     uuid.Must(uuid.NewV4()).String(),
+		Name:   loadTestOptions.Name,
+		Status: models.LoadTestJobQueued,
+	}
+	if !h.config.LoadTestQueue.EnqueueIfIdle(req.Context(), job) {
+		logrus.Errorf("Error: turned down the worker load test %s as other load tests are running", loadTestOptions.Name)
+		http.Error(w, "the worker is busy running other load tests", http.StatusTooManyRequests)
+		return
+	}
+	defer h.config.LoadTestQueue.Release(context.Background(), job.ID)
+
+	logrus.Debugf("worker load test %s will start at %v", loadTestOptions.Name, wReq.StartAt)
+	select {
+	case <-time.After(time.Until(wReq.StartAt)):
+	case <-req.Context().Done():
+		logrus.Debugf("coordinator went away before the worker load test %s started", loadTestOptions.Name)
+		return
+	}
+
+	resultsMap, _, err := helpers.RunLoadGenerator(req.Context(), lg, loadTestOptions)
+	if err != nil {
+		err = errors.Wrap(err, "unable to perform the worker load test")
+		logrus.Error(err)
+		http.Error(w, "unable to perform the load test", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	if err = json.NewEncoder(w).Encode(&models.WorkerLoadTestResponse{Results: resultsMap}); err != nil {
+		logrus.Errorf("error: unable to marshal the worker load test results: %v", err)
+	}
+}
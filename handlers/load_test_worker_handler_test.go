@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/layer5io/meshery/helpers"
+	"github.com/layer5io/meshery/models"
+)
+
+const testWorkerToken = "worker-token"
+
+// newTestWorker starts an in-process Meshery worker running its share of the load tests with fortio, in turn with
+// the tests of the given queue
+func newTestWorker(queue *helpers.LoadTestQueue) *httptest.Server {
+	h := &Handler{
+		config: &models.HandlerConfig{
+			LoadGenerators: map[string]models.LoadGenerator{
+				models.FortioLG.Name(): helpers.NewFortioLoadGenerator(),
+			},
+			LoadTestWorkerToken: testWorkerToken,
+			LoadTestQueue:       queue,
+		},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(helpers.WorkerLoadTestPath, h.LoadTestWorkerHandler)
+	return httptest.NewServer(mux)
+}
+
+func TestDistributedLoadTestWithInProcessWorkers(t *testing.T) {
+	var requests int64
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&requests, 1)
+	}))
+	defer target.Close()
+
+	workers := []string{}
+	for i := 0; i < 2; i++ {
+		worker := newTestWorker(helpers.NewLoadTestQueue(1, 0))
+		defer worker.Close()
+		workers = append(workers, worker.URL)
+	}
+
+	opts := &models.LoadTestOptions{
+		Name:           "distributed",
+		URL:            target.URL,
+		LoadGenerator:  models.FortioLG,
+		HTTPNumThreads: 3,
+		HTTPQPS:        30,
+		Duration:       time.Second,
+		Distributed:    true,
+	}
+	resultsMap, result, err := helpers.DistributedLoadTest(context.Background(), workers, testWorkerToken, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	perWorker, ok := resultsMap["workers"].([]map[string]interface{})
+	if !ok || len(perWorker) != len(workers) {
+		t.Fatalf("expected the results of %d workers, got %v", len(workers), resultsMap["workers"])
+	}
+	var threads float64
+	var count float64
+	for i, w := range perWorker {
+		if w["worker"] != workers[i] {
+			t.Errorf("unexpected worker %v, expected %s", w["worker"], workers[i])
+		}
+		rm := w["runner_results"].(map[string]interface{})
+		threads += rm["NumThreads"].(float64)
+		count += rm["DurationHistogram"].(map[string]interface{})["Count"].(float64)
+	}
+	if threads != 3 {
+		t.Errorf("the workers used %g connections instead of 3", threads)
+	}
+	if result.DurationHistogram.Count != int64(count) {
+		t.Errorf("the merged histogram holds %d requests, the workers sent %g", result.DurationHistogram.Count, count)
+	}
+	// fortio also checks every connection before the test starts
+	if sent := atomic.LoadInt64(&requests); sent != int64(count)+3 {
+		t.Errorf("the target got %d requests, the results account for %g", sent, count)
+	}
+	if result.DurationHistogram.Count < 20 {
+		t.Errorf("only %d requests were sent at 30 qps over 1s", result.DurationHistogram.Count)
+	}
+}
+
+func TestLoadTestWorkerHandlerRejectsInvalidToken(t *testing.T) {
+	worker := newTestWorker(helpers.NewLoadTestQueue(1, 0))
+	defer worker.Close()
+
+	bd, _ := json.Marshal(&models.WorkerLoadTestRequest{
+		Options: &models.LoadTestOptions{URL: "http://localhost:1/", Duration: time.Second},
+		StartAt: time.Now(),
+	})
+	for _, token := range []string{"", "other-token"} {
+		req, _ := http.NewRequest(http.MethodPost, worker.URL+helpers.WorkerLoadTestPath, bytes.NewReader(bd))
+		req.Header.Set(helpers.WorkerTokenHeader, token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("token %q: got status %d instead of %d", token, resp.StatusCode, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestLoadTestWorkerHandlerTurnsDownSharesWhileBusy(t *testing.T) {
+	var requests int64
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&requests, 1)
+	}))
+	defer target.Close()
+	queue := helpers.NewLoadTestQueue(1, 0)
+	worker := newTestWorker(queue)
+	defer worker.Close()
+
+	opts := &models.LoadTestOptions{
+		Name:          "distributed",
+		URL:           target.URL,
+		LoadGenerator: models.FortioLG,
+		HTTPQPS:       10,
+		Duration:      time.Second,
+		Distributed:   true,
+	}
+	running := &models.LoadTestJob{ID: "local", Name: "local"}
+	if err := queue.Enqueue(context.Background(), running); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := helpers.DistributedLoadTest(context.Background(), []string{worker.URL}, testWorkerToken, opts); err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("the worker ran its share alongside a local test: %v", err)
+	}
+	if n := atomic.LoadInt64(&requests); n != 0 {
+		t.Errorf("the worker sent %d requests while busy", n)
+	}
+	if entries := queue.GetEntries(context.Background()); len(entries) != 1 || entries[0].JobID != running.ID {
+		t.Errorf("the turned down share was left in the queue: %+v", entries)
+	}
+
+	queue.Release(context.Background(), running.ID)
+	if _, _, err := helpers.DistributedLoadTest(context.Background(), []string{worker.URL}, testWorkerToken, opts); err != nil {
+		t.Errorf("the idle worker did not run its share: %v", err)
+	}
+	if entries := queue.GetEntries(context.Background()); len(entries) != 0 {
+		t.Errorf("the share was left in the queue once done: %+v", entries)
+	}
+}
+
+func TestLoadTestWorkersHandlerOnlyAcceptsConfiguredWorkers(t *testing.T) {
+	h := &Handler{
+		config: &models.HandlerConfig{
+			LoadTestWorkersTracker: helpers.NewLoadTestWorkersTracker([]string{"http://worker-1:8080/", "http://worker-2:8080"}),
+		},
+	}
+	call := func(method, workerURL string) (int, []string) {
+		q := url.Values{"workerURL": []string{workerURL}}
+		req := httptest.NewRequest(method, "/api/load-test-workers?"+q.Encode(), nil)
+		rec := httptest.NewRecorder()
+		h.LoadTestWorkersHandler(rec, req, nil, nil, nil, nil)
+		workers := []string{}
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &workers); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rec.Code, workers
+	}
+
+	if code, _ := call(http.MethodPost, "http://169.254.169.254"); code != http.StatusForbidden {
+		t.Errorf("an unknown worker was accepted with the status %d", code)
+	}
+	if code, workers := call(http.MethodDelete, "http://worker-1:8080"); code != http.StatusOK || !reflect.DeepEqual(workers, []string{"http://worker-2:8080"}) {
+		t.Errorf("unexpected workers after the removal: %d %v", code, workers)
+	}
+	if code, workers := call(http.MethodPost, "http://worker-1:8080/"); code != http.StatusOK || !reflect.DeepEqual(workers, []string{"http://worker-1:8080", "http://worker-2:8080"}) {
+		t.Errorf("unexpected workers after adding back a configured one: %d %v", code, workers)
+	}
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// PerformanceProfilesHandler is used for listing, creating, updating, deleting and running performance profiles
+func (h *Handler) PerformanceProfilesHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *models.Preference, user *models.User, provider models.Provider) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, "/api/performance/profiles"), "/"), "/")
+	profileID := parts[0]
+
+	if profileID == "" {
+		switch req.Method {
+		case http.MethodGet:
+			profiles, err := provider.GetPerformanceProfiles(req, req.URL.Query().Get("search"))
+			if err != nil {
+				logrus.Error(err)
+				http.Error(w, "unable to get the performance profiles", http.StatusInternalServerError)
+				return
+			}
+			h.writePerformanceProfileJSON(w, http.StatusOK, profiles)
+		case http.MethodPost:
+			profile := &models.PerformanceProfile{
+				ID:        uuid.Must(uuid.NewV4()),
+				UserID:    user.UserID,
+				CreatedAt: time.Now(),
+			}
+			if profile, ok := h.savePerformanceProfile(w, req, profile, prefObj, provider); ok {
+				h.writePerformanceProfileJSON(w, http.StatusCreated, profile)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "run" {
+		if req.Method != http.MethodPost {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// the profile is run by the load test handler, with the parameters of the request overriding the ones of the profile
+		q := req.URL.Query()
+		q.Set("profile", profileID)
+		req.URL.RawQuery = q.Encode()
+		req.Form = nil
+		h.LoadTestHandler(w, req, session, prefObj, user, provider)
+		return
+	}
+	if len(parts) != 1 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	profile, err := h.getPerformanceProfile(req, provider, profileID, req.URL.Query().Get("version"))
+	if err != nil {
+		logrus.Error(err)
+		http.Error(w, "performance profile not found", http.StatusNotFound)
+		return
+	}
+	switch req.Method {
+	case http.MethodGet:
+		h.writePerformanceProfileJSON(w, http.StatusOK, profile)
+	case http.MethodPut:
+		if profile, ok := h.savePerformanceProfile(w, req, profile, prefObj, provider); ok {
+			h.writePerformanceProfileJSON(w, http.StatusOK, profile)
+		}
+	case http.MethodDelete:
+		if err := provider.DeletePerformanceProfile(req, profile.ID); err != nil {
+			logrus.Error(err)
+			http.Error(w, "unable to delete the performance profile", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// getPerformanceProfile fetches the given version of a profile, the latest one when the version is empty
+func (h *Handler) getPerformanceProfile(req *http.Request, provider models.Provider, profileID, version string) (*models.PerformanceProfile, error) {
+	id := uuid.FromStringOrNil(profileID)
+	if id == uuid.Nil {
+		return nil, fmt.Errorf("invalid performance profile id: %s", profileID)
+	}
+	v := 0
+	if version != "" {
+		var err error
+		if v, err = strconv.Atoi(version); err != nil || v < 1 {
+			return nil, fmt.Errorf("invalid performance profile version: %s", version)
+		}
+	}
+	profile, err := provider.GetPerformanceProfile(req, id, v)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get the performance profile %s", profileID)
+	}
+	return profile, nil
+}
+
+// savePerformanceProfile applies the definition of the request to the profile and saves it as a new version,
+// it returns false when it already wrote an error to the client
+func (h *Handler) savePerformanceProfile(w http.ResponseWriter, req *http.Request, profile *models.PerformanceProfile,
+	prefObj *models.Preference, provider models.Provider) (*models.PerformanceProfile, bool) {
+	input := &models.PerformanceProfile{}
+	if err := json.NewDecoder(req.Body).Decode(input); err != nil {
+		logrus.Errorf("Error: unable to parse the performance profile: %v", err)
+		http.Error(w, "unable to parse the performance profile", http.StatusBadRequest)
+		return nil, false
+	}
+	// the identity and the history of the profile are kept
+	input.ID = profile.ID
+	input.Version = profile.Version
+	input.UserID = profile.UserID
+	input.CreatedAt = profile.CreatedAt
+	input.UpdatedAt = time.Now()
+	if err := h.validatePerformanceProfile(input, prefObj); err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	saved, err := provider.SavePerformanceProfile(req, input)
+	if err != nil {
+		logrus.Error(err)
+		http.Error(w, "unable to save the performance profile", http.StatusInternalServerError)
+		return nil, false
+	}
+	return saved, true
+}
+
+func (h *Handler) validatePerformanceProfile(profile *models.PerformanceProfile, prefObj *models.Preference) error {
+	if strings.TrimSpace(profile.Name) == "" {
+		return errors.New("please provide a name for the performance profile")
+	}
+	if len(profile.Endpoints) == 0 {
+		return errors.New("please provide at least one endpoint")
+	}
+	grpc, _ := strconv.ParseBool(profile.Options.Get("grpc"))
+	for _, endpoint := range profile.Endpoints {
+		if err := validateLoadTestURL(endpoint, grpc); err != nil {
+			return errors.Wrapf(err, "invalid endpoint %s", endpoint)
+		}
+	}
+	if _, err := h.getLoadGenerator(profile.LoadGenerator); err != nil {
+		return err
+	}
+	if profile.ConcurrentRequests < 0 || profile.QPS < 0 {
+		return errors.New("the concurrent requests and the qps cannot be negative")
+	}
+	if profile.Duration != "" {
+		if d, err := time.ParseDuration(profile.Duration); err != nil || d <= 0 {
+			return fmt.Errorf("invalid duration: %s", profile.Duration)
+		}
+	}
+	if params := profile.LoadTestParams(); params.Get("stages") != "" {
+		if _, err := parseLoadTestStages(params.Get("stages")); err != nil {
+			return err
+		}
+	}
+	if _, err := parseLoadTestHeaders(profile.Headers); err != nil {
+		return err
+	}
+	if _, err := parseLoadTestSLOs(profile.SLOs, prefObj); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (h *Handler) writePerformanceProfileJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		logrus.Errorf("error: unable to marshal performance profile: %v", err)
+	}
+}
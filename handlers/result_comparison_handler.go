@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/helpers"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// CompareResultsHandler compares results with a baseline, which is either the named baseline
+// or the first of the given result ids
+func (h *Handler) CompareResultsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *models.Preference, user *models.User, p models.Provider) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	q := req.URL.Query()
+
+	ids := q["id"]
+	if name := q.Get("baseline"); name != "" {
+		baselineID, ok := prefObj.ResultBaselines[name]
+		if !ok {
+			logrus.Errorf("Error: baseline %s not found", name)
+			http.Error(w, fmt.Sprintf("baseline %s not found", name), http.StatusNotFound)
+			return
+		}
+		ids = append([]string{baselineID}, ids...)
+	}
+	if len(ids) < 2 {
+		logrus.Error("Error: not enough results provided to compare")
+		http.Error(w, "please provide a baseline and at least one result id to compare", http.StatusBadRequest)
+		return
+	}
+
+	opts := models.ResultComparisonOptions{}
+	var err error
+	if q.Get("threshold") != "" {
+		if opts.Threshold, err = strconv.ParseFloat(q.Get("threshold"), 64); err != nil || opts.Threshold <= 0 {
+			logrus.Errorf("Error: invalid threshold: %s", q.Get("threshold"))
+			http.Error(w, "please provide a valid threshold", http.StatusBadRequest)
+			return
+		}
+	}
+	if q.Get("alpha") != "" {
+		if opts.Alpha, err = strconv.ParseFloat(q.Get("alpha"), 64); err != nil || opts.Alpha <= 0 || opts.Alpha >= 1 {
+			logrus.Errorf("Error: invalid alpha: %s", q.Get("alpha"))
+			http.Error(w, "please provide a valid alpha", http.StatusBadRequest)
+			return
+		}
+	}
+
+	results := []*models.MesheryResult{}
+	for _, id := range ids {
+		key := uuid.FromStringOrNil(id)
+		if key == uuid.Nil {
+			logrus.Errorf("Error: invalid result id: %s", id)
+			http.Error(w, "please provide valid result ids", http.StatusBadRequest)
+			return
+		}
+		result, err := p.GetResult(req, key)
+		if err != nil {
+			logrus.Error(errors.Wrapf(err, "unable to get the result %s", id))
+			http.Error(w, "error while getting load test results", http.StatusInternalServerError)
+			return
+		}
+		results = append(results, result)
+	}
+
+	comparison, err := helpers.CompareResults(results[0], results[1:], opts)
+	if err != nil {
+		logrus.Error(err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	if err = json.NewEncoder(w).Encode(comparison); err != nil {
+		logrus.Errorf("Error: unable to marshal the comparison: %v", err)
+		http.Error(w, "error while comparing the results", http.StatusInternalServerError)
+	}
+}
+
+// ResultBaselinesHandler lists, names and removes the baselines results are compared with
+func (h *Handler) ResultBaselinesHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *models.Preference, user *models.User, p models.Provider) {
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		name := req.FormValue("name")
+		id := req.FormValue("id")
+		if name == "" || uuid.FromStringOrNil(id) == uuid.Nil {
+			logrus.Error("Error: invalid baseline name or result id")
+			http.Error(w, "please provide a name and a valid result id", http.StatusBadRequest)
+			return
+		}
+		if _, err := p.GetResult(req, uuid.FromStringOrNil(id)); err != nil {
+			logrus.Error(errors.Wrapf(err, "unable to get the result %s", id))
+			http.Error(w, "result not found", http.StatusNotFound)
+			return
+		}
+		if prefObj.ResultBaselines == nil {
+			prefObj.ResultBaselines = map[string]string{}
+		}
+		prefObj.ResultBaselines[name] = id
+	case http.MethodDelete:
+		name := req.FormValue("name")
+		if _, ok := prefObj.ResultBaselines[name]; !ok {
+			http.Error(w, fmt.Sprintf("baseline %s not found", name), http.StatusNotFound)
+			return
+		}
+		delete(prefObj.ResultBaselines, name)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if req.Method != http.MethodGet {
+		if err := p.RecordPreferences(req, user.UserID, prefObj); err != nil {
+			logrus.Errorf("unable to save user preferences: %v", err)
+			http.Error(w, "unable to save user preferences", http.StatusInternalServerError)
+			return
+		}
+	}
+	baselines := prefObj.ResultBaselines
+	if baselines == nil {
+		baselines = map[string]string{}
+	}
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(baselines); err != nil {
+		logrus.Errorf("Error: unable to marshal the baselines: %v", err)
+	}
+}
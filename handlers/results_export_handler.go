@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/helpers"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// resultsExportPageSize is the number of results fetched at once from the provider during a bulk export
+const resultsExportPageSize = 100
+
+// ExportResultsHandler exports the results matching the search and filters of /api/results as a zip archive
+// holding a file per result in the requested format, the results which cannot be exported to the format, eg.
+// the ones without SLO assertions for junit, are left out
+func (h *Handler) ExportResultsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, _ *models.Preference, user *models.User, p models.Provider) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	q := req.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if !isResultExportFormat(format) {
+		http.Error(w, fmt.Sprintf("unknown export format: %s", format), http.StatusBadRequest)
+		return
+	}
+
+	results := []*models.MesheryResult{}
+	for page := 0; ; page++ {
+		bdr, err := p.FetchResults(req, strconv.Itoa(page), strconv.Itoa(resultsExportPageSize), q.Get("search"), q.Get("order"))
+		if err != nil {
+			logrus.Errorf("Error: unable to fetch the results to export: %v", err)
+			http.Error(w, "error while getting load test results", http.StatusInternalServerError)
+			return
+		}
+		resultPage := &models.MesheryResultPage{}
+		if err := json.Unmarshal(bdr, resultPage); err != nil {
+			logrus.Errorf("Error: unable to unmarshal the results to export: %v", err)
+			http.Error(w, "error while getting load test results", http.StatusInternalServerError)
+			return
+		}
+		results = append(results, resultPage.Results...)
+		if len(resultPage.Results) < resultsExportPageSize || len(results) >= resultPage.TotalCount {
+			break
+		}
+	}
+
+	w.Header().Set("content-type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="results_%s.zip"`, time.Now().Format("20060102T150405")))
+	zw := zip.NewWriter(w)
+	exported := 0
+	for _, result := range results {
+		data, _, extension, err := helpers.ExportResult(result, format)
+		if errors.Cause(err) == helpers.ErrResultExportUnsupported {
+			logrus.Debugf("skipping the result %s: %v", result.ID, err)
+			continue
+		}
+		if err != nil {
+			// the archive has already been partially sent, it is left incomplete
+			logrus.Errorf("Error: unable to export the result %s: %v", result.ID, err)
+			return
+		}
+		f, err := zw.Create(fmt.Sprintf("result_%s.%s", result.ID, extension))
+		if err == nil {
+			_, err = f.Write(data)
+		}
+		if err != nil {
+			logrus.Errorf("Error: unable to write the results archive: %v", err)
+			return
+		}
+		exported++
+	}
+	if err := zw.Close(); err != nil {
+		logrus.Errorf("Error: unable to write the results archive: %v", err)
+		return
+	}
+	logrus.Debugf("exported %d of %d results", exported, len(results))
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// maxResultsImportSize is the maximum size of the benchmark specs imported at once
+const maxResultsImportSize = 32 << 20
+
+// ImportResultsHandler stores the results of the SMP benchmark specs given in yaml, either in the request body or
+// as the files of a multipart form, each holding one or more specs. The specs are all checked before any of them
+// is stored, the mesh and name query parameters override the ones of the specs. The specs exported without a
+// smp_version by earlier Meshery releases held their latencies in seconds, the imported results read that way carry
+// a warning
+func (h *Handler) ImportResultsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, _ *models.Preference, user *models.User, p models.Provider) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	docs, err := readResultsImport(w, req)
+	if err != nil {
+		msg := "unable to read the benchmark specs"
+		logrus.Error(errors.Wrap(err, msg))
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	q := req.URL.Query()
+	results := []*models.MesheryResult{}
+	warnings := []string{}
+	for _, doc := range docs {
+		specs, err := models.ParseBenchmarkSpecs(doc)
+		if err != nil {
+			logrus.Error(err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, spec := range specs {
+			result, err := spec.ConvertToMesheryResult()
+			if err != nil {
+				logrus.Error(err)
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if mesh := q.Get("mesh"); mesh != "" {
+				result.Mesh = mesh
+			}
+			if name := q.Get("name"); name != "" {
+				result.Name = name
+			}
+			warning := ""
+			if spec.LatenciesInSeconds() {
+				warning = "the spec has no smp_version and its latencies are below a millisecond, they were read as seconds as exported by earlier Meshery releases"
+				logrus.Warnf("benchmark spec %s: %s", spec.ExpUUID, warning)
+			}
+			results = append(results, result)
+			warnings = append(warnings, warning)
+		}
+	}
+
+	imported := []map[string]string{}
+	for i, result := range results {
+		resultID, err := h.storeImportedResult(req, p, result)
+		if err != nil {
+			msg := "unable to store the imported results"
+			logrus.Error(errors.Wrap(err, msg))
+			http.Error(w, msg, http.StatusInternalServerError)
+			return
+		}
+		entry := map[string]string{"id": resultID, "name": result.Name}
+		if warnings[i] != "" {
+			entry["warning"] = warnings[i]
+		}
+		imported = append(imported, entry)
+	}
+	logrus.Debugf("imported %d results", len(imported))
+
+	w.Header().Set("content-type", "application/json")
+	if err := json.NewEncoder(w).Encode(imported); err != nil {
+		logrus.Error(errors.Wrap(err, "unable to marshal the imported results"))
+	}
+}
+
+// storeImportedResult stores the imported result with the provider and returns its ID. The results of the local
+// provider are written straight to its store, as publishing them would also share them with the SaaS when the
+// anonymous performance results are enabled, and drop them otherwise
+func (h *Handler) storeImportedResult(req *http.Request, p models.Provider, result *models.MesheryResult) (string, error) {
+	if p.GetProviderType() != models.LocalProviderType {
+		resultID, err := p.PublishResults(req, result)
+		if err != nil {
+			return "", err
+		}
+		if resultID == "" {
+			return "", errors.New("the provider did not return the ID of the result")
+		}
+		return resultID, nil
+	}
+	return h.storeLocalResult(result)
+}
+
+// storeLocalResult writes the result to the result store of the local provider under a new ID and returns it
+func (h *Handler) storeLocalResult(result *models.MesheryResult) (string, error) {
+	if h.config.ResultPersister == nil {
+		return "", errors.New("no result store is configured")
+	}
+	result.ID = uuid.Must(uuid.NewV4())
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to marshal the result")
+	}
+	if err := h.config.ResultPersister.WriteResult(result.ID, data); err != nil {
+		return "", err
+	}
+	return result.ID.String(), nil
+}
+
+// readResultsImport returns the content of the files of the multipart form or else the request body
+func readResultsImport(w http.ResponseWriter, req *http.Request) ([][]byte, error) {
+	req.Body = http.MaxBytesReader(w, req.Body, maxResultsImportSize)
+	if !strings.HasPrefix(req.Header.Get("content-type"), "multipart/form-data") {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{body}, nil
+	}
+
+	if err := req.ParseMultipartForm(maxResultsImportSize); err != nil {
+		return nil, err
+	}
+	docs := [][]byte{}
+	for _, fhs := range req.MultipartForm.File {
+		for _, fh := range fhs {
+			f, err := fh.Open()
+			if err != nil {
+				return nil, err
+			}
+			body, err := ioutil.ReadAll(f)
+			_ = f.Close()
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to read %s", fh.Filename)
+			}
+			docs = append(docs, body)
+		}
+	}
+	if len(docs) == 0 {
+		return nil, errors.New("no file given")
+	}
+	return docs, nil
+}
@@ -0,0 +1,157 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// WorkerLoadTestPath is the path on which Meshery workers accept their share of a distributed load test
+	WorkerLoadTestPath = "/api/worker/load-test"
+
+	// WorkerTokenHeader is the header used for authenticating the coordinator with the workers
+	WorkerTokenHeader = "X-Meshery-Worker-Token"
+
+	// time given to all the workers to receive their share before they start in sync
+	workerStartDelay = 3 * time.Second
+)
+
+type workerResult struct {
+	worker     string
+	resultsMap map[string]interface{}
+	err        error
+}
+
+// DistributedLoadTest splits the load test across the given workers, starts them in sync and
+// merges their results into one, keeping the results of each worker under "workers"
+func DistributedLoadTest(ctx context.Context, workers []string, token string, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	if len(workers) == 0 {
+		err := errors.New("no load test workers are registered")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	threads := opts.HTTPNumThreads
+	if threads < 1 {
+		threads = 1
+	}
+	// every worker needs at least one connection
+	if len(workers) > threads {
+		workers = workers[:threads]
+	}
+	n := len(workers)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	startAt := time.Now().Add(workerStartDelay)
+	results := make([]*workerResult, n)
+	wg := sync.WaitGroup{}
+	for i, worker := range workers {
+		wOpts := *opts
+		wOpts.Distributed = false
+		wOpts.HTTPNumThreads = threads / n
+		if i < threads%n {
+			wOpts.HTTPNumThreads++
+		}
+		if opts.HTTPQPS > 0 {
+			wOpts.HTTPQPS = opts.HTTPQPS * float64(wOpts.HTTPNumThreads) / float64(threads)
+		}
+		wg.Add(1)
+		go func(i int, worker string, wOpts *models.LoadTestOptions) {
+			defer wg.Done()
+			resultsMap, err := runOnWorker(ctx, worker, token, &models.WorkerLoadTestRequest{
+				Options: wOpts,
+				StartAt: startAt,
+			})
+			if err != nil {
+				err = errors.Wrapf(err, "load test on worker %s failed", worker)
+				logrus.Error(err)
+				// no point in letting the others go on with a partial load
+				cancel()
+			}
+			results[i] = &workerResult{
+				worker:     worker,
+				resultsMap: resultsMap,
+				err:        err,
+			}
+		}(i, worker, &wOpts)
+	}
+	wg.Wait()
+
+	var firstErr error
+	for _, r := range results {
+		if r.err != nil && (firstErr == nil || errors.Cause(firstErr) == context.Canceled) {
+			firstErr = r.err
+		}
+	}
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+	return mergeWorkerResults(opts, results)
+}
+
+func runOnWorker(ctx context.Context, worker, token string, wReq *models.WorkerLoadTestRequest) (map[string]interface{}, error) {
+	bd, err := json.Marshal(wReq)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal the worker request")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(worker, "/")+WorkerLoadTestPath, bytes.NewReader(bd))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create the worker request")
+	}
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set(WorkerTokenHeader, token)
+	logrus.Debugf("sending load test share to worker %s: %d connections at %f qps", worker, wReq.Options.HTTPNumThreads, wReq.Options.HTTPQPS)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	bdr, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the worker response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("worker responded with status code: %d, body: %s", resp.StatusCode, bdr)
+	}
+	wResp := &models.WorkerLoadTestResponse{}
+	if err := json.Unmarshal(bdr, wResp); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal the worker response")
+	}
+	return wResp.Results, nil
+}
+
+func mergeWorkerResults(opts *models.LoadTestOptions, results []*workerResult) (map[string]interface{}, *periodic.RunnerResults, error) {
+	resultsMaps := []map[string]interface{}{}
+	for _, r := range results {
+		resultsMaps = append(resultsMaps, r.resultsMap)
+	}
+	resultsMap, result, err := mergeResultsMaps(opts, resultsMaps)
+	if err != nil {
+		return nil, nil, err
+	}
+	workers := []map[string]interface{}{}
+	for _, r := range results {
+		workers = append(workers, map[string]interface{}{
+			"worker":         r.worker,
+			"runner_results": r.resultsMap,
+		})
+	}
+	resultsMap["workers"] = workers
+	logrus.Debugf("Merged version of the test: %+#v", resultsMap)
+	return resultsMap, result, nil
+}
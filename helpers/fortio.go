@@ -1,7 +1,11 @@
 package helpers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
 	"os"
 	"strings"
 
@@ -15,33 +19,125 @@ import (
 
 // SharedHTTPOptions is the flag->httpoptions transfer code shared between
 // fortio_main and fcurl.
-func sharedHTTPOptions(opts *models.LoadTestOptions) *fhttp.HTTPOptions {
+func sharedHTTPOptions(opts *models.LoadTestOptions) (*fhttp.HTTPOptions, error) {
 	url := strings.TrimLeft(opts.URL, " \t\r\n")
 	httpOpts := fhttp.HTTPOptions{}
 	httpOpts.URL = url
-	httpOpts.HTTP10 = false
+	httpOpts.HTTP10 = opts.HTTP10
 	httpOpts.DisableFastClient = false
-	httpOpts.DisableKeepAlive = false
+	httpOpts.DisableKeepAlive = opts.DisableKeepAlive
 	httpOpts.AllowHalfClose = false
-	httpOpts.Compression = false
+	httpOpts.Compression = opts.Compression
 	httpOpts.HTTPReqTimeOut = fhttp.HTTPReqTimeOutDefaultValue
 	httpOpts.Insecure = opts.IsInsecure
 	httpOpts.UserCredentials = ""
-	httpOpts.ContentType = ""
-	// httpOpts.Payload = fnet.GeneratePayload(*PayloadFileFlag, *PayloadSizeFlag, *PayloadFlag)
+	httpOpts.ContentType = opts.ContentType
+	httpOpts.Payload = opts.Payload
 	// httpOpts.UnixDomainSocket = *unixDomainSocketFlag
+
+	if opts.IsGRPC {
+		// the payload is sent along with the gRPC pings, none of the HTTP settings apply
+		return &httpOpts, nil
+	}
+	// the clients of fortio infer the method from the body: POST when there is a body or a content type, GET
+	// otherwise, the other methods are sent with the Go client
+	switch opts.Method() {
+	case http.MethodGet:
+		if len(httpOpts.Payload) > 0 || httpOpts.ContentType != "" {
+			return nil, errors.New("fortio is unable to send a body with GET requests")
+		}
+	case http.MethodPost:
+		if len(httpOpts.Payload) == 0 && httpOpts.ContentType == "" {
+			httpOpts.ContentType = "application/octet-stream"
+		}
+	}
+	for key, values := range opts.HTTPHeaders {
+		for _, value := range values {
+			if err := httpOpts.AddAndValidateExtraHeader(key + ": " + value); err != nil {
+				return nil, errors.Wrapf(err, "invalid header %s", key)
+			}
+		}
+	}
+
+	if opts.HTTP10 {
+		// HTTP/1.0 is only spoken by the fast client, which neither does https, compression nor a Host override
+		if opts.Compression {
+			return nil, errors.New("fortio does not support compression with HTTP/1.0")
+		}
+		if opts.HTTPHeaders.Get("Host") != "" {
+			return nil, errors.New("fortio does not send the Host header with HTTP/1.0")
+		}
+		if strings.HasPrefix(strings.ToLower(url), "https://") {
+			return nil, errors.New("fortio does not support HTTP/1.0 with https")
+		}
+		if usesGoHTTPClient(opts) {
+			return nil, fmt.Errorf("fortio does not support HTTP/1.0 with the HTTP method %s", opts.Method())
+		}
+		return &httpOpts, nil
+	}
 	// if false { // *followRedirectsFlag {
 	httpOpts.FollowRedirects = true
 	httpOpts.DisableFastClient = true
 	// }
-	return &httpOpts
+	return &httpOpts, nil
+}
+
+var fortioDefaultPercentiles = []float64{50, 75, 90, 99, 99.9}
+
+// FortioLoadGenerator is the LoadGenerator backed by Fortio
+type FortioLoadGenerator struct{}
+
+// NewFortioLoadGenerator returns a new FortioLoadGenerator
+func NewFortioLoadGenerator() *FortioLoadGenerator {
+	return &FortioLoadGenerator{}
+}
+
+// Name returns the name of the load generator
+func (f *FortioLoadGenerator) Name() string {
+	return models.FortioLG.Name()
+}
+
+// Capabilities returns the features supported by Fortio
+func (f *FortioLoadGenerator) Capabilities() models.LoadGeneratorCapabilities {
+	return models.LoadGeneratorCapabilities{
+		GRPC:        true,
+		Stages:      true,
+		Scenarios:   true,
+		LiveMetrics: true,
+		HTTPMethods: []string{
+			http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+			http.MethodPatch, http.MethodDelete, http.MethodOptions, http.MethodTrace,
+		},
+		HTTPHeaders:       true,
+		HTTPBody:          true,
+		HTTPClientOptions: true,
+		CACert:            true,
+		ClientCerts:       true,
+		LoadModels:        []models.LoadModel{models.ClosedLoadModel, models.OpenLoadModel},
+		WarmUp:            true,
+	}
+}
+
+// Run runs the load test using Fortio
+func (f *FortioLoadGenerator) Run(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	return FortioLoadTest(ctx, opts)
 }
 
 // FortioLoadTest is the actual code which invokes Fortio to run the load test
-func FortioLoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+func FortioLoadTest(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	if len(opts.Scenario) > 0 {
+		return ScenarioLoadTest(ctx, opts)
+	}
+	if opts.LoadModel == models.OpenLoadModel || opts.WarmUp > 0 || len(opts.Stages) > 0 {
+		return FortioScheduledLoadTest(ctx, opts)
+	}
 	defaults := &periodic.DefaultRunnerOptions
 	// httpOpts := bincommon.SharedHTTPOptions()
-	httpOpts := sharedHTTPOptions(opts)
+	httpOpts, err := sharedHTTPOptions(opts)
+	if err != nil {
+		logrus.Error(err)
+		return nil, nil, err
+	}
 	if opts.IsInsecure {
 		httpOpts.Insecure = true
 	}
@@ -80,23 +176,55 @@ func FortioLoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *peri
 	// 	}
 	// 	labels = shortURL
 	// }
+	// the aborter is used for stopping the run when the context is cancelled or the test is ended early
+	aborter := periodic.NewAborter()
+	runCtx, cancelRun := loadTestRunContext(ctx, opts)
+	defer cancelRun()
+	stopWatching := make(chan struct{})
+	defer close(stopWatching)
+	go func() {
+		select {
+		case <-runCtx.Done():
+			logrus.Debugf("context done, aborting the load test: %s", opts.Name)
+			aborter.Abort()
+		case <-stopWatching:
+		}
+	}()
+	percentiles := fortioDefaultPercentiles
+	if len(opts.Percentiles) > 0 {
+		percentiles = opts.Percentiles
+	}
 	ro := periodic.RunnerOptions{
 		QPS:         qps,
 		Duration:    opts.Duration,
 		NumThreads:  opts.HTTPNumThreads,
-		Percentiles: []float64{50, 75, 90, 99, 99.9},
+		Percentiles: percentiles,
 		Resolution:  defaults.Resolution,
 		Out:         out,
 		Labels:      labels,
 		Exactly:     0,
+		Stop:        aborter,
 	}
 	var res periodic.HasRunnerResult
-	var err error
 	if opts.IsGRPC {
+		// fortio loads the CA certificate from a file
+		caCertFile := ""
+		if opts.CACert != "" {
+			caCertFile, err = writeTempFile("meshery-ca-cert-", []byte(opts.CACert))
+			if err != nil {
+				err = errors.Wrap(err, "unable to store the CA certificate")
+				logrus.Error(err)
+				return nil, nil, err
+			}
+			defer func() {
+				_ = os.Remove(caCertFile)
+			}()
+		}
 		o := fgrpc.GRPCRunnerOptions{
 			RunnerOptions:      ro,
 			Destination:        rURL,
-			CACert:             opts.CACert,
+			CACert:             caCertFile,
+			CertOverride:       opts.CertOverride,
 			Service:            opts.GRPCHealthSvc,
 			Streams:            opts.GRPCStreamsCount,
 			AllowInitialErrors: opts.AllowInitialErrors,
@@ -105,7 +233,11 @@ func FortioLoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *peri
 			UsePing:            opts.GRPCDoPing,
 			UnixDomainSocket:   httpOpts.UnixDomainSocket,
 		}
-		res, err = fgrpc.RunGRPCTest(&o)
+		if opts.Recorder != nil {
+			res, err = runFortioGRPCTest(&o, opts)
+		} else {
+			res, err = fgrpc.RunGRPCTest(&o)
+		}
 	} else {
 		o := fhttp.HTTPRunnerOptions{
 			HTTPOptions:        *httpOpts,
@@ -114,7 +246,14 @@ func FortioLoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *peri
 			AllowInitialErrors: opts.AllowInitialErrors,
 			AbortOn:            0,
 		}
-		res, err = fhttp.RunHTTPTest(&o)
+		if opts.Recorder != nil || usesGoHTTPClient(opts) {
+			res, err = runFortioHTTPTest(&o, opts)
+		} else {
+			res, err = fhttp.RunHTTPTest(&o)
+		}
+	}
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
 	}
 	if err != nil {
 		err = errors.Wrap(err, "error while running tests")
@@ -150,3 +289,21 @@ func FortioLoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *peri
 	logrus.Debugf("Mapped version of the test: %+#v", resultsMap)
 	return resultsMap, result, nil
 }
+
+// writeTempFile writes the data to a new temporary file and returns its name
+func writeTempFile(prefix string, data []byte) (string, error) {
+	f, err := ioutil.TempFile("", prefix)
+	if err != nil {
+		return "", err
+	}
+	if _, err = f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	if err = f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,133 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"fortio.org/fortio/fgrpc"
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// fortioGRPCRunner is what the Fortio runner calls at the target rate on each stream of a gRPC load test, it does
+// what the runnable of Fortio does while also reporting the outcome of each call to the recorder, as 200 when the
+// service is serving, 503 when it is not and -1, like socket errors, when the call failed
+type fortioGRPCRunner struct {
+	health   grpc_health_v1.HealthClient
+	healthIn *grpc_health_v1.HealthCheckRequest
+	ping     fgrpc.PingServerClient
+	pingIn   *fgrpc.PingMessage
+	recorder models.LoadTestRecorder
+	retCodes fgrpc.HealthResultMap
+}
+
+// call makes a health check or ping call and returns its outcome
+func (r *fortioGRPCRunner) call() (string, error) {
+	if r.ping != nil {
+		if _, err := r.ping.Ping(context.Background(), r.pingIn); err != nil {
+			return fgrpc.Error, err
+		}
+		return grpc_health_v1.HealthCheckResponse_SERVING.String(), nil
+	}
+	res, err := r.health.Check(context.Background(), r.healthIn)
+	if err != nil {
+		return fgrpc.Error, err
+	}
+	return res.Status.String(), nil
+}
+
+// Run makes a call on the stream
+func (r *fortioGRPCRunner) Run(t int) {
+	start := time.Now()
+	status, _ := r.call()
+	if r.recorder != nil {
+		code := http.StatusOK
+		switch status {
+		case fgrpc.Error:
+			code = -1
+		case grpc_health_v1.HealthCheckResponse_SERVING.String():
+		default:
+			code = http.StatusServiceUnavailable
+		}
+		r.recorder.Record(code, time.Since(start))
+	}
+	r.retCodes[status]++
+}
+
+// runFortioGRPCTest runs a gRPC load test like fgrpc.RunGRPCTest, except that the outcome of the calls is reported to
+// the recorder of the test as they complete
+func runFortioGRPCTest(o *fgrpc.GRPCRunnerOptions, opts *models.LoadTestOptions) (*fgrpc.GRPCRunnerResults, error) {
+	if o.Streams < 1 {
+		o.Streams = 1
+	}
+	if o.NumThreads < 1 {
+		o.NumThreads = periodic.DefaultRunnerOptions.NumThreads
+	}
+	if o.UsePing {
+		o.RunType = "GRPC Ping"
+		if o.Delay > 0 {
+			o.RunType += fmt.Sprintf(" Delay=%v", o.Delay)
+		}
+	} else {
+		o.RunType = "GRPC Health"
+	}
+	if pll := len(o.Payload); pll > 0 {
+		o.RunType += fmt.Sprintf(" PayloadLength=%d", pll)
+	}
+	// every connection carries the given number of streams
+	o.NumThreads *= o.Streams
+	r := periodic.NewPeriodicRunner(&o.RunnerOptions)
+	defer r.Options().Abort()
+
+	var conns []*grpc.ClientConn
+	defer func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	}()
+	ts := time.Now().UnixNano()
+	for i := 0; i < r.Options().NumThreads; i++ {
+		if i%o.Streams == 0 {
+			conn, err := fgrpc.Dial(o)
+			if err != nil {
+				return nil, err
+			}
+			conns = append(conns, conn)
+		}
+		conn := conns[len(conns)-1]
+		runner := &fortioGRPCRunner{recorder: opts.Recorder, retCodes: fgrpc.HealthResultMap{}}
+		if o.UsePing {
+			runner.ping = fgrpc.NewPingServerClient(conn)
+			runner.pingIn = &fgrpc.PingMessage{Payload: o.Payload, DelayNanos: o.Delay.Nanoseconds(), Seq: int64(i), Ts: ts}
+		} else {
+			runner.health = grpc_health_v1.NewHealthClient(conn)
+			runner.healthIn = &grpc_health_v1.HealthCheckRequest{Service: o.Service}
+		}
+		// like fortio, every stream is checked before the test starts
+		if _, err := runner.call(); err != nil && !o.AllowInitialErrors {
+			return nil, fmt.Errorf("error in the first grpc call (ping = %v) for %s: %v", o.UsePing, o.Destination, err)
+		}
+		r.Options().Runners[i] = runner
+	}
+
+	total := &fgrpc.GRPCRunnerResults{
+		RetCodes:    fgrpc.HealthResultMap{},
+		Destination: o.Destination,
+		Streams:     o.Streams,
+		Ping:        o.UsePing,
+	}
+	total.RunnerResults = r.Run()
+	for _, runnable := range r.Options().Runners {
+		if runner, ok := runnable.(*fortioGRPCRunner); ok {
+			for status, count := range runner.retCodes {
+				total.RetCodes[status] += count
+			}
+		}
+	}
+	r.Options().ReleaseRunners()
+	return total, nil
+}
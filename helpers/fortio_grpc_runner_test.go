@@ -0,0 +1,40 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"fortio.org/fortio/fgrpc"
+	"github.com/layer5io/meshery/models"
+)
+
+func TestFortioGRPCTestReportsTheCallsToTheRecorder(t *testing.T) {
+	port := fgrpc.PingServerTCP("0", "", "", "meshery-test", 0)
+	for _, ping := range []bool{false, true} {
+		recorder := NewLoadTestProgressRecorder(true)
+		resultsMap, result, err := RunLoadGenerator(context.Background(), NewFortioLoadGenerator(), &models.LoadTestOptions{
+			URL:              fmt.Sprintf("localhost:%d", port),
+			IsGRPC:           true,
+			GRPCHealthSvc:    "meshery-test",
+			GRPCDoPing:       ping,
+			GRPCStreamsCount: 2,
+			HTTPNumThreads:   2,
+			HTTPQPS:          40,
+			Duration:         time.Second,
+			Recorder:         recorder,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshot := recorder.Snapshot()
+		if snapshot == nil || snapshot.Requests != result.DurationHistogram.Count || snapshot.Errors != 0 {
+			t.Errorf("ping %v: the recorder got %+v for the %d calls of the test", ping, snapshot, result.DurationHistogram.Count)
+		}
+		retCodes, _ := resultsMap["RetCodes"].(map[string]interface{})
+		if retCodes["SERVING"] != float64(result.DurationHistogram.Count) || resultsMap["NumThreads"] != float64(4) {
+			t.Errorf("ping %v: unexpected results over %v threads: %v", ping, resultsMap["NumThreads"], retCodes)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"fortio.org/fortio/fhttp"
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"github.com/layer5io/meshery/models"
+	"github.com/sirupsen/logrus"
+)
+
+// fortioHTTPRunner is what the Fortio runner calls at the target rate on each connection of an HTTP load test, it
+// does what the runnable of Fortio does while also reporting the outcome of each request to the recorder
+type fortioHTTPRunner struct {
+	client      fhttp.Fetcher
+	recorder    models.LoadTestRecorder
+	retCodes    map[int]int64
+	sizes       *stats.Histogram
+	headerSizes *stats.Histogram
+}
+
+// Run sends a request on the connection
+func (r *fortioHTTPRunner) Run(t int) {
+	start := time.Now()
+	code, body, headerSize := r.client.Fetch()
+	if r.recorder != nil {
+		r.recorder.Record(code, time.Since(start))
+	}
+	r.retCodes[code]++
+	r.sizes.Record(float64(len(body)))
+	r.headerSizes.Record(float64(headerSize))
+}
+
+// runFortioHTTPTest runs an HTTP load test like fhttp.RunHTTPTest, except that the outcome of the requests is
+// reported to the recorder of the test, if any, as they complete and that the TLS certificates of the test are used
+func runFortioHTTPTest(o *fhttp.HTTPRunnerOptions, opts *models.LoadTestOptions) (*fhttp.HTTPRunnerResults, error) {
+	o.RunType = "HTTP"
+	r := periodic.NewPeriodicRunner(&o.RunnerOptions)
+	defer r.Options().Abort()
+	o.HTTPOptions.Init(o.URL)
+
+	runners := make([]*fortioHTTPRunner, r.Options().NumThreads)
+	defer func() {
+		for _, runner := range runners {
+			if runner != nil {
+				runner.client.Close()
+			}
+		}
+	}()
+	for i := range runners {
+		client, err := newFortioHTTPClient(&o.HTTPOptions, opts)
+		if err != nil {
+			return nil, err
+		}
+		if client == nil {
+			return nil, fmt.Errorf("unable to create client %d for %s", i, o.URL)
+		}
+		runners[i] = &fortioHTTPRunner{
+			client:      client,
+			recorder:    opts.Recorder,
+			retCodes:    map[int]int64{},
+			sizes:       stats.NewHistogram(0, 100),
+			headerSizes: stats.NewHistogram(0, 5),
+		}
+		r.Options().Runners[i] = runners[i]
+		// like fortio, every connection is checked before the test starts
+		if code, data, _ := client.Fetch(); !o.AllowInitialErrors && code != http.StatusOK {
+			return nil, fmt.Errorf("error %d for %s: %q", code, o.URL, string(data))
+		}
+	}
+
+	total := &fhttp.HTTPRunnerResults{
+		RetCodes: map[int]int64{},
+		URL:      o.URL,
+	}
+	total.RunnerResults = r.Run()
+	sizes := stats.NewHistogram(0, 100)
+	headerSizes := stats.NewHistogram(0, 5)
+	for i, runner := range runners {
+		total.SocketCount += runner.client.Close()
+		runners[i] = nil
+		for code, count := range runner.retCodes {
+			total.RetCodes[code] += count
+		}
+		sizes.Transfer(runner.sizes)
+		headerSizes.Transfer(runner.headerSizes)
+	}
+	r.Options().ReleaseRunners()
+	total.Sizes = sizes.Export()
+	total.HeaderSizes = headerSizes.Export()
+	return total, nil
+}
+
+// usesGoHTTPClient returns true when the requests of the HTTP load test are sent with the Go client, as the clients
+// of fortio neither present client certificates, verify the server with a custom CA certificate nor send other
+// methods than GET and POST
+func usesGoHTTPClient(opts *models.LoadTestOptions) bool {
+	if opts.IsGRPC {
+		return false
+	}
+	method := opts.Method()
+	return opts.Cert != "" || opts.CACert != "" || (method != http.MethodGet && method != http.MethodPost)
+}
+
+// newFortioHTTPClient returns the client of a connection of an HTTP load test
+func newFortioHTTPClient(httpOpts *fhttp.HTTPOptions, opts *models.LoadTestOptions) (fhttp.Fetcher, error) {
+	if !usesGoHTTPClient(opts) {
+		// the client is nil when fortio is unable to create it
+		if client := fhttp.NewClient(httpOpts); client != nil {
+			return client, nil
+		}
+		return nil, nil
+	}
+	connOpts := *opts
+	connOpts.HTTPNumThreads = 1
+	client, err := scenarioHTTPClient(&connOpts)
+	if err != nil {
+		return nil, err
+	}
+	return &goHTTPFetcher{
+		client: client,
+		opts:   opts,
+		endpoint: &models.LoadTestEndpoint{
+			URL:         httpOpts.URL,
+			HTTPMethod:  opts.Method(),
+			ContentType: opts.ContentType,
+			Payload:     opts.Payload,
+		},
+	}, nil
+}
+
+// goHTTPFetcher sends the requests of a connection of an HTTP load test with the Go client
+type goHTTPFetcher struct {
+	client   *http.Client
+	opts     *models.LoadTestOptions
+	endpoint *models.LoadTestEndpoint
+}
+
+// Fetch sends a request and returns its status code, -1 on socket errors like fortio, along with its body
+func (f *goHTTPFetcher) Fetch() (int, []byte, int) {
+	req, err := newScenarioRequest(context.Background(), f.opts, f.endpoint, "")
+	if err != nil {
+		logrus.Debugf("unable to create the request to %s: %v", f.endpoint.URL, err)
+		return -1, nil, 0
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		logrus.Debugf("request to %s failed: %v", f.endpoint.URL, err)
+		return -1, nil, 0
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	return resp.StatusCode, body, 0
+}
+
+// Close closes the connection, the number of sockets used is not tracked
+func (f *goHTTPFetcher) Close() int {
+	f.client.CloseIdleConnections()
+	return 0
+}
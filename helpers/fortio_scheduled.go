@@ -0,0 +1,227 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"fortio.org/fortio/fhttp"
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"fortio.org/fortio/version"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// scheduledStats holds what the connections of a scheduled load test recorded over one of its stages, or over the
+// whole test for a test without stages
+type scheduledStats struct {
+	latencies   *stats.Histogram
+	sizes       *stats.Histogram
+	headerSizes *stats.Histogram
+	retCodes    map[int]int64
+}
+
+func newScheduledStats() *scheduledStats {
+	return &scheduledStats{
+		latencies:   stats.NewHistogram(0, periodic.DefaultRunnerOptions.Resolution),
+		sizes:       stats.NewHistogram(0, 100),
+		headerSizes: stats.NewHistogram(0, 5),
+		retCodes:    map[int]int64{},
+	}
+}
+
+// transfer moves what the other stats recorded to these ones
+func (s *scheduledStats) transfer(o *scheduledStats) {
+	s.latencies.Transfer(o.latencies)
+	s.sizes.Transfer(o.sizes)
+	s.headerSizes.Transfer(o.headerSizes)
+	for code, count := range o.retCodes {
+		s.retCodes[code] += count
+	}
+}
+
+// scheduledConnection holds a connection of a scheduled load test along with what it recorded over each stage
+type scheduledConnection struct {
+	client fhttp.Fetcher
+	stats  []*scheduledStats
+}
+
+// FortioScheduledLoadTest runs an HTTP load test with the Fortio clients paced by Meshery rather than by the runner
+// of Fortio, which is needed for the open load model, the warm-up and multi-stage load profiles. With the open load
+// model the requests are scheduled at the arrival rate of the test and sent by the first idle connection, their
+// latency being measured from the time they were scheduled at: when the target stalls, the requests scheduled
+// meanwhile queue up and their latencies account for the wait instead of being left out as with the closed-loop runner
+// of Fortio. The warm-up is run at the start of the test over the same connections, its requests being left out of
+// the results. The stages of a multi-stage profile are all run over the same connections, the rate and the number of
+// connections in use being ramped continuously, and the results of each stage are recorded under "stages" while the
+// overall results are the ones of the whole profile
+func FortioScheduledLoadTest(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	if opts.IsGRPC {
+		err := errors.New("fortio only supports the open load model, the warm-up and multi-stage load profiles with HTTP")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	model := opts.LoadModel
+	profile, err := newLoadProfile(opts)
+	if err != nil {
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	if model == models.OpenLoadModel && profile.unpaced {
+		err := errors.New("the open load model needs a target rate")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	httpOpts, err := sharedHTTPOptions(opts)
+	if err != nil {
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	if opts.IsInsecure {
+		httpOpts.Insecure = true
+	}
+	httpOpts.Init(httpOpts.URL)
+	rURL := httpOpts.URL
+
+	numConns := profile.maxConnections()
+	percentiles := fortioDefaultPercentiles
+	if len(opts.Percentiles) > 0 {
+		percentiles = opts.Percentiles
+	}
+
+	conns := make([]*scheduledConnection, numConns)
+	defer func() {
+		for _, c := range conns {
+			if c != nil {
+				c.client.Close()
+			}
+		}
+	}()
+	for i := range conns {
+		client, err := newFortioHTTPClient(httpOpts, opts)
+		if err != nil {
+			logrus.Error(err)
+			return nil, nil, err
+		}
+		if client == nil {
+			err := fmt.Errorf("unable to create client %d for %s", i, rURL)
+			logrus.Error(err)
+			return nil, nil, err
+		}
+		conns[i] = &scheduledConnection{client: client}
+		for s := 0; s < profile.stageCount(); s++ {
+			conns[i].stats = append(conns[i].stats, newScheduledStats())
+		}
+		// like fortio, every connection is checked before the test starts
+		if code, data, _ := client.Fetch(); !opts.AllowInitialErrors && code != http.StatusOK {
+			err := fmt.Errorf("error %d for %s: %q", code, rURL, string(data))
+			logrus.Error(err)
+			return nil, nil, err
+		}
+	}
+
+	logrus.Infof("Starting %s http test for %s with up to %d connections at %s qps after a warm-up of %v", model, rURL, numConns, profile.requestedQPS(0, opts.TotalDuration()), opts.WarmUp)
+	runCtx, cancelRun := loadTestRunContext(ctx, opts)
+	defer cancelRun()
+	start := time.Now()
+	measured := start.Add(opts.WarmUp)
+	startRecorderAfterWarmUp(runCtx, opts, start)
+	elapsed := runLoadSchedule(runCtx, start, profile, model, func(conn int, origin time.Time, warmingUp bool) {
+		c := conns[conn]
+		code, body, headerSize := c.client.Fetch()
+		if warmingUp {
+			return
+		}
+		latency := time.Since(origin)
+		if opts.Recorder != nil {
+			opts.Recorder.Record(code, latency)
+		}
+		st := c.stats[profile.stageAt(origin.Sub(measured))]
+		st.latencies.Record(latency.Seconds())
+		st.retCodes[code]++
+		st.sizes.Record(float64(len(body)))
+		st.headerSizes.Record(float64(headerSize))
+	})
+	if ctx.Err() != nil {
+		err := errors.Wrap(ctx.Err(), "error while running tests")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+
+	stageStats := make([]*scheduledStats, profile.stageCount())
+	total := newScheduledStats()
+	for s := range stageStats {
+		stageStats[s] = newScheduledStats()
+		for _, c := range conns {
+			stageStats[s].transfer(c.stats[s])
+		}
+	}
+	socketCount := 0
+	for i, c := range conns {
+		socketCount += c.client.Close()
+		conns[i] = nil
+	}
+
+	// results returns the results of the requests recorded by st, sent between from and to after the warm-up
+	results := func(st *scheduledStats, from, to, ran time.Duration, numConns int) *fhttp.HTTPRunnerResults {
+		return &fhttp.HTTPRunnerResults{
+			RunnerResults: periodic.RunnerResults{
+				RunType:           "HTTP",
+				Labels:            opts.Name + " -_- " + strings.TrimLeft(opts.URL, " \t\r\n"),
+				StartTime:         measured.Add(from),
+				RequestedQPS:      profile.requestedQPS(from, to),
+				RequestedDuration: (to - from).String(),
+				ActualQPS:         ratePerSecond(st.latencies.Count, ran),
+				ActualDuration:    ran,
+				NumThreads:        numConns,
+				Version:           version.Short(),
+				DurationHistogram: exportHistogram(st.latencies, percentiles),
+			},
+			RetCodes:    st.retCodes,
+			Sizes:       exportHistogram(st.sizes, nil),
+			HeaderSizes: exportHistogram(st.headerSizes, nil),
+			URL:         rURL,
+		}
+	}
+	stages := []map[string]interface{}{}
+	for s, stage := range profile.stages {
+		ran := stage.ran(elapsed)
+		if ran <= 0 {
+			// the stages left once the test is ended early are not reported
+			continue
+		}
+		stageMap := map[string]interface{}{}
+		if err := remarshal(results(stageStats[s], stage.offset, stage.offset+stage.length(), ran, stage.connections), &stageMap); err != nil {
+			logrus.Error(err)
+			return nil, nil, err
+		}
+		stages = append(stages, stage.results(stageMap))
+	}
+	for _, st := range stageStats {
+		total.transfer(st)
+	}
+	res := results(total, 0, opts.TotalDuration(), elapsed, numConns)
+	res.SocketCount = socketCount
+	bd, err := json.Marshal(res)
+	if err != nil {
+		err = errors.Wrap(err, "error while converting results to map")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	resultsMap := map[string]interface{}{}
+	if err = json.Unmarshal(bd, &resultsMap); err != nil {
+		err = errors.Wrap(err, "error while unmarshaling data to map")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	if len(profile.stages) > 0 {
+		resultsMap["stages"] = stages
+	}
+	logrus.Debugf("Mapped version of the test: %+#v", resultsMap)
+	return resultsMap, res.Result(), nil
+}
@@ -0,0 +1,97 @@
+package helpers
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/layer5io/meshery/models"
+)
+
+func TestFortioWarmUpReusesTheConnectionsOfTheTest(t *testing.T) {
+	var conns, requests int64
+	target := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&requests, 1)
+	}))
+	target.Config.ConnState = func(c net.Conn, s http.ConnState) {
+		if s == http.StateNew {
+			atomic.AddInt64(&conns, 1)
+		}
+	}
+	target.Start()
+	defer target.Close()
+
+	for _, model := range []models.LoadModel{models.ClosedLoadModel, models.OpenLoadModel} {
+		atomic.StoreInt64(&conns, 0)
+		atomic.StoreInt64(&requests, 0)
+		_, result, err := RunLoadGenerator(context.Background(), NewFortioLoadGenerator(), &models.LoadTestOptions{
+			URL:            target.URL,
+			HTTPQPS:        50,
+			HTTPNumThreads: 2,
+			Duration:       time.Second,
+			WarmUp:         time.Second,
+			LoadModel:      model,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := atomic.LoadInt64(&conns); n != 2 {
+			t.Errorf("%s: the test opened %d connections instead of 2", model, n)
+		}
+		// the results only hold the requests sent after the warm-up, out of the checks of the connections, the
+		// requests of the warm-up and the ones of the test
+		if c := result.DurationHistogram.Count; c < 40 || c > 52 || atomic.LoadInt64(&requests) < 2+45+c {
+			t.Errorf("%s: the results hold %d of the %d requests sent", model, c, atomic.LoadInt64(&requests))
+		}
+		if result.ActualDuration > 1100*time.Millisecond {
+			t.Errorf("%s: the warm-up is part of the duration %v of the results", model, result.ActualDuration)
+		}
+	}
+}
+
+func TestFortioStagesRunOverTheSameConnections(t *testing.T) {
+	var conns, requests int64
+	target := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&requests, 1)
+	}))
+	target.Config.ConnState = func(c net.Conn, s http.ConnState) {
+		if s == http.StateNew {
+			atomic.AddInt64(&conns, 1)
+		}
+	}
+	target.Start()
+	defer target.Close()
+
+	resultsMap, result, err := RunLoadGenerator(context.Background(), NewFortioLoadGenerator(), &models.LoadTestOptions{
+		URL: target.URL,
+		Stages: []*models.LoadTestStage{
+			{Name: "ramp-up", QPS: 40, Connections: 3, Ramp: time.Second},
+			{Name: "steady", QPS: 40, Connections: 3, Duration: time.Second},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt64(&conns); n != 3 {
+		t.Errorf("the stages opened %d connections instead of 3", n)
+	}
+	// the ramp from no load sends half the requests of the steady stage
+	stages, _ := resultsMap["stages"].([]map[string]interface{})
+	if len(stages) != 2 {
+		t.Fatalf("the results hold %d stages instead of 2", len(stages))
+	}
+	for i, expected := range []float64{20, 40} {
+		rr, _ := stages[i]["runner_results"].(map[string]interface{})
+		hist, _ := rr["DurationHistogram"].(map[string]interface{})
+		if count, _ := hist["Count"].(float64); count < expected-3 || count > expected+3 {
+			t.Errorf("the stage %s holds %v requests instead of about %v", stages[i]["name"], count, expected)
+		}
+	}
+	if result.RequestedQPS != "30" || result.DurationHistogram.Count != atomic.LoadInt64(&requests)-3 {
+		t.Errorf("the results of the stages at %s qps hold %d of the %d requests sent", result.RequestedQPS, result.DurationHistogram.Count, atomic.LoadInt64(&requests))
+	}
+}
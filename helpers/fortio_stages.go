@@ -0,0 +1,100 @@
+package helpers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+)
+
+// loadStage is a stage of a multi-stage load profile along with when it starts, after the end of the warm-up
+type loadStage struct {
+	*models.LoadTestStage
+	name        string
+	connections int
+	offset      time.Duration
+}
+
+// length returns how long the stage lasts, its ramp included
+func (s *loadStage) length() time.Duration {
+	return s.Ramp + s.Duration
+}
+
+// ran returns the time the stage ran for out of the elapsed time of the test, 0 when the test was ended before it
+func (s *loadStage) ran(elapsed time.Duration) time.Duration {
+	end := s.offset + s.length()
+	if elapsed < end {
+		end = elapsed
+	}
+	if end < s.offset {
+		return 0
+	}
+	return end - s.offset
+}
+
+// results returns the entry of the stage in the results of the test, holding the results of the requests sent during it
+func (s *loadStage) results(runnerResults map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"name":           s.name,
+		"qps":            s.QPS,
+		"connections":    s.connections,
+		"ramp":           s.Ramp.String(),
+		"duration":       s.Duration.String(),
+		"runner_results": runnerResults,
+	}
+}
+
+// stagedLoadProfile returns the load profile of a multi-stage test, every stage being linearly ramped from the targets
+// of the previous one, the first one from no load over one connection, and its warm-up being run at the targets of
+// the first stage. The whole profile is run over the same connections, the ones it does not call for at a time staying
+// idle
+func stagedLoadProfile(opts *models.LoadTestOptions) (*loadProfile, error) {
+	p := &loadProfile{warmUp: opts.WarmUp}
+	prevQPS, prevConns := 0.0, 1
+	var offset time.Duration
+	for i, stage := range opts.Stages {
+		name := stage.Name
+		if name == "" {
+			name = fmt.Sprintf("stage-%d", i+1)
+		}
+		conns := stage.Connections
+		if conns < 1 {
+			conns = 1
+		}
+		if i == 0 {
+			p.add(opts.WarmUp, stage.QPS, stage.QPS, conns, conns)
+		}
+		p.add(stage.Ramp, prevQPS, stage.QPS, prevConns, conns)
+		p.add(stage.Duration, stage.QPS, stage.QPS, conns, conns)
+		p.stages = append(p.stages, &loadStage{LoadTestStage: stage, name: name, connections: conns, offset: offset})
+		offset += stage.Ramp + stage.Duration
+		prevQPS, prevConns = stage.QPS, conns
+	}
+	if offset <= 0 {
+		return nil, errors.New("the load profile does not contain any stage with a duration")
+	}
+	return p, nil
+}
+
+// stageAt returns the index of the stage running d after the end of the warm-up, 0 for a test without stages
+func (p *loadProfile) stageAt(d time.Duration) int {
+	for i, s := range p.stages {
+		if d < s.offset+s.length() {
+			return i
+		}
+	}
+	if len(p.stages) == 0 {
+		return 0
+	}
+	return len(p.stages) - 1
+}
+
+// stageCount returns the number of slices the results of the test are recorded in, one per stage or one for a test
+// without stages
+func (p *loadProfile) stageCount() int {
+	if len(p.stages) == 0 {
+		return 1
+	}
+	return len(p.stages)
+}
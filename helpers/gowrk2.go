@@ -1,8 +1,15 @@
 package helpers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 
 	"fortio.org/fortio/fgrpc"
 	"fortio.org/fortio/fhttp"
@@ -13,8 +20,39 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// percentiles reported by the lua script used with wrk2
+var wrk2Percentiles = []float64{50, 75, 90, 99, 99.99, 99.999}
+
+// WRK2LoadGenerator is the LoadGenerator backed by wrk2
+type WRK2LoadGenerator struct{}
+
+// NewWRK2LoadGenerator returns a new WRK2LoadGenerator
+func NewWRK2LoadGenerator() *WRK2LoadGenerator {
+	return &WRK2LoadGenerator{}
+}
+
+// Name returns the name of the load generator
+func (w *WRK2LoadGenerator) Name() string {
+	return models.Wrk2LG.Name()
+}
+
+// Capabilities returns the features supported by wrk2
+func (w *WRK2LoadGenerator) Capabilities() models.LoadGeneratorCapabilities {
+	return models.LoadGeneratorCapabilities{
+		GRPC:        false,
+		Percentiles: wrk2Percentiles,
+		// wrk2 sends the requests at a constant rate and measures their latencies from the time they were due
+		LoadModels: []models.LoadModel{models.OpenLoadModel},
+	}
+}
+
+// Run runs the load test using wrk2
+func (w *WRK2LoadGenerator) Run(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	return WRK2LoadTest(ctx, opts)
+}
+
 // WRK2LoadTest is the actual code which invokes gowrk2 to run the load test
-func WRK2LoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+func WRK2LoadTest(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
 	qps := opts.HTTPQPS // TODO possibly use translated <=0 to "max" from results/options normalization in periodic/
 	if qps <= 0 {
 		qps = -1 // 0==unitialized struct == default duration, -1 (0 for flag) is max
@@ -22,6 +60,10 @@ func WRK2LoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *period
 	rURL := strings.TrimLeft(opts.URL, " \t\r\n")
 
 	labels := opts.Name + " -_- " + rURL
+	percentiles := wrk2Percentiles
+	if len(opts.Percentiles) > 0 {
+		percentiles = opts.Percentiles
+	}
 	ro := &api.GoWRK2Config{
 		DurationInSeconds: opts.Duration.Seconds(),
 		Thread:            opts.HTTPNumThreads,
@@ -29,17 +71,20 @@ func WRK2LoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *period
 		RQPS:        qps,
 		URL:         rURL,
 		Labels:      labels,
-		Percentiles: []float64{50, 75, 90, 99, 99.99, 99.999},
+		Percentiles: percentiles,
 	}
 	var res periodic.HasRunnerResult
-	var err error
 	if opts.IsGRPC {
 		err := errors.New("wrk2 does not support gRPC at the moment")
 		logrus.Error(err)
 		return nil, nil, err
 	}
-	var gres *api.GoWRK2
-	gres, err = api.WRKRun(ro)
+	gres, err := runWRK2(ctx, ro)
+	if ctx.Err() != nil {
+		err = errors.Wrap(ctx.Err(), "load test cancelled")
+		logrus.Error(err)
+		return nil, nil, err
+	}
 	if err == nil {
 		logrus.Debugf("WRK Result: %+v", gres)
 		res, err = api.TransformWRKToFortio(gres, ro)
@@ -79,3 +124,47 @@ func WRK2LoadTest(opts *models.LoadTestOptions) (map[string]interface{}, *period
 	logrus.Debugf("Mapped version of the test: %+#v", resultsMap)
 	return resultsMap, result, nil
 }
+
+// runWRK2 runs wrk2 like api.WRKRun does, except that the wrk2 process is killed once the context is done
+func runWRK2(ctx context.Context, config *api.GoWRK2Config) (*api.GoWRK2, error) {
+	wrkLoc := "./wrk2/wrk"
+	if loc := os.Getenv("WRK_LOCATION"); loc != "" {
+		wrkLoc = loc
+	}
+	rURL, err := url.Parse(config.URL)
+	if err != nil || !rURL.IsAbs() {
+		return nil, fmt.Errorf("given URL (%s) is not a valid URL", config.URL)
+	}
+	if rURL.Port() == "" {
+		if rURL.Scheme == "https" {
+			rURL.Host += ":443"
+		} else {
+			rURL.Host += ":80"
+		}
+	}
+	dur := strconv.FormatFloat(config.DurationInSeconds, 'f', -1, 64)
+	args := []string{"-t" + strconv.Itoa(config.Thread),
+		"-d" + dur + "s",
+		"-R" + strconv.FormatFloat(config.RQPS, 'f', -1, 64),
+		"-s", "./wrk2/scripts/multiple-endpoints_in_json.lua", rURL.String()}
+	logrus.Debugf("received command: wrk %v", args)
+
+	startTime := time.Now()
+	out, err := exec.CommandContext(ctx, wrkLoc, args...).Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to execute the requested command")
+	}
+	logrus.Debugf("Received output: %s", out)
+	raw := &api.GoWRK2{}
+	if err := json.Unmarshal(out, raw); err != nil {
+		// like gowrk2, the output is retried once without what precedes its first comma
+		i := strings.Index(string(out), ",")
+		if i < 0 || json.Unmarshal(out[i+1:], raw) != nil {
+			return nil, errors.Wrap(err, "unable to unmarshal the result")
+		}
+	}
+	raw.StartTime = startTime
+	raw.RequestedDuration = dur + "s"
+	raw.RequestedQPS = fmt.Sprintf("%f", config.RQPS)
+	return raw, nil
+}
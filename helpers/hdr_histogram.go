@@ -0,0 +1,133 @@
+package helpers
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+
+	"fortio.org/fortio/stats"
+)
+
+// the histograms are written with 3 significant digits and values in microseconds,
+// which is what HdrHistogram based tools expect for latencies by default
+const (
+	hdrSignificantDigits = 3
+	hdrLowestValue       = 1
+	// hdrSubBucketHalfCountMagnitude is log2 of half the number of sub buckets needed for 3 significant
+	// digits: ceil(log2(2 * 10^3)) - 1
+	hdrSubBucketHalfCountMagnitude = 10
+	hdrSubBucketHalfCount          = 1 << hdrSubBucketHalfCountMagnitude
+	hdrSubBucketMask               = 2*hdrSubBucketHalfCount - 1
+	// hdrLeadingZeroCountBase is 64 - unit magnitude (0 for a lowest value of 1) - hdrSubBucketHalfCountMagnitude - 1
+	hdrLeadingZeroCountBase = 64 - hdrSubBucketHalfCountMagnitude - 1
+
+	hdrEncodingCookieV2           = 0x1c849303 | 0x10
+	hdrCompressedEncodingCookieV2 = 0x1c849304 | 0x10
+)
+
+// hdrCountsIndex returns the index of the value in the counts array of an HdrHistogram
+func hdrCountsIndex(value int64) int {
+	bucket := hdrLeadingZeroCountBase - bits.LeadingZeros64(uint64(value)|hdrSubBucketMask)
+	subBucket := int(value >> uint(bucket))
+	return (bucket+1)<<hdrSubBucketHalfCountMagnitude + subBucket - hdrSubBucketHalfCount
+}
+
+// encodeHdrHistogram encodes the histogram in the compressed V2 format of HdrHistogram, the latencies of each
+// bucket, given in seconds, are recorded at the middle of the bucket
+func encodeHdrHistogram(h *stats.HistogramData) ([]byte, error) {
+	counts := []int64{}
+	maxValue := int64(0)
+	for _, b := range h.Data {
+		if b.Count == 0 {
+			continue
+		}
+		value := int64(math.Round((b.Start + b.End) / 2 * 1e6))
+		if value < hdrLowestValue {
+			value = hdrLowestValue
+		}
+		if value > maxValue {
+			maxValue = value
+		}
+		i := hdrCountsIndex(value)
+		for len(counts) <= i {
+			counts = append(counts, 0)
+		}
+		counts[i] += b.Count
+	}
+	highest := int64(time.Hour / time.Microsecond)
+	if highest < 2*maxValue {
+		highest = 2 * maxValue
+	}
+
+	// the counts are zig zag LEB128 encoded, with runs of zeros written as their negated length
+	payload := &bytes.Buffer{}
+	buf := make([]byte, binary.MaxVarintLen64)
+	for i := 0; i < len(counts); {
+		value := counts[i]
+		i++
+		if value == 0 {
+			zeros := int64(1)
+			for i < len(counts) && counts[i] == 0 {
+				zeros++
+				i++
+			}
+			value = -zeros
+		}
+		payload.Write(buf[:binary.PutVarint(buf, value)])
+	}
+
+	encoded := &bytes.Buffer{}
+	for _, v := range []interface{}{
+		int32(hdrEncodingCookieV2),
+		int32(payload.Len()),
+		int32(0), // normalizing index offset
+		int32(hdrSignificantDigits),
+		int64(hdrLowestValue),
+		highest,
+		float64(1), // integer to double value conversion ratio
+	} {
+		if err := binary.Write(encoded, binary.BigEndian, v); err != nil {
+			return nil, err
+		}
+	}
+	encoded.Write(payload.Bytes())
+
+	compressed := &bytes.Buffer{}
+	zw := zlib.NewWriter(compressed)
+	if _, err := zw.Write(encoded.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	out := &bytes.Buffer{}
+	if err := binary.Write(out, binary.BigEndian, int32(hdrCompressedEncodingCookieV2)); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(out, binary.BigEndian, int32(compressed.Len())); err != nil {
+		return nil, err
+	}
+	out.Write(compressed.Bytes())
+	return out.Bytes(), nil
+}
+
+// hdrHistogramLog returns the histogram as an HdrHistogram log with a single interval covering the whole test,
+// which can be read by the HdrHistogram log processing tools
+func hdrHistogramLog(h *stats.HistogramData, start time.Time, duration time.Duration) ([]byte, error) {
+	encoded, err := encodeHdrHistogram(h)
+	if err != nil {
+		return nil, err
+	}
+	out := &bytes.Buffer{}
+	fmt.Fprintln(out, "#[Histogram log format version 1.3]")
+	fmt.Fprintf(out, "#[StartTime: %.3f (seconds since epoch), %s]\n", float64(start.UnixNano())/1e9, start.UTC().Format(time.RFC1123))
+	fmt.Fprintln(out, `"StartTimestamp","Interval_Length","Interval_Max","Interval_Compressed_Histogram"`)
+	// the maximum is written in milliseconds
+	fmt.Fprintf(out, "%.3f,%.3f,%.3f,%s\n", 0.0, duration.Seconds(), h.Max*1000, base64.StdEncoding.EncodeToString(encoded))
+	return out.Bytes(), nil
+}
@@ -0,0 +1,94 @@
+package helpers
+
+import (
+	"math"
+	"sort"
+
+	"fortio.org/fortio/stats"
+)
+
+// MergeHistogramData merges the exported histograms of several runs into one and
+// computes the given percentiles on the merged data
+func MergeHistogramData(percentiles []float64, hs ...*stats.HistogramData) *stats.HistogramData {
+	merged := &stats.HistogramData{}
+	var sumSquares float64
+	buckets := map[stats.Interval]int64{}
+	first := true
+	for _, h := range hs {
+		if h == nil || h.Count == 0 {
+			continue
+		}
+		if first || h.Min < merged.Min {
+			merged.Min = h.Min
+		}
+		if first || h.Max > merged.Max {
+			merged.Max = h.Max
+		}
+		first = false
+		merged.Count += h.Count
+		merged.Sum += h.Sum
+		// sum of squares of each run, recovered from its average and standard deviation
+		sumSquares += float64(h.Count) * (h.StdDev*h.StdDev + h.Avg*h.Avg)
+		for _, b := range h.Data {
+			buckets[b.Interval] += b.Count
+		}
+	}
+	if merged.Count == 0 {
+		return merged
+	}
+	merged.Avg = merged.Sum / float64(merged.Count)
+	if variance := sumSquares/float64(merged.Count) - merged.Avg*merged.Avg; variance > 0 {
+		merged.StdDev = math.Sqrt(variance)
+	}
+
+	for interval, count := range buckets {
+		merged.Data = append(merged.Data, stats.Bucket{
+			Interval: interval,
+			Count:    count,
+		})
+	}
+	sort.Slice(merged.Data, func(i, j int) bool {
+		if merged.Data[i].End == merged.Data[j].End {
+			return merged.Data[i].Start < merged.Data[j].Start
+		}
+		return merged.Data[i].End < merged.Data[j].End
+	})
+	var cumulative int64
+	for i := range merged.Data {
+		cumulative += merged.Data[i].Count
+		merged.Data[i].Percent = 100 * float64(cumulative) / float64(merged.Count)
+	}
+
+	for _, p := range percentiles {
+		merged.Percentiles = append(merged.Percentiles, stats.Percentile{
+			Percentile: p,
+			Value:      bucketsPercentile(merged, p),
+		})
+	}
+	return merged
+}
+
+// bucketsPercentile estimates a percentile from the buckets, assuming the values are
+// evenly spread within each bucket, the same way fortio computes them
+func bucketsPercentile(h *stats.HistogramData, percentile float64) float64 {
+	if percentile >= 100 {
+		return h.Max
+	}
+	if percentile <= 0 {
+		return h.Min
+	}
+	var total int64
+	for _, b := range h.Data {
+		total += b.Count
+	}
+	target := percentile / 100 * float64(total)
+	var cumulative int64
+	for _, b := range h.Data {
+		if float64(cumulative+b.Count) >= target {
+			value := b.Start + (b.End-b.Start)*(target-float64(cumulative))/float64(b.Count)
+			return math.Min(math.Max(value, h.Min), h.Max)
+		}
+		cumulative += b.Count
+	}
+	return h.Max
+}
@@ -0,0 +1,361 @@
+package helpers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"github.com/gofrs/uuid"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// LoadTestJobOptionsEnv is the environment variable through which a load test job receives its options
+	LoadTestJobOptionsEnv = "LOAD_TEST_JOB_OPTIONS"
+
+	// DefaultLoadTestJobImage is the Meshery image run by the load test jobs unless another one is configured
+	DefaultLoadTestJobImage = "layer5/meshery:stable-latest"
+
+	// loadTestJobResultsMarker prefixes the line of the output of a load test job which holds its results
+	loadTestJobResultsMarker = "meshery-load-test-results: "
+
+	loadTestJobContainer  = "load-generator"
+	loadTestJobOptionsKey = "options"
+
+	// time given to the load generator pod to get scheduled, pull its image and start
+	loadTestJobStartTimeout = 5 * time.Minute
+
+	// the results line holds the whole histogram, way longer than the usual log lines
+	maxLoadTestJobResultsSize = 32 << 20
+
+	// number of output lines kept for reporting why a load generator failed
+	loadTestJobOutputTail = 10
+)
+
+// waiting reasons of the load generator container which it will not recover from by itself
+var loadTestJobStartFailures = map[string]bool{
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+// RunLoadTestJob runs the load test whose options are given in json with the matching load generator and writes its
+// results to out, it is what the Meshery image does when it is run as a load test job
+func RunLoadTestJob(ctx context.Context, optionsJSON string, loadGenerators map[string]models.LoadGenerator, out io.Writer) error {
+	opts := &models.LoadTestOptions{}
+	if err := json.Unmarshal([]byte(optionsJSON), opts); err != nil {
+		return errors.Wrap(err, "unable to parse the load test options")
+	}
+	lg, ok := loadGenerators[opts.LoadGenerator.Name()]
+	if !ok {
+		return fmt.Errorf("unknown load generator: %s", opts.LoadGenerator)
+	}
+	resultsMap, _, err := RunLoadGenerator(ctx, lg, opts)
+	if err != nil {
+		return errors.Wrap(err, "unable to perform the load test")
+	}
+	bd, err := json.Marshal(resultsMap)
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal the load test results")
+	}
+	_, err = fmt.Fprintf(out, "\n%s%s\n", loadTestJobResultsMarker, bd)
+	return err
+}
+
+// InClusterLoadTest runs the load generator as a Kubernetes job in the cluster of the given kubeconfig, so that the
+// load originates from within the cluster, and through the mesh when the sidecar is injected. The output of the load
+// generator is passed line by line to progress and the job is removed once the load test is over
+func InClusterLoadTest(ctx context.Context, kubeconfig []byte, contextName string, opts *models.LoadTestOptions, progress func(string)) (map[string]interface{}, *periodic.RunnerResults, error) {
+	inCluster := opts.InCluster
+	clientset, err := getK8SClientSet(kubeconfig, contextName)
+	if err != nil {
+		return nil, nil, err
+	}
+	clientConfig, err := getK8SClientConfig(kubeconfig, contextName)
+	if err != nil {
+		return nil, nil, err
+	}
+	// the output is followed for the whole duration of the load test, which rules out the timeout of the clientset
+	streamClientset, err := newK8SClientSet(clientConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jobOpts := *opts
+	jobOpts.InCluster = nil
+	jobOpts.Distributed = false
+	// the assertions are evaluated by Meshery once the results are back
+	jobOpts.SLOs = nil
+	optionsJSON, err := json.Marshal(&jobOpts)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to marshal the load test options")
+	}
+
+	id, _ := uuid.NewV4()
+	name := "meshery-load-test-" + id.String()[:8]
+	namespace := inCluster.Namespace
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+
+	// the options hold the headers and TLS material of the requests, hence the secret
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    loadTestJobLabels(),
+		},
+		Data: map[string][]byte{
+			loadTestJobOptionsKey: optionsJSON,
+		},
+	}
+	if secret, err = clientset.CoreV1().Secrets(namespace).Create(secret); err != nil {
+		err = errors.Wrapf(err, "unable to create the secret of the load test job in the namespace %s", namespace)
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	defer func() {
+		if err := clientset.CoreV1().Secrets(namespace).Delete(name, &metav1.DeleteOptions{}); err != nil {
+			logrus.Warn(errors.Wrapf(err, "unable to delete the secret of the load test job %s", name))
+		}
+	}()
+
+	job, err := clientset.BatchV1().Jobs(namespace).Create(loadTestJob(name, namespace, inCluster, opts.WarmUp+opts.TotalDuration()))
+	if err != nil {
+		err = errors.Wrapf(err, "unable to create the load test job in the namespace %s", namespace)
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	defer func() {
+		propagation := metav1.DeletePropagationBackground
+		if err := clientset.BatchV1().Jobs(namespace).Delete(name, &metav1.DeleteOptions{PropagationPolicy: &propagation}); err != nil {
+			logrus.Warn(errors.Wrapf(err, "unable to delete the load test job %s", name))
+		}
+	}()
+	// should Meshery go away before cleaning up, the secret is garbage collected along with the job
+	secret.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(job, batchv1.SchemeGroupVersion.WithKind("Job"))}
+	if _, err := clientset.CoreV1().Secrets(namespace).Update(secret); err != nil {
+		logrus.Warn(errors.Wrapf(err, "unable to set the owner of the secret of the load test job %s", name))
+	}
+	progress(fmt.Sprintf("Created the load test job %s in the namespace %s", name, namespace))
+
+	pod, err := waitLoadTestJobPod(ctx, clientset, namespace, name)
+	if err != nil {
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	progress(fmt.Sprintf("Load generator pod %s started", pod))
+
+	resultsMap, tail, err := followLoadTestJobOutput(ctx, streamClientset, namespace, pod, progress)
+	if err != nil {
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	if resultsMap == nil {
+		msg := fmt.Sprintf("the load generator pod %s exited without results%s", pod, loadTestJobExitStatus(clientset, namespace, pod))
+		if len(tail) > 0 {
+			msg += ", its last output was:\n" + strings.Join(tail, "\n")
+		}
+		err = errors.New(msg)
+		logrus.Error(err)
+		return nil, nil, err
+	}
+
+	resultsMap[models.InClusterLoadTestResultKey] = map[string]interface{}{
+		"namespace": namespace,
+		"pod":       pod,
+		"sidecar":   inCluster.InjectSidecar,
+	}
+	result := &periodic.RunnerResults{}
+	if err := remarshal(resultsMap, result); err != nil {
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	return resultsMap, result, nil
+}
+
+func loadTestJobLabels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       "meshery-load-test",
+		"app.kubernetes.io/managed-by": "meshery",
+	}
+}
+
+// loadTestJob returns a job running the load generator once, the annotations of the meshes which are supported
+// have them inject their sidecar in its pod or not
+func loadTestJob(name, namespace string, inCluster *models.InClusterLoadTest, duration time.Duration) *batchv1.Job {
+	image := inCluster.Image
+	if image == "" {
+		image = DefaultLoadTestJobImage
+	}
+	annotations := map[string]string{
+		"sidecar.istio.io/inject":             "false",
+		"linkerd.io/inject":                   "disabled",
+		"consul.hashicorp.com/connect-inject": "false",
+	}
+	if inCluster.InjectSidecar {
+		annotations["sidecar.istio.io/inject"] = "true"
+		annotations["linkerd.io/inject"] = "enabled"
+		annotations["consul.hashicorp.com/connect-inject"] = "true"
+		// no request should go out before the proxy is ready to handle it
+		annotations["proxy.istio.io/config"] = "holdApplicationUntilProxyStarts: true"
+	}
+	backoffLimit := int32(0)
+	// the sidecars keep the pod running once the load generator is done, the deadline only bounds a lost job
+	deadline := int64((duration + 2*loadTestJobStartTimeout) / time.Second)
+	ttl := int32(time.Hour / time.Second)
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    loadTestJobLabels(),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			ActiveDeadlineSeconds:   &deadline,
+			TTLSecondsAfterFinished: &ttl,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      loadTestJobLabels(),
+					Annotations: annotations,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{{
+						Name:            loadTestJobContainer,
+						Image:           image,
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Env: []corev1.EnvVar{{
+							Name: LoadTestJobOptionsEnv,
+							ValueFrom: &corev1.EnvVarSource{
+								SecretKeyRef: &corev1.SecretKeySelector{
+									LocalObjectReference: corev1.LocalObjectReference{Name: name},
+									Key:                  loadTestJobOptionsKey,
+								},
+							},
+						}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// waitLoadTestJobPod waits for the load generator container of the job to start and returns the name of its pod
+func waitLoadTestJobPod(ctx context.Context, clientset *kubernetes.Clientset, namespace, job string) (string, error) {
+	timeout := time.After(loadTestJobStartTimeout)
+	for {
+		pods, err := clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: "job-name=" + job})
+		if err != nil {
+			logrus.Warn(errors.Wrapf(err, "unable to get the pod of the load test job %s", job))
+		} else {
+			for _, pod := range pods.Items {
+				if pod.Status.Phase == corev1.PodFailed {
+					return "", fmt.Errorf("the load generator pod %s failed: %s %s", pod.Name, pod.Status.Reason, pod.Status.Message)
+				}
+				for _, cs := range pod.Status.ContainerStatuses {
+					if cs.Name != loadTestJobContainer {
+						continue
+					}
+					if cs.State.Running != nil || cs.State.Terminated != nil {
+						return pod.Name, nil
+					}
+					if w := cs.State.Waiting; w != nil && loadTestJobStartFailures[w.Reason] {
+						return "", fmt.Errorf("the load generator pod %s cannot start: %s: %s", pod.Name, w.Reason, w.Message)
+					}
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-timeout:
+			return "", fmt.Errorf("the load generator pod of the job %s did not start within %v", job, loadTestJobStartTimeout)
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// followLoadTestJobOutput passes the output of the load generator to progress until it exits, it returns the
+// results found in the output, if any, along with its last lines
+func followLoadTestJobOutput(ctx context.Context, clientset *kubernetes.Clientset, namespace, pod string, progress func(string)) (map[string]interface{}, []string, error) {
+	stream, err := clientset.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container: loadTestJobContainer,
+		Follow:    true,
+	}).Stream()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "unable to follow the output of the load generator pod %s", pod)
+	}
+	defer func() {
+		_ = stream.Close()
+	}()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// unblocks the scanner when the load test is cancelled
+		select {
+		case <-ctx.Done():
+			_ = stream.Close()
+		case <-done:
+		}
+	}()
+
+	var (
+		resultsMap map[string]interface{}
+		tail       []string
+	)
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), maxLoadTestJobResultsSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, loadTestJobResultsMarker) {
+			resultsMap = map[string]interface{}{}
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, loadTestJobResultsMarker)), &resultsMap); err != nil {
+				return nil, nil, errors.Wrap(err, "unable to parse the results of the load generator")
+			}
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		progress(line)
+		tail = append(tail, line)
+		if len(tail) > loadTestJobOutputTail {
+			tail = tail[1:]
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, nil, ctx.Err()
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, errors.Wrapf(err, "unable to read the output of the load generator pod %s", pod)
+	}
+	return resultsMap, tail, nil
+}
+
+// loadTestJobExitStatus describes how the load generator container exited, if known
+func loadTestJobExitStatus(clientset *kubernetes.Clientset, namespace, pod string) string {
+	p, err := clientset.CoreV1().Pods(namespace).Get(pod, metav1.GetOptions{})
+	if err != nil {
+		return ""
+	}
+	for _, cs := range p.Status.ContainerStatuses {
+		if cs.Name == loadTestJobContainer && cs.State.Terminated != nil {
+			return fmt.Sprintf(" (exit code %d, %s)", cs.State.Terminated.ExitCode, cs.State.Terminated.Reason)
+		}
+	}
+	return ""
+}
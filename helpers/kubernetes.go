@@ -13,6 +13,17 @@ import (
 )
 
 func getK8SClientSet(kubeconfig []byte, contextName string) (*kubernetes.Clientset, error) {
+	clientConfig, err := getK8SClientConfig(kubeconfig, contextName)
+	if err != nil {
+		return nil, err
+	}
+	clientConfig.Timeout = 2 * time.Second
+	return newK8SClientSet(clientConfig)
+}
+
+// getK8SClientConfig returns the config of the given context of the kubeconfig, or the in-cluster config
+// when no kubeconfig is given
+func getK8SClientConfig(kubeconfig []byte, contextName string) (*rest.Config, error) {
 	var clientConfig *rest.Config
 	var err error
 	if len(kubeconfig) == 0 {
@@ -39,7 +50,10 @@ func getK8SClientSet(kubeconfig []byte, contextName string) (*kubernetes.Clients
 			return nil, err
 		}
 	}
-	clientConfig.Timeout = 2 * time.Second
+	return clientConfig, nil
+}
+
+func newK8SClientSet(clientConfig *rest.Config) (*kubernetes.Clientset, error) {
 	clientset, err := kubernetes.NewForConfig(clientConfig)
 	if err != nil {
 		err = errors.Wrap(err, "unable to create client set")
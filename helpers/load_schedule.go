@@ -0,0 +1,247 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/layer5io/meshery/models"
+)
+
+// idleConnectionCheck is how often a connection the load profile does not call for checks whether it is called for again
+const idleConnectionCheck = 100 * time.Millisecond
+
+// loadSegment is a slice of a load profile over which the rate goes linearly from fromQPS to toQPS and the number of
+// connections from fromConns to toConns
+type loadSegment struct {
+	duration  time.Duration
+	fromQPS   float64
+	toQPS     float64
+	fromConns int
+	toConns   int
+}
+
+// requests returns how many requests are due over the first d of the segment
+func (s *loadSegment) requests(d time.Duration) float64 {
+	t := d.Seconds()
+	return s.fromQPS*t + (s.toQPS-s.fromQPS)*t*t/(2*s.duration.Seconds())
+}
+
+// timeOf returns how long into the segment the requests due add up to n, n being at most the requests of the segment
+func (s *loadSegment) timeOf(n float64) time.Duration {
+	if n <= 0 {
+		return 0
+	}
+	// the positive root of a*t^2 + fromQPS*t - n, in a form which holds for a constant rate as well
+	a := (s.toQPS - s.fromQPS) / (2 * s.duration.Seconds())
+	t := 2 * n / (s.fromQPS + math.Sqrt(math.Max(s.fromQPS*s.fromQPS+4*a*n, 0)))
+	return time.Duration(t * float64(time.Second))
+}
+
+// connections returns the number of connections called for d into the segment
+func (s *loadSegment) connections(d time.Duration) int {
+	frac := d.Seconds() / s.duration.Seconds()
+	return int(math.Round(float64(s.fromConns) + float64(s.toConns-s.fromConns)*frac))
+}
+
+// loadProfile is how the load of a test goes over time, from the start of its warm-up, the requests of an unpaced
+// profile being sent back to back over its connections
+type loadProfile struct {
+	segments []*loadSegment
+	warmUp   time.Duration
+	unpaced  bool
+	// stages are the stages of a multi-stage test, in the order they are run in
+	stages []*loadStage
+}
+
+// newLoadProfile returns the load profile of the test, made of its stages if any, its warm-up being run at the targets
+// the test starts with
+func newLoadProfile(opts *models.LoadTestOptions) (*loadProfile, error) {
+	if len(opts.Stages) > 0 {
+		return stagedLoadProfile(opts)
+	}
+	conns := opts.HTTPNumThreads
+	if conns < 1 {
+		conns = 1
+	}
+	p := &loadProfile{warmUp: opts.WarmUp, unpaced: opts.HTTPQPS <= 0}
+	p.add(opts.WarmUp, opts.HTTPQPS, opts.HTTPQPS, conns, conns)
+	p.add(opts.Duration, opts.HTTPQPS, opts.HTTPQPS, conns, conns)
+	return p, nil
+}
+
+// add appends a segment to the profile, the segments which do not last being left out
+func (p *loadProfile) add(d time.Duration, fromQPS, toQPS float64, fromConns, toConns int) {
+	if d <= 0 {
+		return
+	}
+	p.segments = append(p.segments, &loadSegment{duration: d, fromQPS: fromQPS, toQPS: toQPS, fromConns: fromConns, toConns: toConns})
+}
+
+// length returns how long the profile lasts, warm-up included
+func (p *loadProfile) length() time.Duration {
+	var d time.Duration
+	for _, s := range p.segments {
+		d += s.duration
+	}
+	return d
+}
+
+// maxConnections returns the number of connections the test needs at its peak
+func (p *loadProfile) maxConnections() int {
+	max := 1
+	for _, s := range p.segments {
+		if s.fromConns > max {
+			max = s.fromConns
+		}
+		if s.toConns > max {
+			max = s.toConns
+		}
+	}
+	return max
+}
+
+// connectionsAt returns the number of connections called for d after the start of the profile
+func (p *loadProfile) connectionsAt(d time.Duration) int {
+	for _, s := range p.segments {
+		if d < s.duration {
+			return s.connections(d)
+		}
+		d -= s.duration
+	}
+	return 0
+}
+
+// requestsUntil returns how many requests are due over the first d of the profile
+func (p *loadProfile) requestsUntil(d time.Duration) float64 {
+	n := 0.0
+	for _, s := range p.segments {
+		if d < s.duration {
+			return n + s.requests(d)
+		}
+		n += s.requests(s.duration)
+		d -= s.duration
+	}
+	return n
+}
+
+// dueAt returns how long after the start of the profile the request n of the test is due, false when the requests of
+// the profile are fewer
+func (p *loadProfile) dueAt(n int64) (time.Duration, bool) {
+	var offset time.Duration
+	left := float64(n)
+	for _, s := range p.segments {
+		count := s.requests(s.duration)
+		if left < count {
+			return offset + s.timeOf(left), true
+		}
+		left -= count
+		offset += s.duration
+	}
+	return 0, false
+}
+
+// requestedQPS returns the rate requested from the test between from and to after the end of its warm-up, the average
+// rate of the ramps included
+func (p *loadProfile) requestedQPS(from, to time.Duration) string {
+	if p.unpaced || to <= from {
+		return "max"
+	}
+	requests := p.requestsUntil(p.warmUp+to) - p.requestsUntil(p.warmUp+from)
+	return fmt.Sprintf("%g", requests/(to-from).Seconds())
+}
+
+// runLoadSchedule has the connections of the test, which starts at start, call send until the requests of its load
+// profile were all sent and returns how long the test took once warmed up. The requests are due at the rate of the
+// profile at the time and sent by the first idle connection among the ones the profile calls for, or back to back by
+// each connection of an unpaced profile. With the open load model send is given the time the request was due at, so
+// that the latency accounts for the time the request waited for a connection, otherwise it is given the time the
+// request was actually sent at. The test starts with its warm-up over the same connections, send being told whether
+// the request is part of it
+func runLoadSchedule(ctx context.Context, start time.Time, profile *loadProfile, model models.LoadModel, send func(conn int, origin time.Time, warmingUp bool)) time.Duration {
+	measured := start.Add(profile.warmUp)
+	end := start.Add(profile.length())
+	open := model == models.OpenLoadModel && !profile.unpaced
+	next := int64(-1)
+	wg := sync.WaitGroup{}
+	for i := 0; i < profile.maxConnections(); i++ {
+		wg.Add(1)
+		go func(conn int) {
+			defer wg.Done()
+			timer := time.NewTimer(0)
+			defer timer.Stop()
+			for ctx.Err() == nil {
+				now := time.Now()
+				if !now.Before(end) {
+					return
+				}
+				var due time.Time
+				switch {
+				case conn >= profile.connectionsAt(now.Sub(start)):
+					// the connection stays open, without sending requests, until the profile calls for it again
+					due = now.Add(idleConnectionCheck)
+					if due.After(end) {
+						due = end
+					}
+					if !waitUntil(ctx, timer, due) {
+						return
+					}
+					continue
+				case profile.unpaced:
+					due = now
+				default:
+					d, ok := profile.dueAt(atomic.AddInt64(&next, 1))
+					if !ok {
+						return
+					}
+					due = start.Add(d)
+				}
+				if !due.Before(end) || !waitUntil(ctx, timer, due) {
+					return
+				}
+				origin := due
+				if !open {
+					origin = time.Now()
+				}
+				send(conn, origin, origin.Before(measured))
+			}
+		}(i)
+	}
+	wg.Wait()
+	if elapsed := time.Since(measured); elapsed > 0 {
+		return elapsed
+	}
+	return 0
+}
+
+// waitUntil waits on the timer until t, false when the context is done first
+func waitUntil(ctx context.Context, timer *time.Timer, t time.Time) bool {
+	wait := time.Until(t)
+	if wait <= 0 {
+		return true
+	}
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(wait)
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// ratePerSecond returns the rate of count over d, 0 when the test was ended before d started, like during its warm-up
+func ratePerSecond(count int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(count) / d.Seconds()
+}
@@ -0,0 +1,271 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"github.com/layer5io/meshery/models"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// the guardrails computed from the requests are checked every guardCheckInterval, the Prometheus ones
+	// every guardPrometheusInterval
+	guardCheckInterval      = time.Second
+	guardPrometheusInterval = 5 * time.Second
+
+	// the error rate and the latencies of a window with fewer requests are not checked, so that a couple of
+	// early failures do not abort the test
+	guardMinRequests = 20
+)
+
+// guardBucket holds what was recorded during a second of the test
+type guardBucket struct {
+	second    int64
+	requests  int64
+	errors    int64
+	latencies *stats.Histogram
+}
+
+// LoadTestGuard ends a running load test early once any of its guardrails trips, it records the outcome of the
+// requests of the test before passing it on to the next recorder, if any
+type LoadTestGuard struct {
+	guardrails []*models.LoadTestGuardrail
+	next       models.LoadTestRecorder
+	promClient *models.PrometheusClient
+	promURL    string
+
+	started bool
+	// buckets cover the longest window of the guardrails, the oldest first
+	buckets   []*guardBucket
+	retention time.Duration
+	abort     *models.LoadTestAbort
+	stopped   chan struct{}
+	lock      *sync.Mutex
+}
+
+// NewLoadTestGuard creates a new instance of LoadTestGuard, the Prometheus guardrails are evaluated with the
+// Prometheus at promURL
+func NewLoadTestGuard(guardrails []*models.LoadTestGuardrail, next models.LoadTestRecorder, promClient *models.PrometheusClient, promURL string) *LoadTestGuard {
+	g := &LoadTestGuard{
+		guardrails: guardrails,
+		next:       next,
+		promClient: promClient,
+		promURL:    promURL,
+		stopped:    make(chan struct{}),
+		lock:       &sync.Mutex{},
+	}
+	for _, gr := range guardrails {
+		if gr.Window > g.retention {
+			g.retention = gr.Window
+		}
+	}
+	return g
+}
+
+// Start marks the start of the test, the guardrails are only checked from then on
+func (g *LoadTestGuard) Start() {
+	g.lock.Lock()
+	g.started = true
+	g.buckets = nil
+	g.lock.Unlock()
+	if g.next != nil {
+		g.next.Start()
+	}
+}
+
+// Record records the status code and the latency of a request and checks the status guardrails against it
+func (g *LoadTestGuard) Record(code int, latency time.Duration) {
+	g.lock.Lock()
+	now := time.Now()
+	second := now.Unix()
+	if len(g.buckets) == 0 || g.buckets[len(g.buckets)-1].second != second {
+		g.buckets = append(g.buckets, &guardBucket{
+			second:    second,
+			latencies: stats.NewHistogram(0, periodic.DefaultRunnerOptions.Resolution),
+		})
+	}
+	b := g.buckets[len(g.buckets)-1]
+	b.requests++
+	if code < 200 || code > 299 {
+		b.errors++
+	}
+	b.latencies.Record(latency.Seconds())
+	for _, gr := range g.guardrails {
+		if g.started && gr.MatchesStatus(code) {
+			g.trip(gr, float64(code), fmt.Sprintf("a request got the status code %d", code))
+		}
+	}
+	g.lock.Unlock()
+	if g.next != nil {
+		g.next.Record(code, latency)
+	}
+}
+
+// Stopped returns a channel which is closed once a guardrail trips
+func (g *LoadTestGuard) Stopped() <-chan struct{} {
+	return g.stopped
+}
+
+// Abort returns why the test was ended early, nil if no guardrail tripped
+func (g *LoadTestGuard) Abort() *models.LoadTestAbort {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.abort
+}
+
+// Watch checks the guardrails computed from the requests and the Prometheus ones, once the test started, until
+// one of them trips or the context is done
+func (g *LoadTestGuard) Watch(ctx context.Context) {
+	ticker := time.NewTicker(guardCheckInterval)
+	defer ticker.Stop()
+	var lastPromCheck time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-g.stopped:
+			return
+		case <-ticker.C:
+		}
+		g.lock.Lock()
+		started := g.started
+		if started {
+			g.checkWindows(time.Now())
+		}
+		g.lock.Unlock()
+		if started && time.Since(lastPromCheck) >= guardPrometheusInterval {
+			lastPromCheck = time.Now()
+			g.checkPrometheus(ctx)
+		}
+	}
+}
+
+// checkWindows checks the guardrails computed from the requests completed over their window, must be called
+// with the lock held
+func (g *LoadTestGuard) checkWindows(now time.Time) {
+	// the buckets past the longest window are dropped
+	n := 0
+	for n < len(g.buckets) && now.Sub(time.Unix(g.buckets[n].second+1, 0)) > g.retention {
+		n++
+	}
+	g.buckets = g.buckets[n:]
+
+	for _, gr := range g.guardrails {
+		a := gr.Assertion
+		if a == nil || gr.IsPrometheus() {
+			continue
+		}
+		var requests, errors int64
+		hists := []*stats.HistogramData{}
+		for _, b := range g.buckets {
+			// the window is made of the seconds which started within it
+			if now.Sub(time.Unix(b.second, 0)) > gr.Window {
+				continue
+			}
+			requests += b.requests
+			errors += b.errors
+			hists = append(hists, b.latencies.Export())
+		}
+		if requests < guardMinRequests {
+			continue
+		}
+		var value float64
+		switch a.Metric {
+		case models.SLOErrorRate:
+			value = 100 * float64(errors) / float64(requests)
+		case models.SLOLatencyPercentile:
+			h := MergeHistogramData([]float64{a.Percentile}, hists...)
+			value = h.Percentiles[0].Value * 1000
+		case models.SLOLatencyAvg:
+			value = MergeHistogramData(nil, hists...).Avg * 1000
+		case models.SLOLatencyMax:
+			value = MergeHistogramData(nil, hists...).Max * 1000
+		default:
+			continue
+		}
+		if a.Check(value) {
+			g.trip(gr, value, fmt.Sprintf("%s was %g over the last %v", a.Expr, value, gr.Window))
+			return
+		}
+	}
+}
+
+// checkPrometheus evaluates the Prometheus guardrails over their window
+func (g *LoadTestGuard) checkPrometheus(ctx context.Context) {
+	for _, gr := range g.guardrails {
+		if !gr.IsPrometheus() {
+			continue
+		}
+		a := gr.Assertion
+		if g.promURL == "" {
+			continue
+		}
+		end := time.Now()
+		start := end.Add(-gr.Window)
+		data, err := g.promClient.QueryRangeUsingClient(ctx, g.promURL, a.Query, start, end, g.promClient.ComputeStep(ctx, start, end))
+		if err != nil {
+			logrus.Warnf("unable to evaluate the guardrail %q: %v", gr.Expr, err)
+			continue
+		}
+		value, err := aggregatePrometheusValue(data, a.Metric == models.SLOPrometheusMax)
+		if err != nil {
+			logrus.Warnf("unable to evaluate the guardrail %q: %v", gr.Expr, err)
+			continue
+		}
+		if a.Check(value) {
+			g.lock.Lock()
+			g.trip(gr, value, fmt.Sprintf("%s was %g over the last %v", a.Query, value, gr.Window))
+			g.lock.Unlock()
+			return
+		}
+	}
+}
+
+// trip ends the test because of the guardrail, must be called with the lock held
+func (g *LoadTestGuard) trip(gr *models.LoadTestGuardrail, value float64, reason string) {
+	if g.abort != nil {
+		return
+	}
+	g.abort = &models.LoadTestAbort{
+		Guardrail: gr.Expr,
+		Value:     value,
+		Time:      time.Now(),
+		Reason:    reason,
+	}
+	logrus.Warnf("guardrail %q tripped, aborting the load test: %s", gr.Expr, reason)
+	close(g.stopped)
+}
+
+// loadTestRunContext returns the context of the run of a load test, which is also done once the recorder of the
+// test ends it early, in which case the load generators return the results gathered until then
+func loadTestRunContext(ctx context.Context, opts *models.LoadTestOptions) (context.Context, context.CancelFunc) {
+	runCtx, cancel := context.WithCancel(ctx)
+	if opts.Recorder != nil && opts.Recorder.Stopped() != nil {
+		go func() {
+			select {
+			case <-opts.Recorder.Stopped():
+				cancel()
+			case <-runCtx.Done():
+			}
+		}()
+	}
+	return runCtx, cancel
+}
+
+// loadTestStopped returns true once the recorder of the test ended it early
+func loadTestStopped(opts *models.LoadTestOptions) bool {
+	if opts.Recorder == nil {
+		return false
+	}
+	select {
+	case <-opts.Recorder.Stopped():
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,246 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/layer5io/meshery/models"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// finished jobs are kept around for this long so their status can still be polled
+	loadTestJobRetention = 24 * time.Hour
+
+	loadTestJobSubscriberBuffer = 100
+)
+
+type loadTestJobEntry struct {
+	job         *models.LoadTestJob
+	cancel      context.CancelFunc
+	history     []*models.LoadTestResponse
+	subscribers map[int]chan *models.LoadTestResponse
+	// progress is the latest snapshot of the live metrics, only the latest one is replayed
+	progress *models.LoadTestResponse
+	// queued is the latest position of the job in the run queue, replayed while the job is queued
+	queued *models.LoadTestResponse
+}
+
+// LoadTestJobTracker tracks the load test jobs run by this Meshery instance
+type LoadTestJobTracker struct {
+	jobs    map[string]*loadTestJobEntry
+	nextSub int
+	jLock   *sync.Mutex
+}
+
+// NewLoadTestJobTracker creates a new instance of LoadTestJobTracker
+func NewLoadTestJobTracker() *LoadTestJobTracker {
+	return &LoadTestJobTracker{
+		jobs:  map[string]*loadTestJobEntry{},
+		jLock: &sync.Mutex{},
+	}
+}
+
+// AddJob registers a new job along with the func which cancels it
+func (a *LoadTestJobTracker) AddJob(ctx context.Context, job *models.LoadTestJob, cancel context.CancelFunc) {
+	a.jLock.Lock()
+	defer a.jLock.Unlock()
+	a.pruneJobs()
+	if job.Status == "" {
+		job.Status = models.LoadTestJobRunning
+	}
+	if job.StartTime.IsZero() {
+		job.StartTime = time.Now()
+	}
+	a.jobs[job.ID] = &loadTestJobEntry{
+		job:         job,
+		cancel:      cancel,
+		subscribers: map[int]chan *models.LoadTestResponse{},
+	}
+}
+
+// pruneJobs removes finished jobs past their retention, must be called with the lock held
+func (a *LoadTestJobTracker) pruneJobs() {
+	for id, entry := range a.jobs {
+		if entry.job.EndTime != nil && time.Since(*entry.job.EndTime) > loadTestJobRetention {
+			delete(a.jobs, id)
+		}
+	}
+}
+
+// GetJob returns a copy of the job with the given ID
+func (a *LoadTestJobTracker) GetJob(ctx context.Context, id string) (*models.LoadTestJob, bool) {
+	a.jLock.Lock()
+	defer a.jLock.Unlock()
+	entry, ok := a.jobs[id]
+	if !ok {
+		return nil, false
+	}
+	job := *entry.job
+	return &job, true
+}
+
+// GetJobs returns copies of all the tracked jobs ordered by start time
+func (a *LoadTestJobTracker) GetJobs(ctx context.Context) []*models.LoadTestJob {
+	a.jLock.Lock()
+	defer a.jLock.Unlock()
+	jobs := make([]*models.LoadTestJob, 0, len(a.jobs))
+	for _, entry := range a.jobs {
+		job := *entry.job
+		jobs = append(jobs, &job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartTime.Before(jobs[j].StartTime)
+	})
+	return jobs
+}
+
+// CancelJob cancels a running job
+func (a *LoadTestJobTracker) CancelJob(ctx context.Context, id string) error {
+	a.jLock.Lock()
+	defer a.jLock.Unlock()
+	entry, ok := a.jobs[id]
+	if !ok {
+		return fmt.Errorf("load test job %s not found", id)
+	}
+	if entry.job.Status.Done() {
+		return fmt.Errorf("load test job %s is already %s", id, entry.job.Status)
+	}
+	entry.job.Status = models.LoadTestJobCancelled
+	entry.job.Message = "Load test cancelled"
+	if entry.cancel != nil {
+		entry.cancel()
+	}
+	return nil
+}
+
+// Publish records a response for the job and ships it to all the subscribers
+func (a *LoadTestJobTracker) Publish(ctx context.Context, id string, resp *models.LoadTestResponse) {
+	a.jLock.Lock()
+	defer a.jLock.Unlock()
+	entry, ok := a.jobs[id]
+	if !ok {
+		return
+	}
+	resp.JobID = id
+	if resp.Status == models.LoadTestQueued {
+		entry.job.QueuePosition = resp.QueuePosition
+		entry.queued = resp
+	} else if resp.Status == models.LoadTestProgress {
+		entry.job.Progress = resp.Progress
+		entry.progress = resp
+	} else if entry.job.Status != models.LoadTestJobCancelled {
+		switch resp.Status {
+		case models.LoadTestError:
+			entry.job.Status = models.LoadTestJobFailed
+		case models.LoadTestSuccess:
+			entry.job.Status = models.LoadTestJobCompleted
+			if resp.Result != nil {
+				if resp.ResultPersisted {
+					entry.job.ResultID = resp.Result.ID.String()
+				}
+				if resp.Result.Verdict != nil {
+					passed := resp.Result.Verdict.Passed
+					entry.job.SLOPassed = &passed
+				}
+			}
+		}
+		if resp.Message != "" {
+			entry.job.Message = resp.Message
+		}
+	}
+	if resp.Status != models.LoadTestProgress && resp.Status != models.LoadTestQueued {
+		entry.history = append(entry.history, resp)
+	}
+	for subID, sub := range entry.subscribers {
+		// the subscribers are only written to with the lock held, the last slot of their buffer being kept for
+		// the notice of a drop
+		if len(sub) < cap(sub)-1 {
+			sub <- resp
+			continue
+		}
+		// a subscriber which is unable to keep up is dropped, it can always re-attach and replay the history
+		logrus.Warnf("dropping slow subscriber %d of load test job %s", subID, id)
+		sub <- &models.LoadTestResponse{
+			Status:  models.LoadTestDropped,
+			Message: fmt.Sprintf("the event stream fell behind and was dropped while the load test goes on, re-attach to /api/load-test/%s/events", id),
+			JobID:   id,
+		}
+		close(sub)
+		delete(entry.subscribers, subID)
+	}
+}
+
+// StartJob marks a queued job as running
+func (a *LoadTestJobTracker) StartJob(ctx context.Context, id string) {
+	a.jLock.Lock()
+	defer a.jLock.Unlock()
+	entry, ok := a.jobs[id]
+	if !ok || entry.job.Status != models.LoadTestJobQueued {
+		return
+	}
+	now := time.Now()
+	entry.job.Status = models.LoadTestJobRunning
+	entry.job.QueuePosition = 0
+	entry.job.RunTime = &now
+}
+
+// FinishJob marks the job as done and closes all the subscriber channels
+func (a *LoadTestJobTracker) FinishJob(ctx context.Context, id string) {
+	a.jLock.Lock()
+	defer a.jLock.Unlock()
+	entry, ok := a.jobs[id]
+	if !ok {
+		return
+	}
+	if !entry.job.Status.Done() {
+		// the runner ended without a final response
+		entry.job.Status = models.LoadTestJobFailed
+	}
+	now := time.Now()
+	entry.job.EndTime = &now
+	for subID, sub := range entry.subscribers {
+		close(sub)
+		delete(entry.subscribers, subID)
+	}
+}
+
+// Subscribe returns a channel which replays the responses published so far followed by the live ones,
+// along with a func to unsubscribe
+func (a *LoadTestJobTracker) Subscribe(ctx context.Context, id string) (<-chan *models.LoadTestResponse, func(), error) {
+	a.jLock.Lock()
+	defer a.jLock.Unlock()
+	entry, ok := a.jobs[id]
+	if !ok {
+		return nil, nil, fmt.Errorf("load test job %s not found", id)
+	}
+	sub := make(chan *models.LoadTestResponse, len(entry.history)+3+loadTestJobSubscriberBuffer)
+	for _, resp := range entry.history {
+		sub <- resp
+	}
+	if entry.queued != nil && entry.job.Status == models.LoadTestJobQueued {
+		sub <- entry.queued
+	}
+	if entry.progress != nil && !entry.job.Status.Done() {
+		sub <- entry.progress
+	}
+	if entry.job.EndTime != nil {
+		close(sub)
+		return sub, func() {}, nil
+	}
+	subID := a.nextSub
+	a.nextSub++
+	entry.subscribers[subID] = sub
+	unsubscribe := func() {
+		a.jLock.Lock()
+		defer a.jLock.Unlock()
+		if s, ok := entry.subscribers[subID]; ok {
+			close(s)
+			delete(entry.subscribers, subID)
+		}
+	}
+	return sub, unsubscribe, nil
+}
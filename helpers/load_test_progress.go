@@ -0,0 +1,103 @@
+package helpers
+
+import (
+	"sync"
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"github.com/layer5io/meshery/models"
+)
+
+// loadTestProgressPercentiles are the latency percentiles of the snapshots
+var loadTestProgressPercentiles = []float64{50, 90, 99}
+
+// LoadTestProgressRecorder records the outcome of the requests of a running load test and turns them into
+// snapshots of its live metrics, the latencies of each snapshot covering the requests completed since the
+// previous one. The snapshots of the tests whose requests are not recorded as they run only hold the elapsed time
+type LoadTestProgressRecorder struct {
+	live      bool
+	started   bool
+	start     time.Time
+	last      time.Time
+	requests  int64
+	errors    map[int]int64
+	latencies *stats.Histogram
+	lock      *sync.Mutex
+}
+
+// NewLoadTestProgressRecorder creates a new instance of LoadTestProgressRecorder, no snapshots are taken until
+// the test is started, live being false when the requests of the test are not recorded as it runs
+func NewLoadTestProgressRecorder(live bool) *LoadTestProgressRecorder {
+	return &LoadTestProgressRecorder{
+		live:      live,
+		errors:    map[int]int64{},
+		latencies: stats.NewHistogram(0, periodic.DefaultRunnerOptions.Resolution),
+		lock:      &sync.Mutex{},
+	}
+}
+
+// Start marks the start of the test, what was recorded so far, like during the warm-up, is discarded
+func (p *LoadTestProgressRecorder) Start() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.started = true
+	p.start = time.Now()
+	p.last = p.start
+	p.requests = 0
+	p.errors = map[int]int64{}
+	p.latencies.Reset()
+}
+
+// Record records the status code and the latency of a request
+func (p *LoadTestProgressRecorder) Record(code int, latency time.Duration) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.requests++
+	if code < 200 || code > 299 {
+		p.errors[code]++
+	}
+	p.latencies.Record(latency.Seconds())
+}
+
+// Stopped returns a nil channel, the recorder never ends the test early
+func (p *LoadTestProgressRecorder) Stopped() <-chan struct{} {
+	return nil
+}
+
+// Snapshot returns the live metrics of the test and starts the interval of the next snapshot, it returns nil
+// when the test is not started yet
+func (p *LoadTestProgressRecorder) Snapshot() *models.LoadTestSnapshot {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if !p.started {
+		return nil
+	}
+	now := time.Now()
+	if !p.live {
+		return &models.LoadTestSnapshot{Elapsed: now.Sub(p.start).Seconds(), ElapsedOnly: true}
+	}
+	snapshot := &models.LoadTestSnapshot{
+		Elapsed:  now.Sub(p.start).Seconds(),
+		Requests: p.requests,
+	}
+	if interval := now.Sub(p.last); interval > 0 {
+		snapshot.QPS = float64(p.latencies.Count) / interval.Seconds()
+	}
+	if p.latencies.Count > 0 {
+		h := p.latencies.Export().CalcPercentiles(loadTestProgressPercentiles)
+		snapshot.P50 = h.Percentiles[0].Value
+		snapshot.P90 = h.Percentiles[1].Value
+		snapshot.P99 = h.Percentiles[2].Value
+	}
+	if len(p.errors) > 0 {
+		snapshot.ErrorCodes = map[int]int64{}
+		for code, count := range p.errors {
+			snapshot.Errors += count
+			snapshot.ErrorCodes[code] = count
+		}
+	}
+	p.last = now
+	p.latencies.Reset()
+	return snapshot
+}
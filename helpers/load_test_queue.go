@@ -0,0 +1,183 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/layer5io/meshery/models"
+)
+
+// DefaultLoadTestMaxConcurrency is the number of load tests run at once when none is configured, so that the
+// tests do not compete for the CPU and skew each other's results
+const DefaultLoadTestMaxConcurrency = 1
+
+type loadTestQueueEntry struct {
+	entry *models.LoadTestQueueEntry
+	// ready is closed once the test is allowed to run, changed is signaled whenever its position changes
+	ready   chan struct{}
+	changed chan struct{}
+}
+
+// LoadTestQueue is the run queue of the load tests of this Meshery instance, the tests are run in their order
+// of arrival with at most maxConcurrency of them at once
+type LoadTestQueue struct {
+	maxConcurrency int
+	// maxPerUser is the number of tests a user can have running or queued at once, 0 for no limit
+	maxPerUser int
+	// entries holds the running tests followed by the queued ones
+	entries []*loadTestQueueEntry
+	qLock   *sync.Mutex
+}
+
+// NewLoadTestQueue creates a new instance of LoadTestQueue
+func NewLoadTestQueue(maxConcurrency, maxPerUser int) *LoadTestQueue {
+	if maxConcurrency < 1 {
+		maxConcurrency = DefaultLoadTestMaxConcurrency
+	}
+	if maxPerUser < 0 {
+		maxPerUser = 0
+	}
+	return &LoadTestQueue{
+		maxConcurrency: maxConcurrency,
+		maxPerUser:     maxPerUser,
+		qLock:          &sync.Mutex{},
+	}
+}
+
+// Enqueue adds the job at the end of the queue, it fails when the user already has too many tests in it
+func (a *LoadTestQueue) Enqueue(ctx context.Context, job *models.LoadTestJob) error {
+	a.qLock.Lock()
+	defer a.qLock.Unlock()
+	if a.maxPerUser > 0 {
+		count := 0
+		for _, e := range a.entries {
+			if e.entry.UserID == job.UserID {
+				count++
+			}
+		}
+		if count >= a.maxPerUser {
+			return fmt.Errorf("already %d load tests running or queued, the most a user can have at once", count)
+		}
+	}
+	a.add(job)
+	return nil
+}
+
+// EnqueueIfIdle adds the job to the queue only when it is allowed to run right away, it returns false, leaving the
+// queue as is, when the tests in the queue leave no room for it
+func (a *LoadTestQueue) EnqueueIfIdle(ctx context.Context, job *models.LoadTestJob) bool {
+	a.qLock.Lock()
+	defer a.qLock.Unlock()
+	if len(a.entries) >= a.maxConcurrency {
+		return false
+	}
+	a.add(job)
+	return true
+}
+
+// add adds the job at the end of the queue, must be called with the lock held
+func (a *LoadTestQueue) add(job *models.LoadTestJob) {
+	a.entries = append(a.entries, &loadTestQueueEntry{
+		entry: &models.LoadTestQueueEntry{
+			JobID:      job.ID,
+			Name:       job.Name,
+			UserID:     job.UserID,
+			Status:     models.LoadTestJobQueued,
+			EnqueuedAt: time.Now(),
+		},
+		ready:   make(chan struct{}),
+		changed: make(chan struct{}, 1),
+	})
+	a.update()
+}
+
+// update lets the tests at the head of the queue run and signals the queued ones their new position, must be
+// called with the lock held
+func (a *LoadTestQueue) update() {
+	for i, e := range a.entries {
+		if i < a.maxConcurrency {
+			if e.entry.Status == models.LoadTestJobQueued {
+				now := time.Now()
+				e.entry.Status = models.LoadTestJobRunning
+				e.entry.Position = 0
+				e.entry.StartedAt = &now
+				close(e.ready)
+			}
+			continue
+		}
+		if position := i - a.maxConcurrency + 1; e.entry.Position != position {
+			e.entry.Position = position
+			select {
+			case e.changed <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// find returns the entry of the job, must be called with the lock held
+func (a *LoadTestQueue) find(id string) (int, *loadTestQueueEntry) {
+	for i, e := range a.entries {
+		if e.entry.JobID == id {
+			return i, e
+		}
+	}
+	return -1, nil
+}
+
+// Wait blocks until the job is allowed to run or the context is done, positionChanged being called with the
+// position of the job in the queue whenever it changes
+func (a *LoadTestQueue) Wait(ctx context.Context, id string, positionChanged func(position int)) error {
+	a.qLock.Lock()
+	_, e := a.find(id)
+	a.qLock.Unlock()
+	if e == nil {
+		return fmt.Errorf("load test job %s is not queued", id)
+	}
+	for {
+		select {
+		case <-e.ready:
+			return nil
+		default:
+		}
+		select {
+		case <-e.ready:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-e.changed:
+			a.qLock.Lock()
+			position := e.entry.Position
+			a.qLock.Unlock()
+			if position > 0 {
+				positionChanged(position)
+			}
+		}
+	}
+}
+
+// Release removes the job from the queue once it is done, or given up on while queued, letting the next one run
+func (a *LoadTestQueue) Release(ctx context.Context, id string) {
+	a.qLock.Lock()
+	defer a.qLock.Unlock()
+	i, e := a.find(id)
+	if e == nil {
+		return
+	}
+	a.entries = append(a.entries[:i], a.entries[i+1:]...)
+	a.update()
+}
+
+// GetEntries returns copies of the running tests followed by the queued ones in their order
+func (a *LoadTestQueue) GetEntries(ctx context.Context) []*models.LoadTestQueueEntry {
+	a.qLock.Lock()
+	defer a.qLock.Unlock()
+	entries := make([]*models.LoadTestQueueEntry, 0, len(a.entries))
+	for _, e := range a.entries {
+		entry := *e.entry
+		entries = append(entries, &entry)
+	}
+	return entries
+}
@@ -0,0 +1,36 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/models"
+)
+
+// RunLoadGenerator runs the load test with the load generator. The warm-up of the test, if any, is run by the load
+// generator itself as the start of the test over the same connections, its requests being left out of the results,
+// and the recorder of the test is started by the load generator once the warm-up is over
+func RunLoadGenerator(ctx context.Context, lg models.LoadGenerator, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	if opts.Recorder != nil && opts.WarmUp <= 0 {
+		opts.Recorder.Start()
+	}
+	return lg.Run(ctx, opts)
+}
+
+// startRecorderAfterWarmUp starts the recorder of the test, if any, at the end of its warm-up, which started at start,
+// unless the context is done first
+func startRecorderAfterWarmUp(ctx context.Context, opts *models.LoadTestOptions, start time.Time) {
+	if opts.Recorder == nil || opts.WarmUp <= 0 {
+		return
+	}
+	timer := time.NewTimer(time.Until(start.Add(opts.WarmUp)))
+	go func() {
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			opts.Recorder.Start()
+		case <-ctx.Done():
+		}
+	}()
+}
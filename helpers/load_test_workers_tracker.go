@@ -0,0 +1,73 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// LoadTestWorkersTracker is used to hold the list of known load test workers, only the workers configured by the
+// administrator with LOAD_TEST_WORKER_URLS can be used, as the coordinator sends them the worker token
+type LoadTestWorkersTracker struct {
+	// allowed holds the configured workers and workers the ones of them which are currently in use
+	allowed     map[string]struct{}
+	workers     map[string]struct{}
+	workersLock *sync.Mutex
+}
+
+// NewLoadTestWorkersTracker returns an instance of LoadTestWorkersTracker for the configured workers, which are
+// all in use at first
+func NewLoadTestWorkersTracker(workerURLs []string) *LoadTestWorkersTracker {
+	allowed := map[string]struct{}{}
+	initialWorkers := map[string]struct{}{}
+	for _, u := range workerURLs {
+		if u = normalizeWorkerURL(u); u != "" {
+			allowed[u] = struct{}{}
+			initialWorkers[u] = struct{}{}
+		}
+	}
+	return &LoadTestWorkersTracker{
+		allowed:     allowed,
+		workers:     initialWorkers,
+		workersLock: &sync.Mutex{},
+	}
+}
+
+// normalizeWorkerURL returns the worker URL without surrounding spaces and trailing slashes
+func normalizeWorkerURL(workerURL string) string {
+	return strings.TrimRight(strings.TrimSpace(workerURL), "/")
+}
+
+// AddWorker is used to put back in use one of the configured workers, any other worker is rejected
+func (a *LoadTestWorkersTracker) AddWorker(ctx context.Context, workerURL string) error {
+	a.workersLock.Lock()
+	defer a.workersLock.Unlock()
+	workerURL = normalizeWorkerURL(workerURL)
+	if _, ok := a.allowed[workerURL]; !ok {
+		return fmt.Errorf("worker %s is not one of the workers configured with LOAD_TEST_WORKER_URLS", workerURL)
+	}
+	a.workers[workerURL] = struct{}{}
+	return nil
+}
+
+// RemoveWorker is used to stop using a worker
+func (a *LoadTestWorkersTracker) RemoveWorker(ctx context.Context, workerURL string) {
+	a.workersLock.Lock()
+	defer a.workersLock.Unlock()
+	delete(a.workers, normalizeWorkerURL(workerURL))
+}
+
+// GetWorkers returns the sorted list of the workers in use
+func (a *LoadTestWorkersTracker) GetWorkers(ctx context.Context) []string {
+	a.workersLock.Lock()
+	defer a.workersLock.Unlock()
+
+	wk := make([]string, 0, len(a.workers))
+	for x := range a.workers {
+		wk = append(wk, x)
+	}
+	sort.Strings(wk)
+	return wk
+}
@@ -0,0 +1,215 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// meshProxyImages are the images of the proxies of the meshes, the other images of meshesMeta are the control plane ones
+var meshProxyImages = map[string]string{
+	"Istio":   "docker.io/istio/proxyv2",
+	"Linkerd": "gcr.io/linkerd-io/proxy",
+	"Consul":  "envoyproxy/envoy",
+}
+
+// MeshIntrospection - holds the build and the settings of a mesh deployed on kubernetes
+type MeshIntrospection struct {
+	// Mesh is the name of the mesh as detected by ScanKubernetes
+	Mesh string
+	// MeshBuild is the version of the control plane, from the tag of its images
+	MeshBuild string
+	// ProxyBuild is the version of the proxy, from the tag of its image
+	ProxyBuild string
+	Config     *models.MeshConfig
+}
+
+// IntrospectMesh returns the build of the mesh, from the images of the deployments detected by ScanKubernetes, and its
+// settings, read from the configuration of the meshes which are supported, ie. Istio and Linkerd. The mesh is the one
+// whose name matches the given one or else the only one detected
+func IntrospectMesh(kubeconfig []byte, contextName, meshName string, installedMeshes map[string][]v1.Deployment) (*MeshIntrospection, error) {
+	mesh := ""
+	for name := range installedMeshes {
+		if meshName != "" && strings.Contains(strings.ToLower(meshName), strings.ToLower(name)) {
+			mesh = name
+			break
+		}
+	}
+	if mesh == "" && len(installedMeshes) == 1 {
+		for name := range installedMeshes {
+			mesh = name
+		}
+	}
+	if mesh == "" {
+		return nil, fmt.Errorf("unable to find the deployments of the mesh %q", meshName)
+	}
+
+	mi := &MeshIntrospection{
+		Mesh: mesh,
+	}
+	namespace := ""
+	for _, d := range installedMeshes[mesh] {
+		for _, cont := range d.Spec.Template.Spec.Containers {
+			if proxyImage, ok := meshProxyImages[mesh]; ok && strings.HasPrefix(cont.Image, proxyImage) {
+				if mi.ProxyBuild == "" {
+					mi.ProxyBuild = imageTag(cont.Image)
+				}
+				continue
+			}
+			for _, imageName := range meshesMeta[mesh] {
+				if strings.HasPrefix(cont.Image, imageName) && mi.MeshBuild == "" {
+					mi.MeshBuild = imageTag(cont.Image)
+					namespace = d.Namespace
+				}
+			}
+		}
+	}
+
+	var err error
+	switch mesh {
+	case "Istio":
+		if namespace == "" {
+			namespace = "istio-system"
+		}
+		mi.Config, err = istioMeshConfig(kubeconfig, contextName, namespace)
+	case "Linkerd":
+		if namespace == "" {
+			namespace = "linkerd"
+		}
+		mi.Config, err = linkerdMeshConfig(kubeconfig, contextName, namespace, mi)
+	}
+	if err != nil {
+		// the builds are still worth recording
+		logrus.Warn(errors.Wrapf(err, "unable to read the configuration of %s", mesh))
+	}
+	return mi, nil
+}
+
+// imageTag returns the tag of the image, if any
+func imageTag(image string) string {
+	image = strings.SplitN(image, "@", 2)[0]
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return ""
+	}
+	return image[i+1:]
+}
+
+// deploymentRunning tells whether the deployment exists with at least a replica
+func deploymentRunning(clientset *kubernetes.Clientset, namespace, name string) bool {
+	d, err := clientset.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+	return err == nil && (d.Spec.Replicas == nil || *d.Spec.Replicas > 0)
+}
+
+// istioMeshSettings holds the fields of the Istio mesh config relevant to SMP
+type istioMeshSettings struct {
+	EnableAutoMtls      *bool  `yaml:"enableAutoMtls"`
+	DisablePolicyChecks *bool  `yaml:"disablePolicyChecks"`
+	MixerReportServer   string `yaml:"mixerReportServer"`
+	DefaultConfig       struct {
+		Concurrency int `yaml:"concurrency"`
+	} `yaml:"defaultConfig"`
+}
+
+// istioMeshConfig reads the mesh config of the istio config map, mTLS is enabled either by the auto mTLS of the
+// mesh config or by a strict default mesh policy, telemetry either by mixer or by the stats filters of telemetry v2
+func istioMeshConfig(kubeconfig []byte, contextName, namespace string) (*models.MeshConfig, error) {
+	clientset, err := getK8SClientSet(kubeconfig, contextName)
+	if err != nil {
+		return nil, err
+	}
+	cm, err := clientset.CoreV1().ConfigMaps(namespace).Get("istio", metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get the istio config map")
+	}
+	settings := &istioMeshSettings{}
+	if err := yaml.Unmarshal([]byte(cm.Data["mesh"]), settings); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the istio mesh config")
+	}
+
+	config := &models.MeshConfig{
+		ProxyConcurrency: settings.DefaultConfig.Concurrency,
+		MtlsEnabled:      settings.EnableAutoMtls != nil && *settings.EnableAutoMtls,
+		MeshPolicyEnabled: (settings.DisablePolicyChecks == nil || !*settings.DisablePolicyChecks) &&
+			deploymentRunning(clientset, namespace, "istio-policy"),
+		MeshTelemetryEnabled: settings.MixerReportServer != "" && deploymentRunning(clientset, namespace, "istio-telemetry"),
+	}
+
+	istioClient, err := getIstioClient(kubeconfig, contextName)
+	if err != nil {
+		return config, err
+	}
+	if !config.MtlsEnabled {
+		// the mesh policies were replaced by the peer authentications in Istio 1.5
+		if mp, err := istioClient.AuthenticationV1alpha1().MeshPolicies().Get("default", metav1.GetOptions{}); err == nil {
+			for _, peer := range mp.Spec.GetPeers() {
+				if peer.GetMtls() != nil && peer.GetMtls().GetMode().String() == "STRICT" {
+					config.MtlsEnabled = true
+				}
+			}
+		}
+	}
+	if !config.MeshTelemetryEnabled {
+		if filters, err := istioClient.NetworkingV1alpha3().EnvoyFilters(namespace).List(metav1.ListOptions{}); err == nil {
+			for _, f := range filters.Items {
+				if strings.HasPrefix(f.Name, "stats-filter-") {
+					config.MeshTelemetryEnabled = true
+				}
+			}
+		}
+	}
+	return config, nil
+}
+
+// linkerdGlobalConfig and linkerdProxyConfig hold the fields of the linkerd config map relevant to SMP
+type linkerdGlobalConfig struct {
+	Version         string           `json:"version"`
+	IdentityContext *json.RawMessage `json:"identityContext"`
+}
+
+type linkerdProxyConfig struct {
+	ProxyImage *struct {
+		ImageName string `json:"imageName"`
+	} `json:"proxyImage"`
+	ProxyVersion string `json:"proxyVersion"`
+}
+
+// linkerdMeshConfig reads the linkerd config map, mTLS is enabled along with the identity service and the telemetry
+// when linkerd prometheus is deployed, the builds are completed with the versions of the config map
+func linkerdMeshConfig(kubeconfig []byte, contextName, namespace string, mi *MeshIntrospection) (*models.MeshConfig, error) {
+	clientset, err := getK8SClientSet(kubeconfig, contextName)
+	if err != nil {
+		return nil, err
+	}
+	cm, err := clientset.CoreV1().ConfigMaps(namespace).Get("linkerd-config", metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get the linkerd config map")
+	}
+	global := &linkerdGlobalConfig{}
+	if err := json.Unmarshal([]byte(cm.Data["global"]), global); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the linkerd global config")
+	}
+	proxy := &linkerdProxyConfig{}
+	if err := json.Unmarshal([]byte(cm.Data["proxy"]), proxy); err != nil {
+		return nil, errors.Wrap(err, "unable to parse the linkerd proxy config")
+	}
+
+	if global.Version != "" {
+		mi.MeshBuild = global.Version
+	}
+	if proxy.ProxyVersion != "" {
+		mi.ProxyBuild = proxy.ProxyVersion
+	}
+	return &models.MeshConfig{
+		MtlsEnabled:          global.IdentityContext != nil && string(*global.IdentityContext) != "null",
+		MeshTelemetryEnabled: deploymentRunning(clientset, namespace, "linkerd-prometheus"),
+	}, nil
+}
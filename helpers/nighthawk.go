@@ -0,0 +1,349 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fortio.org/fortio/fhttp"
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultNighthawkLocation = "nighthawk_client"
+
+	// statistic holding the end to end request latency in the nighthawk output
+	nighthawkLatencyStatistic = "benchmark_http_client.request_to_response"
+)
+
+var nighthawkDefaultPercentiles = []float64{50, 75, 90, 99, 99.9}
+
+// NighthawkLoadGenerator is the LoadGenerator backed by Envoy's Nighthawk.
+// It drives the nighthawk_client binary, which either generates the load locally
+// or delegates it to a remote nighthawk_service when a service address is set.
+type NighthawkLoadGenerator struct {
+	location string
+	service  string
+}
+
+// NewNighthawkLoadGenerator returns a new NighthawkLoadGenerator for the nighthawk_client binary
+// at the given location and the optional nighthawk_service address (host:port)
+func NewNighthawkLoadGenerator(location, service string) *NighthawkLoadGenerator {
+	if location == "" {
+		location = defaultNighthawkLocation
+	}
+	return &NighthawkLoadGenerator{
+		location: location,
+		service:  service,
+	}
+}
+
+// Name returns the name of the load generator
+func (n *NighthawkLoadGenerator) Name() string {
+	return models.NighthawkLG.Name()
+}
+
+// Capabilities returns the features supported by Nighthawk
+func (n *NighthawkLoadGenerator) Capabilities() models.LoadGeneratorCapabilities {
+	return models.LoadGeneratorCapabilities{
+		GRPC:  false,
+		HTTP2: true,
+		HTTPMethods: []string{
+			http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+			http.MethodDelete, http.MethodOptions, http.MethodTrace,
+		},
+		HTTPHeaders: true,
+		// the latencies of the open loop of nighthawk are measured from the time the requests were sent rather
+		// than from the time they were due, so they are not corrected for the coordinated omission and the open
+		// load model is not offered
+		LoadModels: []models.LoadModel{models.ClosedLoadModel},
+	}
+}
+
+// Run runs the load test using Nighthawk
+func (n *NighthawkLoadGenerator) Run(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	if opts.IsGRPC {
+		err := errors.New("nighthawk does not support gRPC at the moment")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	rURL := strings.TrimLeft(opts.URL, " \t\r\n")
+
+	args := n.buildArgs(opts, rURL)
+	logrus.Debugf("received command: %s %v", n.location, args)
+
+	startTime := time.Now()
+	out, err := exec.CommandContext(ctx, n.location, args...).Output()
+	if ctx.Err() != nil {
+		err = errors.Wrap(ctx.Err(), "load test cancelled")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "unable to execute nighthawk")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	logrus.Debugf("Nighthawk output: %s", out)
+
+	percentiles := nighthawkDefaultPercentiles
+	if len(opts.Percentiles) > 0 {
+		percentiles = opts.Percentiles
+	}
+	res, err := transformNighthawkToFortio(out, startTime, opts, rURL, percentiles)
+	if err != nil {
+		logrus.Error(err)
+		return nil, nil, err
+	}
+
+	bd, err := json.Marshal(res)
+	if err != nil {
+		err = errors.Wrap(err, "error while converting results to map")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	resultsMap := map[string]interface{}{}
+	err = json.Unmarshal(bd, &resultsMap)
+	if err != nil {
+		err = errors.Wrap(err, "error while unmarshaling data to map")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	logrus.Debugf("Mapped version of the test: %+#v", resultsMap)
+	return resultsMap, res.Result(), nil
+}
+
+// nighthawkTargets returns the rate and the duration, in seconds, nighthawk is run at for the test, nighthawk only
+// taking whole numbers of them, the rate of a test at max speed being a high one as nighthawk has no max speed mode
+func nighthawkTargets(opts *models.LoadTestOptions) (int64, int64) {
+	qps := opts.HTTPQPS
+	if qps <= 0 {
+		qps = 100000
+	}
+	duration := int64(math.Ceil(opts.Duration.Seconds()))
+	if duration < 1 {
+		duration = 1
+	}
+	return int64(math.Ceil(qps)), duration
+}
+
+func (n *NighthawkLoadGenerator) buildArgs(opts *models.LoadTestOptions, rURL string) []string {
+	rps, duration := nighthawkTargets(opts)
+	if (opts.HTTPQPS > 0 && float64(rps) != opts.HTTPQPS) || time.Duration(duration)*time.Second != opts.Duration {
+		logrus.Warnf("nighthawk only takes whole rates and durations, running at %d qps for %ds instead", rps, duration)
+	}
+	connections := opts.HTTPNumThreads
+	if connections < 1 {
+		connections = 1
+	}
+	args := []string{
+		"--rps", strconv.FormatInt(rps, 10),
+		"--connections", strconv.Itoa(connections),
+		"--duration", strconv.FormatInt(duration, 10),
+		"--output-format", "json",
+	}
+	if opts.HTTP2 {
+		args = append(args, "--h2")
+	}
+	if opts.HTTPMethod != "" {
+		args = append(args, "--request-method", opts.HTTPMethod)
+	}
+	keys := make([]string, 0, len(opts.HTTPHeaders))
+	for key := range opts.HTTPHeaders {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range opts.HTTPHeaders[key] {
+			args = append(args, "--request-header", key+":"+value)
+		}
+	}
+	if n.service != "" {
+		args = append(args, "--nighthawk-service", n.service)
+	}
+	return append(args, rURL)
+}
+
+type nighthawkOutput struct {
+	Results []*nighthawkResult `json:"results"`
+}
+
+type nighthawkResult struct {
+	Name              string                `json:"name"`
+	Statistics        []*nighthawkStatistic `json:"statistics"`
+	Counters          []*nighthawkCounter   `json:"counters"`
+	ExecutionDuration string                `json:"execution_duration"`
+}
+
+type nighthawkStatistic struct {
+	ID          string                 `json:"id"`
+	Count       json.Number            `json:"count"`
+	Mean        string                 `json:"mean"`
+	Pstdev      string                 `json:"pstdev"`
+	Min         string                 `json:"min"`
+	Max         string                 `json:"max"`
+	Percentiles []*nighthawkPercentile `json:"percentiles"`
+}
+
+type nighthawkPercentile struct {
+	Percentile float64     `json:"percentile"`
+	Count      json.Number `json:"count"`
+	Duration   string      `json:"duration"`
+}
+
+type nighthawkCounter struct {
+	Name  string      `json:"name"`
+	Value json.Number `json:"value"`
+}
+
+// nighthawkSeconds converts a proto JSON duration like "0.000503s" to seconds
+func nighthawkSeconds(d string) float64 {
+	if d == "" {
+		return 0
+	}
+	dur, err := time.ParseDuration(d)
+	if err != nil {
+		logrus.Warnf("unable to parse nighthawk duration %q: %v", d, err)
+		return 0
+	}
+	return dur.Seconds()
+}
+
+// transformNighthawkToFortio normalizes the nighthawk json output into the fortio http results
+func transformNighthawkToFortio(out []byte, startTime time.Time, opts *models.LoadTestOptions, rURL string, percentiles []float64) (*fhttp.HTTPRunnerResults, error) {
+	raw := &nighthawkOutput{}
+	if err := json.Unmarshal(out, raw); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal the nighthawk output")
+	}
+	var global *nighthawkResult
+	for _, r := range raw.Results {
+		if r.Name == "global" {
+			global = r
+			break
+		}
+	}
+	if global == nil {
+		return nil, errors.New("nighthawk output does not contain global results")
+	}
+	var latency *nighthawkStatistic
+	for _, st := range global.Statistics {
+		if st.ID == nighthawkLatencyStatistic {
+			latency = st
+			break
+		}
+	}
+	if latency == nil {
+		return nil, fmt.Errorf("nighthawk output does not contain the %s statistic", nighthawkLatencyStatistic)
+	}
+
+	// the results hold the rate and the duration nighthawk was actually run at
+	rps, seconds := nighthawkTargets(opts)
+	requestedQPS := "max"
+	if opts.HTTPQPS > 0 {
+		requestedQPS = strconv.FormatInt(rps, 10)
+	}
+	requestedDuration := time.Duration(seconds) * time.Second
+	count, _ := latency.Count.Int64()
+	dur := time.Duration(nighthawkSeconds(global.ExecutionDuration) * float64(time.Second))
+	if dur <= 0 {
+		dur = requestedDuration
+	}
+	hist := &stats.HistogramData{
+		Count:  count,
+		Min:    nighthawkSeconds(latency.Min),
+		Max:    nighthawkSeconds(latency.Max),
+		Avg:    nighthawkSeconds(latency.Mean),
+		StdDev: nighthawkSeconds(latency.Pstdev),
+	}
+	hist.Sum = hist.Avg * float64(count)
+
+	sort.Slice(latency.Percentiles, func(i, j int) bool {
+		return latency.Percentiles[i].Percentile < latency.Percentiles[j].Percentile
+	})
+	var prevCount int64
+	prevEnd := hist.Min
+	for _, p := range latency.Percentiles {
+		c, _ := p.Count.Int64()
+		end := nighthawkSeconds(p.Duration)
+		if c > prevCount {
+			hist.Data = append(hist.Data, stats.Bucket{
+				Interval: stats.Interval{
+					Start: prevEnd,
+					End:   end,
+				},
+				Percent: p.Percentile * 100,
+				Count:   c - prevCount,
+			})
+			prevCount = c
+			prevEnd = end
+		}
+	}
+	for _, p := range percentiles {
+		hist.Percentiles = append(hist.Percentiles, stats.Percentile{
+			Percentile: p,
+			Value:      interpolateNighthawkPercentile(latency.Percentiles, p/100),
+		})
+	}
+
+	retCodes := map[int]int64{}
+	for _, c := range global.Counters {
+		// nighthawk only reports status classes, eg. benchmark.http_2xx, which are recorded as 200, 300, etc.
+		if strings.HasPrefix(c.Name, "benchmark.http_") && strings.HasSuffix(c.Name, "xx") {
+			class, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(c.Name, "benchmark.http_"), "xx"))
+			if err != nil {
+				continue
+			}
+			v, _ := c.Value.Int64()
+			retCodes[class*100] += v
+		}
+	}
+
+	return &fhttp.HTTPRunnerResults{
+		URL:      rURL,
+		RetCodes: retCodes,
+		RunnerResults: periodic.RunnerResults{
+			RunType:           "HTTP",
+			Labels:            opts.Name + " -_- " + rURL,
+			StartTime:         startTime,
+			RequestedQPS:      requestedQPS,
+			RequestedDuration: requestedDuration.String(),
+			ActualQPS:         float64(count) / dur.Seconds(),
+			ActualDuration:    dur,
+			NumThreads:        opts.HTTPNumThreads,
+			Version:           "nighthawk",
+			DurationHistogram: hist,
+		},
+	}, nil
+}
+
+// interpolateNighthawkPercentile linearly interpolates the latency (in seconds) at the
+// given fraction from the sorted percentiles reported by nighthawk
+func interpolateNighthawkPercentile(ps []*nighthawkPercentile, fraction float64) float64 {
+	if len(ps) == 0 {
+		return 0
+	}
+	prev := ps[0]
+	for _, p := range ps {
+		if p.Percentile >= fraction {
+			if p.Percentile == prev.Percentile {
+				return nighthawkSeconds(p.Duration)
+			}
+			ratio := (fraction - prev.Percentile) / (p.Percentile - prev.Percentile)
+			start := nighthawkSeconds(prev.Duration)
+			return start + ratio*(nighthawkSeconds(p.Duration)-start)
+		}
+		prev = p
+	}
+	return nighthawkSeconds(prev.Duration)
+}
@@ -0,0 +1,199 @@
+package helpers
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/layer5io/meshery/models"
+)
+
+// fakeNighthawkOutput is the global result of a nighthawk_client run as printed with --output-format json
+const fakeNighthawkOutput = `{
+  "results": [
+    {
+      "name": "worker_0",
+      "statistics": [],
+      "counters": []
+    },
+    {
+      "name": "global",
+      "statistics": [
+        {
+          "id": "benchmark_http_client.request_to_response",
+          "count": "100",
+          "mean": "0.002s",
+          "pstdev": "0.0005s",
+          "min": "0.001s",
+          "max": "0.010s",
+          "percentiles": [
+            {"percentile": 0.5, "count": "50", "duration": "0.002s"},
+            {"percentile": 0, "count": "1", "duration": "0.001s"},
+            {"percentile": 0.9, "count": "90", "duration": "0.004s"},
+            {"percentile": 1, "count": "100", "duration": "0.010s"}
+          ]
+        }
+      ],
+      "counters": [
+        {"name": "benchmark.http_2xx", "value": "95"},
+        {"name": "benchmark.http_5xx", "value": "5"},
+        {"name": "upstream_cx_total", "value": "2"}
+      ],
+      "execution_duration": "2s"
+    }
+  ]
+}`
+
+// writeFakeNighthawk writes in the directory a nighthawk_client stand-in which records its arguments, one per line,
+// and prints the given output
+func writeFakeNighthawk(t *testing.T, dir, output string) (binary, argsFile string) {
+	binary = filepath.Join(dir, "nighthawk_client")
+	argsFile = filepath.Join(dir, "args")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > " + argsFile + "\ncat <<'EOF'\n" + output + "\nEOF\n"
+	if err := ioutil.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return binary, argsFile
+}
+
+// tempNighthawkDir creates the directory of a fake nighthawk_client, removed by the caller
+func tempNighthawkDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "nighthawk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestNighthawkLoadGeneratorRun(t *testing.T) {
+	dir := tempNighthawkDir(t)
+	defer os.RemoveAll(dir)
+	binary, argsFile := writeFakeNighthawk(t, dir, fakeNighthawkOutput)
+	lg := NewNighthawkLoadGenerator(binary, "nighthawk:8443")
+
+	opts := &models.LoadTestOptions{
+		Name:           "test",
+		URL:            " http://localhost:9080/",
+		HTTPQPS:        50.5,
+		HTTPNumThreads: 2,
+		Duration:       1500 * time.Millisecond,
+		HTTP2:          true,
+		HTTPMethod:     http.MethodPost,
+		HTTPHeaders: http.Header{
+			"X-B": []string{"2"},
+			"X-A": []string{"1", "3"},
+		},
+		Percentiles: []float64{50, 75},
+	}
+	resultsMap, result, err := lg.Run(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ioutil.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Split(strings.TrimSpace(string(out)), "\n")
+	expected := []string{
+		"--rps", "51",
+		"--connections", "2",
+		"--duration", "2",
+		"--output-format", "json",
+		"--h2",
+		"--request-method", "POST",
+		"--request-header", "X-A:1",
+		"--request-header", "X-A:3",
+		"--request-header", "X-B:2",
+		"--nighthawk-service", "nighthawk:8443",
+		"http://localhost:9080/",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("unexpected arguments:\n got: %q\nwant: %q", args, expected)
+	}
+
+	h := result.DurationHistogram
+	if h.Count != 100 || h.Min != 0.001 || h.Max != 0.01 || h.Avg != 0.002 {
+		t.Errorf("unexpected histogram: %+v", h)
+	}
+	// nighthawk only takes whole rates and durations, the results hold the ones it was run at
+	if result.RequestedQPS != "51" || result.RequestedDuration != "2s" {
+		t.Errorf("unexpected targets: %s qps over %s", result.RequestedQPS, result.RequestedDuration)
+	}
+	if result.ActualQPS != 50 || result.ActualDuration != 2*time.Second {
+		t.Errorf("unexpected rate: %g qps over %v", result.ActualQPS, result.ActualDuration)
+	}
+	if len(h.Percentiles) != 2 || h.Percentiles[0].Value != 0.002 || math.Abs(h.Percentiles[1].Value-0.00325) > 1e-9 {
+		t.Errorf("unexpected percentiles: %+v", h.Percentiles)
+	}
+	var total int64
+	for _, b := range h.Data {
+		total += b.Count
+	}
+	if total != 100 {
+		t.Errorf("the buckets hold %d samples instead of 100", total)
+	}
+	retCodes, _ := resultsMap["RetCodes"].(map[string]interface{})
+	if retCodes["200"] != float64(95) || retCodes["500"] != float64(5) || len(retCodes) != 2 {
+		t.Errorf("unexpected status codes: %v", resultsMap["RetCodes"])
+	}
+}
+
+func TestNighthawkLoadGeneratorRunDefaults(t *testing.T) {
+	dir := tempNighthawkDir(t)
+	defer os.RemoveAll(dir)
+	binary, argsFile := writeFakeNighthawk(t, dir, fakeNighthawkOutput)
+	lg := NewNighthawkLoadGenerator(binary, "")
+
+	_, result, err := lg.Run(context.Background(), &models.LoadTestOptions{
+		URL:      "http://localhost:9080/",
+		Duration: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Split(strings.TrimSpace(string(out)), "\n")
+	expected := []string{
+		"--rps", "100000",
+		"--connections", "1",
+		"--duration", "1",
+		"--output-format", "json",
+		"http://localhost:9080/",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("unexpected arguments:\n got: %q\nwant: %q", args, expected)
+	}
+	if result.RequestedQPS != "max" || result.RequestedDuration != "1s" {
+		t.Errorf("unexpected targets: %s qps over %s", result.RequestedQPS, result.RequestedDuration)
+	}
+	if len(result.DurationHistogram.Percentiles) != len(nighthawkDefaultPercentiles) {
+		t.Errorf("unexpected percentiles: %+v", result.DurationHistogram.Percentiles)
+	}
+}
+
+func TestNighthawkLoadGeneratorRunInvalidOutput(t *testing.T) {
+	dir := tempNighthawkDir(t)
+	defer os.RemoveAll(dir)
+	for name, output := range map[string]string{
+		"not json":          "nighthawk failed",
+		"no global results": `{"results": [{"name": "worker_0"}]}`,
+		"no latency":        `{"results": [{"name": "global", "statistics": [{"id": "other"}]}]}`,
+	} {
+		binary, _ := writeFakeNighthawk(t, dir, output)
+		lg := NewNighthawkLoadGenerator(binary, "")
+		if _, _, err := lg.Run(context.Background(), &models.LoadTestOptions{URL: "http://localhost:9080/", Duration: time.Second}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,412 @@
+package helpers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultComparisonThreshold is the relative change in percent above which a significant change is a regression
+	DefaultComparisonThreshold = 10.0
+
+	// DefaultComparisonAlpha is the significance level of the statistical tests
+	DefaultComparisonAlpha = 0.05
+)
+
+// percentiles compared when the baseline does not carry any
+var comparisonDefaultPercentiles = []float64{50, 90, 99}
+
+type comparedResult struct {
+	summary *models.ResultSummary
+	hist    *stats.HistogramData
+	grpc    bool
+	failed  int64
+	total   int64
+}
+
+// CompareResults compares each of the candidates with the baseline and flags the regressions: a latency
+// or throughput change beyond the threshold which is statistically significant, or a significant increase
+// of the error rate
+func CompareResults(baseline *models.MesheryResult, candidates []*models.MesheryResult, opts models.ResultComparisonOptions) (*models.ResultComparison, error) {
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultComparisonThreshold
+	}
+	if opts.Alpha <= 0 || opts.Alpha >= 1 {
+		opts.Alpha = DefaultComparisonAlpha
+	}
+	base, err := newComparedResult(baseline)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the baseline result %s", baseline.ID)
+	}
+	comparison := &models.ResultComparison{
+		Options:  opts,
+		Baseline: base.summary,
+	}
+	percentiles := []float64{}
+	for _, p := range base.hist.Percentiles {
+		percentiles = append(percentiles, p.Percentile)
+	}
+	if len(percentiles) == 0 {
+		percentiles = comparisonDefaultPercentiles
+	}
+	for _, c := range candidates {
+		cand, err := newComparedResult(c)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read the result %s", c.ID)
+		}
+		if cand.grpc != base.grpc {
+			return nil, fmt.Errorf("unable to compare the %s result %s with the %s baseline", protocolName(cand.grpc), c.ID, protocolName(base.grpc))
+		}
+		diff := compareResult(base, cand, percentiles, opts)
+		comparison.Regression = comparison.Regression || diff.Regression
+		comparison.Candidates = append(comparison.Candidates, diff)
+	}
+	return comparison, nil
+}
+
+// SummarizeResult returns the figures of the result used for comparisons
+func SummarizeResult(m *models.MesheryResult) (*models.ResultSummary, error) {
+	c, err := newComparedResult(m)
+	if err != nil {
+		return nil, err
+	}
+	return c.summary, nil
+}
+
+func protocolName(grpc bool) string {
+	if grpc {
+		return "gRPC"
+	}
+	return "HTTP"
+}
+
+func newComparedResult(m *models.MesheryResult) (*comparedResult, error) {
+	runType, _ := m.Result["RunType"].(string)
+	if runType == "" {
+		return nil, errors.New("the result does not hold any runner results")
+	}
+	rr := &periodic.RunnerResults{}
+	if err := remarshal(m.Result, rr); err != nil {
+		return nil, err
+	}
+	if rr.DurationHistogram == nil || rr.DurationHistogram.Count == 0 {
+		return nil, errors.New("the result does not hold any latencies")
+	}
+	retCodes := map[string]int64{}
+	if m.Result["RetCodes"] != nil {
+		if err := remarshal(m.Result["RetCodes"], &retCodes); err != nil {
+			return nil, err
+		}
+	}
+	c := &comparedResult{
+		hist: rr.DurationHistogram,
+		grpc: strings.HasPrefix(runType, "GRPC"),
+	}
+	for _, v := range retCodes {
+		c.total += v
+	}
+	c.failed = failedRequests(retCodes, c.grpc)
+
+	h := rr.DurationHistogram
+	c.summary = &models.ResultSummary{
+		ID:            m.ID.String(),
+		Name:          m.Name,
+		Mesh:          m.Mesh,
+		StartTime:     rr.StartTime,
+		Duration:      rr.ActualDuration.String(),
+		Count:         h.Count,
+		ActualQPS:     rr.ActualQPS,
+		RetCodes:      retCodes,
+		AvgMs:         h.Avg * 1000,
+		StdDevMs:      h.StdDev * 1000,
+		MinMs:         h.Min * 1000,
+		MaxMs:         h.Max * 1000,
+		PercentilesMs: map[string]float64{},
+		Histogram:     h.Data,
+	}
+	if c.total > 0 {
+		c.summary.ErrorRate = 100 * float64(c.failed) / float64(c.total)
+	}
+	for _, p := range h.Percentiles {
+		c.summary.PercentilesMs[percentileName(p.Percentile)] = p.Value * 1000
+	}
+	return c, nil
+}
+
+func percentileName(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'g', -1, 64)
+}
+
+// percentileValue returns the reported percentile, or estimates it from the buckets when it was not computed
+func percentileValue(h *stats.HistogramData, p float64) float64 {
+	for _, hp := range h.Percentiles {
+		if hp.Percentile == p {
+			return hp.Value
+		}
+	}
+	return bucketsPercentile(h, p)
+}
+
+func newMetricDiff(metric string, baseline, candidate float64, higherIsWorse bool) *models.MetricDiff {
+	d := &models.MetricDiff{
+		Metric:    metric,
+		Baseline:  baseline,
+		Candidate: candidate,
+		Delta:     candidate - baseline,
+	}
+	if baseline != 0 {
+		d.DeltaPercent = 100 * d.Delta / baseline
+	}
+	if higherIsWorse {
+		d.Worse = d.Delta > 0
+	} else {
+		d.Worse = d.Delta < 0
+	}
+	return d
+}
+
+func compareResult(base, cand *comparedResult, percentiles []float64, opts models.ResultComparisonOptions) *models.ResultDiff {
+	diff := &models.ResultDiff{
+		Candidate: cand.summary,
+	}
+
+	diff.LatencyMean = welchTest(base.hist, cand.hist, opts.Alpha)
+	diff.HistogramShape = ksTest(base.hist, cand.hist, opts.Alpha)
+	diff.ErrorRateTest = proportionsTest(base.failed, base.total, cand.failed, cand.total, opts.Alpha)
+	latencySignificant := diff.LatencyMean.Significant || diff.HistogramShape.Significant
+
+	for _, p := range percentiles {
+		diff.Latencies = append(diff.Latencies, newMetricDiff(percentileName(p),
+			percentileValue(base.hist, p)*1000, percentileValue(cand.hist, p)*1000, true))
+	}
+	diff.Latencies = append(diff.Latencies, newMetricDiff("avg", base.hist.Avg*1000, cand.hist.Avg*1000, true))
+	for _, l := range diff.Latencies {
+		if l.Worse && l.DeltaPercent > opts.Threshold && latencySignificant {
+			diff.Reasons = append(diff.Reasons, fmt.Sprintf("%s latency increased by %.1f%% (%.3fms -> %.3fms)",
+				l.Metric, l.DeltaPercent, l.Baseline, l.Candidate))
+		}
+	}
+
+	diff.Throughput = newMetricDiff("qps", base.summary.ActualQPS, cand.summary.ActualQPS, false)
+	if diff.Throughput.Worse && -diff.Throughput.DeltaPercent > opts.Threshold {
+		diff.Reasons = append(diff.Reasons, fmt.Sprintf("throughput decreased by %.1f%% (%.1f qps -> %.1f qps)",
+			-diff.Throughput.DeltaPercent, diff.Throughput.Baseline, diff.Throughput.Candidate))
+	}
+
+	diff.ErrorRate = newMetricDiff("error_rate", base.summary.ErrorRate, cand.summary.ErrorRate, true)
+	if diff.ErrorRate.Worse && diff.ErrorRateTest.Significant {
+		diff.Reasons = append(diff.Reasons, fmt.Sprintf("error rate increased from %.3f%% to %.3f%%",
+			diff.ErrorRate.Baseline, diff.ErrorRate.Candidate))
+	}
+
+	codes := []string{}
+	for code := range base.summary.RetCodes {
+		codes = append(codes, code)
+	}
+	for code := range cand.summary.RetCodes {
+		if _, ok := base.summary.RetCodes[code]; !ok {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		// the share of requests is compared, as the tests do not necessarily send the same number of requests
+		var b, c float64
+		if base.total > 0 {
+			b = 100 * float64(base.summary.RetCodes[code]) / float64(base.total)
+		}
+		if cand.total > 0 {
+			c = 100 * float64(cand.summary.RetCodes[code]) / float64(cand.total)
+		}
+		d := newMetricDiff(code, b, c, failedRequests(map[string]int64{code: 1}, base.grpc) > 0)
+		diff.RetCodes = append(diff.RetCodes, d)
+	}
+
+	diff.Regression = len(diff.Reasons) > 0
+	return diff
+}
+
+// welchTest runs Welch's t-test on the mean latencies, the p-value being given by the t distribution with the
+// degrees of freedom of the Welch-Satterthwaite equation, the test does not apply to fewer than 2 latencies
+func welchTest(a, b *stats.HistogramData, alpha float64) *models.SignificanceTest {
+	t := &models.SignificanceTest{
+		Test:   "welch_t",
+		PValue: 1,
+	}
+	if a.Count < 2 || b.Count < 2 {
+		return t
+	}
+	// the standard deviation of the histograms is the one of the population, which is turned into the variance
+	// of the sample
+	va := a.StdDev * a.StdDev / float64(a.Count-1)
+	vb := b.StdDev * b.StdDev / float64(b.Count-1)
+	se := math.Sqrt(va + vb)
+	delta := b.Avg - a.Avg
+	switch {
+	case se > 0:
+		t.Statistic = delta / se
+		t.DegreesOfFreedom = (va + vb) * (va + vb) / (va*va/float64(a.Count-1) + vb*vb/float64(b.Count-1))
+		t.PValue = studentTTwoTailed(t.Statistic, t.DegreesOfFreedom)
+	case delta != 0:
+		t.Statistic = math.Copysign(math.Inf(1), delta)
+		t.PValue = 0
+	}
+	t.Significant = t.PValue < alpha
+	return t
+}
+
+// studentTTwoTailed returns the probability of a value of the t distribution with df degrees of freedom being
+// further from 0 than t
+func studentTTwoTailed(t, df float64) float64 {
+	return regularizedIncompleteBeta(df/(df+t*t), df/2, 0.5)
+}
+
+// regularizedIncompleteBeta returns the regularized incomplete beta function I_x(a, b), evaluated with its
+// continued fraction on the side of x where it converges quickly
+func regularizedIncompleteBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lab, _ := math.Lgamma(a + b)
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log1p(-x))
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(x, a, b) / a
+	}
+	return 1 - front*betaContinuedFraction(1-x, b, a)/b
+}
+
+// betaContinuedFraction evaluates the continued fraction of the incomplete beta function with the modified Lentz's
+// method
+func betaContinuedFraction(x, a, b float64) float64 {
+	const (
+		epsilon       = 1e-15
+		tiny          = 1e-300
+		maxIterations = 10000
+	)
+	clamp := func(v float64) float64 {
+		if math.Abs(v) < tiny {
+			return tiny
+		}
+		return v
+	}
+	c := 1.0
+	d := 1 / clamp(1-(a+b)*x/(a+1))
+	h := d
+	for m := 1.0; m <= maxIterations; m++ {
+		// the even step of the fraction
+		aa := m * (b - m) * x / ((a + 2*m - 1) * (a + 2*m))
+		d = 1 / clamp(1+aa*d)
+		c = clamp(1 + aa/c)
+		h *= d * c
+		// the odd step of the fraction
+		aa = -(a + m) * (a + b + m) * x / ((a + 2*m) * (a + 2*m + 1))
+		d = 1 / clamp(1+aa*d)
+		c = clamp(1 + aa/c)
+		step := d * c
+		h *= step
+		if math.Abs(step-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
+
+// ksTest runs the two sample Kolmogorov-Smirnov test on the latency histograms, the empirical
+// distributions being reconstructed from the buckets
+func ksTest(a, b *stats.HistogramData, alpha float64) *models.SignificanceTest {
+	t := &models.SignificanceTest{
+		Test:   "kolmogorov_smirnov",
+		PValue: 1,
+	}
+	if a.Count == 0 || b.Count == 0 {
+		return t
+	}
+	points := []float64{}
+	for _, h := range []*stats.HistogramData{a, b} {
+		for _, bk := range h.Data {
+			points = append(points, bk.Start, bk.End)
+		}
+	}
+	for _, x := range points {
+		if d := math.Abs(histogramCDF(a, x) - histogramCDF(b, x)); d > t.Statistic {
+			t.Statistic = d
+		}
+	}
+	if t.Statistic > 0 {
+		ne := float64(a.Count) * float64(b.Count) / float64(a.Count+b.Count)
+		lambda := (math.Sqrt(ne) + 0.12 + 0.11/math.Sqrt(ne)) * t.Statistic
+		t.PValue = ksProbability(lambda)
+	}
+	t.Significant = t.PValue < alpha
+	return t
+}
+
+// histogramCDF returns the fraction of the values lower than x, assuming the values are evenly spread within each bucket
+func histogramCDF(h *stats.HistogramData, x float64) float64 {
+	var total, below float64
+	for _, bk := range h.Data {
+		c := float64(bk.Count)
+		total += c
+		switch {
+		case x >= bk.End:
+			below += c
+		case x > bk.Start:
+			below += c * (x - bk.Start) / (bk.End - bk.Start)
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return below / total
+}
+
+// ksProbability is the asymptotic Kolmogorov distribution complement Q(lambda)
+func ksProbability(lambda float64) float64 {
+	if lambda < 0.2 {
+		return 1
+	}
+	var sum float64
+	sign := 1.0
+	for k := 1; k <= 100; k++ {
+		term := sign * 2 * math.Exp(-2*float64(k*k)*lambda*lambda)
+		sum += term
+		if math.Abs(term) < 1e-10 {
+			break
+		}
+		sign = -sign
+	}
+	return math.Min(math.Max(sum, 0), 1)
+}
+
+// proportionsTest runs the two proportions z-test on the error rates
+func proportionsTest(failedA, totalA, failedB, totalB int64, alpha float64) *models.SignificanceTest {
+	t := &models.SignificanceTest{
+		Test:   "two_proportions_z",
+		PValue: 1,
+	}
+	if totalA == 0 || totalB == 0 {
+		return t
+	}
+	pa, pb := float64(failedA)/float64(totalA), float64(failedB)/float64(totalB)
+	p := float64(failedA+failedB) / float64(totalA+totalB)
+	se := math.Sqrt(p * (1 - p) * (1/float64(totalA) + 1/float64(totalB)))
+	if se > 0 {
+		t.Statistic = (pb - pa) / se
+		t.PValue = math.Erfc(math.Abs(t.Statistic) / math.Sqrt2)
+	}
+	t.Significant = t.PValue < alpha
+	return t
+}
@@ -0,0 +1,31 @@
+package helpers
+
+import (
+	"math"
+	"testing"
+
+	"fortio.org/fortio/stats"
+)
+
+func TestWelchTest(t *testing.T) {
+	// too few latencies for the test to apply
+	for _, h := range []*stats.HistogramData{{}, {Count: 1, Avg: 0.5}} {
+		if res := welchTest(&stats.HistogramData{Count: 100, Avg: 0.01, StdDev: 0.001}, h, 0.05); res.Significant || res.PValue != 1 {
+			t.Errorf("the test applied to %d latencies: %+v", h.Count, res)
+		}
+	}
+
+	// 6 latencies with a mean of 10ms and a sample standard deviation of 1ms against 11 with a mean of 12ms and a
+	// sample standard deviation of 2ms, ie. t = 2.7464, df = 14.976, p = 0.01501
+	a := &stats.HistogramData{Count: 6, Avg: 0.010, StdDev: 0.001 * math.Sqrt(5.0/6)}
+	b := &stats.HistogramData{Count: 11, Avg: 0.012, StdDev: 0.002 * math.Sqrt(10.0/11)}
+	res := welchTest(a, b, 0.05)
+	if math.Abs(res.Statistic-2.7464) > 1e-4 || math.Abs(res.DegreesOfFreedom-14.976) > 1e-3 || math.Abs(res.PValue-0.01501) > 1e-5 || !res.Significant {
+		t.Errorf("unexpected outcome: %+v", res)
+	}
+	// with 2 latencies on each side, t = 2.8284 gives p = 0.1056 for 2 degrees of freedom, not the 0.0047 of the
+	// normal distribution
+	if res = welchTest(&stats.HistogramData{Count: 2, Avg: 0.010, StdDev: 0.0005}, &stats.HistogramData{Count: 2, Avg: 0.012, StdDev: 0.0005}, 0.05); res.Significant || math.Abs(res.PValue-0.1056) > 1e-4 {
+		t.Errorf("unexpected outcome with 2 latencies on each side: %+v", res)
+	}
+}
@@ -0,0 +1,206 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// ErrResultExportUnsupported is returned when a result cannot be exported to the requested format,
+// eg. a result without SLO assertions to JUnit
+var ErrResultExportUnsupported = errors.New("the result cannot be exported to this format")
+
+type resultExportFormat struct {
+	contentType string
+	extension   string
+	export      func(*models.MesheryResult) ([]byte, error)
+}
+
+var resultExportFormats = map[string]resultExportFormat{
+	"yaml":  {"application/yaml", "yaml", exportResultSpec},
+	"json":  {"application/json", "json", exportResultJSON},
+	"csv":   {"text/csv", "csv", exportResultCSV},
+	"hdr":   {"text/plain", "hlog", exportResultHdrHistogram},
+	"junit": {"application/xml", "xml", exportResultJUnit},
+}
+
+// ResultExportFormats lists the formats results can be exported to
+var ResultExportFormats = []string{"yaml", "json", "csv", "hdr", "junit"}
+
+// ExportResult converts the result to the given format, one of ResultExportFormats: the SMP benchmark spec in yaml,
+// the result in json, its latency percentiles and histogram buckets in csv, its histogram as an HdrHistogram
+// log or its SLO assertions as a JUnit report. It returns the exported result along with its content type
+// and file extension
+func ExportResult(m *models.MesheryResult, format string) ([]byte, string, string, error) {
+	f, ok := resultExportFormats[format]
+	if !ok {
+		return nil, "", "", fmt.Errorf("unknown export format: %s, expecting one of %s", format, strings.Join(ResultExportFormats, ", "))
+	}
+	data, err := f.export(m)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return data, f.contentType, f.extension, nil
+}
+
+func exportResultSpec(m *models.MesheryResult) ([]byte, error) {
+	sp, err := m.ConvertToSpec()
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(sp)
+}
+
+func exportResultJSON(m *models.MesheryResult) ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+func exportRunnerResults(m *models.MesheryResult) (*periodic.RunnerResults, error) {
+	rr := &periodic.RunnerResults{}
+	if err := remarshal(m.Result, rr); err != nil {
+		return nil, err
+	}
+	if rr.DurationHistogram == nil || rr.DurationHistogram.Count == 0 {
+		return nil, errors.Wrap(ErrResultExportUnsupported, "the result does not hold any latencies")
+	}
+	return rr, nil
+}
+
+// exportResultCSV writes a row per latency percentile and per histogram bucket, the latencies are in milliseconds
+func exportResultCSV(m *models.MesheryResult) ([]byte, error) {
+	rr, err := exportRunnerResults(m)
+	if err != nil {
+		return nil, err
+	}
+	h := rr.DurationHistogram
+	ms := func(v float64) string {
+		return strconv.FormatFloat(v*1000, 'f', -1, 64)
+	}
+	id := m.ID.String()
+	rows := [][]string{{"result_id", "kind", "percentile", "value_ms", "start_ms", "end_ms", "count", "cumulative_percent"}}
+	for _, p := range h.Percentiles {
+		rows = append(rows, []string{id, "percentile", strconv.FormatFloat(p.Percentile, 'f', -1, 64), ms(p.Value), "", "", "", ""})
+	}
+	for _, b := range h.Data {
+		rows = append(rows, []string{id, "bucket", "", "", ms(b.Start), ms(b.End),
+			strconv.FormatInt(b.Count, 10), strconv.FormatFloat(b.Percent, 'f', -1, 64)})
+	}
+	out := &bytes.Buffer{}
+	w := csv.NewWriter(out)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func exportResultHdrHistogram(m *models.MesheryResult) ([]byte, error) {
+	rr, err := exportRunnerResults(m)
+	if err != nil {
+		return nil, err
+	}
+	return hdrHistogramLog(rr.DurationHistogram, rr.StartTime, rr.ActualDuration)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	ID         string          `xml:"id,attr,omitempty"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Time       string          `xml:"time,attr,omitempty"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	TestCases  []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+}
+
+// exportResultJUnit reports each SLO assertion of the result as a test case
+func exportResultJUnit(m *models.MesheryResult) ([]byte, error) {
+	if m.Verdict == nil || len(m.Verdict.Results) == 0 {
+		return nil, errors.Wrap(ErrResultExportUnsupported, "the result does not hold any SLO assertions")
+	}
+	name := m.Name
+	if name == "" {
+		name = m.ID.String()
+	}
+	suite := junitTestSuite{
+		Name: name,
+		ID:   m.ID.String(),
+		Properties: []junitProperty{
+			{Name: "meshery_id", Value: m.ID.String()},
+			{Name: "mesh", Value: m.Mesh},
+		},
+	}
+	rr := &periodic.RunnerResults{}
+	if err := remarshal(m.Result, rr); err == nil && !rr.StartTime.IsZero() {
+		suite.Timestamp = rr.StartTime.UTC().Format("2006-01-02T15:04:05")
+		suite.Time = strconv.FormatFloat(rr.ActualDuration.Seconds(), 'f', 3, 64)
+		if rr.Labels != "" {
+			suite.Properties = append(suite.Properties, junitProperty{Name: "labels", Value: rr.Labels})
+		}
+	}
+	for _, r := range m.Verdict.Results {
+		tc := junitTestCase{
+			ClassName: "meshery.slo",
+			Name:      r.Expr,
+		}
+		switch {
+		case r.Error != "":
+			tc.Error = &junitMessage{Message: r.Error, Type: string(r.Metric)}
+			suite.Errors++
+		case !r.Passed:
+			tc.Failure = &junitMessage{
+				Message: fmt.Sprintf("%s: %g, expected %s %g", r.Metric, r.Value, r.Operator, r.Threshold),
+				Type:    string(r.Metric),
+			}
+			suite.Failures++
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+		suite.Tests++
+	}
+	data, err := xml.MarshalIndent(&junitTestSuites{
+		Name:     "meshery",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Suites:   []junitTestSuite{suite},
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
@@ -0,0 +1,133 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"fortio.org/fortio/fgrpc"
+	"fortio.org/fortio/fhttp"
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+)
+
+// mergeResultsMaps merges the results of several runs of the same test, run side by side, into one
+func mergeResultsMaps(opts *models.LoadTestOptions, resultsMaps []map[string]interface{}) (map[string]interface{}, *periodic.RunnerResults, error) {
+	var (
+		merged interface{}
+		result *periodic.RunnerResults
+	)
+	runnerResults := []*periodic.RunnerResults{}
+	if opts.IsGRPC {
+		gres := &fgrpc.GRPCRunnerResults{RetCodes: fgrpc.HealthResultMap{}}
+		for _, rm := range resultsMaps {
+			rres := &fgrpc.GRPCRunnerResults{}
+			if err := remarshal(rm, rres); err != nil {
+				return nil, nil, err
+			}
+			gres.Destination = rres.Destination
+			gres.Ping = rres.Ping
+			gres.Streams += rres.Streams
+			for k, v := range rres.RetCodes {
+				gres.RetCodes[k] += v
+			}
+			runnerResults = append(runnerResults, rres.Result())
+		}
+		gres.RunnerResults = mergeRunnerResults(opts, runnerResults)
+		merged, result = gres, gres.Result()
+	} else {
+		hres := &fhttp.HTTPRunnerResults{RetCodes: map[int]int64{}}
+		sizes, headerSizes := []*stats.HistogramData{}, []*stats.HistogramData{}
+		for _, rm := range resultsMaps {
+			rres := &fhttp.HTTPRunnerResults{}
+			if err := remarshal(rm, rres); err != nil {
+				return nil, nil, err
+			}
+			hres.URL = rres.URL
+			hres.SocketCount += rres.SocketCount
+			for k, v := range rres.RetCodes {
+				hres.RetCodes[k] += v
+			}
+			sizes = append(sizes, rres.Sizes)
+			headerSizes = append(headerSizes, rres.HeaderSizes)
+			runnerResults = append(runnerResults, rres.Result())
+		}
+		hres.RunnerResults = mergeRunnerResults(opts, runnerResults)
+		hres.Sizes = MergeHistogramData(nil, sizes...)
+		hres.HeaderSizes = MergeHistogramData(nil, headerSizes...)
+		merged, result = hres, hres.Result()
+	}
+
+	resultsMap := map[string]interface{}{}
+	if err := remarshal(merged, &resultsMap); err != nil {
+		return nil, nil, err
+	}
+	if len(opts.Scenario) > 0 {
+		var percentiles []float64
+		if result.DurationHistogram != nil {
+			for _, p := range result.DurationHistogram.Percentiles {
+				percentiles = append(percentiles, p.Percentile)
+			}
+		}
+		scenario, err := mergeScenarioResults(resultsMaps, percentiles)
+		if err != nil {
+			return nil, nil, err
+		}
+		if scenario != nil {
+			resultsMap[models.ScenarioResultKey] = scenario
+		}
+	}
+	return resultsMap, result, nil
+}
+
+func mergeRunnerResults(opts *models.LoadTestOptions, rs []*periodic.RunnerResults) periodic.RunnerResults {
+	merged := periodic.RunnerResults{}
+	hists := []*stats.HistogramData{}
+	var percentiles []float64
+	var end time.Time
+	for i, r := range rs {
+		if i == 0 {
+			merged.RunType = r.RunType
+			merged.Labels = r.Labels
+			merged.Version = r.Version
+			merged.RequestedDuration = r.RequestedDuration
+			merged.Exactly = r.Exactly
+			merged.StartTime = r.StartTime
+			if r.DurationHistogram != nil {
+				for _, p := range r.DurationHistogram.Percentiles {
+					percentiles = append(percentiles, p.Percentile)
+				}
+			}
+		}
+		if r.StartTime.Before(merged.StartTime) {
+			merged.StartTime = r.StartTime
+		}
+		if rEnd := r.StartTime.Add(r.ActualDuration); rEnd.After(end) {
+			end = rEnd
+		}
+		merged.ActualQPS += r.ActualQPS
+		merged.NumThreads += r.NumThreads
+		hists = append(hists, r.DurationHistogram)
+	}
+	merged.ActualDuration = end.Sub(merged.StartTime)
+	merged.DurationHistogram = MergeHistogramData(percentiles, hists...)
+	merged.RequestedQPS = "max"
+	if opts.HTTPQPS > 0 {
+		merged.RequestedQPS = fmt.Sprintf("%g", opts.HTTPQPS)
+	}
+	return merged
+}
+
+// remarshal converts between the map and struct forms of the results by going through json
+func remarshal(in, out interface{}) error {
+	bd, err := json.Marshal(in)
+	if err != nil {
+		return errors.Wrap(err, "error while converting results")
+	}
+	if err = json.Unmarshal(bd, out); err != nil {
+		return errors.Wrap(err, "error while converting results")
+	}
+	return nil
+}
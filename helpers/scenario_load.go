@@ -0,0 +1,402 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"fortio.org/fortio/fhttp"
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"fortio.org/fortio/version"
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// scenarioPlaceholder matches the placeholders of the urls of the endpoints of a scenario, like {id}
+var scenarioPlaceholder = regexp.MustCompile(`\{[^{}/]+\}`)
+
+// ScenarioEndpointURL returns the url of the endpoint with its placeholders replaced by the given value
+func ScenarioEndpointURL(e *models.LoadTestEndpoint, value string) string {
+	return scenarioPlaceholder.ReplaceAllLiteralString(e.URL, value)
+}
+
+// scenarioEndpointResult holds the results of the requests sent to an endpoint of a scenario
+type scenarioEndpointResult struct {
+	Name              string               `json:"name"`
+	Method            string               `json:"method"`
+	URL               string               `json:"url"`
+	Weight            float64              `json:"weight"`
+	ActualQPS         float64              `json:"actual_qps"`
+	RetCodes          map[int]int64        `json:"ret_codes"`
+	DurationHistogram *stats.HistogramData `json:"duration_histogram"`
+}
+
+// scenarioEndpoint is an endpoint of a running scenario
+type scenarioEndpoint struct {
+	*models.LoadTestEndpoint
+	// cumulativeWeight is the sum of the weights of the endpoints up to this one
+	cumulativeWeight float64
+	// nextValue is the index of the value used by the next request
+	nextValue uint64
+}
+
+// scenarioStats holds what the connections of a scenario test recorded over one of its stages, or over the whole test
+// for a test without stages, per endpoint
+type scenarioStats struct {
+	latencies []*stats.Histogram
+	retCodes  []map[int]int64
+	sizes     *stats.Histogram
+}
+
+func newScenarioStats(endpoints int) *scenarioStats {
+	st := &scenarioStats{sizes: stats.NewHistogram(0, 100)}
+	for i := 0; i < endpoints; i++ {
+		st.latencies = append(st.latencies, stats.NewHistogram(0, periodic.DefaultRunnerOptions.Resolution))
+		st.retCodes = append(st.retCodes, map[int]int64{})
+	}
+	return st
+}
+
+// transfer moves what the other stats recorded to these ones
+func (s *scenarioStats) transfer(o *scenarioStats) {
+	for i := range s.latencies {
+		s.latencies[i].Transfer(o.latencies[i])
+		for code, count := range o.retCodes[i] {
+			s.retCodes[i][code] += count
+		}
+	}
+	s.sizes.Transfer(o.sizes)
+}
+
+// scenarioConnection holds what a connection of a scenario test recorded over each stage
+type scenarioConnection struct {
+	rand  *rand.Rand
+	stats []*scenarioStats
+}
+
+// ScenarioLoadTest runs an HTTP load test whose requests are spread across the endpoints of the scenario according
+// to their weights, following the load model and the load profile of the options. The overall results are in the
+// Fortio form while the results of each endpoint are under "scenario", the ones of each stage of a multi-stage test
+// being under "stages" in the same form
+func ScenarioLoadTest(ctx context.Context, opts *models.LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error) {
+	if opts.IsGRPC || opts.HTTP10 {
+		err := errors.New("scenario tests only support HTTP/1.1")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	profile, err := newLoadProfile(opts)
+	if err != nil {
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	if opts.LoadModel == models.OpenLoadModel && profile.unpaced {
+		err := errors.New("the open load model needs a target rate")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	client, err := scenarioHTTPClient(opts)
+	if err != nil {
+		logrus.Error(err)
+		return nil, nil, err
+	}
+
+	endpoints := make([]*scenarioEndpoint, len(opts.Scenario))
+	totalWeight := 0.0
+	for i, e := range opts.Scenario {
+		totalWeight += e.Weight
+		endpoints[i] = &scenarioEndpoint{LoadTestEndpoint: e, cumulativeWeight: totalWeight}
+	}
+	if totalWeight <= 0 {
+		err := errors.New("the endpoints of the scenario have no weight")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+
+	numConns := profile.maxConnections()
+	percentiles := fortioDefaultPercentiles
+	if len(opts.Percentiles) > 0 {
+		percentiles = opts.Percentiles
+	}
+	conns := make([]*scenarioConnection, numConns)
+	seed := time.Now().UnixNano()
+	for i := range conns {
+		c := &scenarioConnection{rand: rand.New(rand.NewSource(seed + int64(i)))}
+		for s := 0; s < profile.stageCount(); s++ {
+			c.stats = append(c.stats, newScenarioStats(len(endpoints)))
+		}
+		conns[i] = c
+	}
+
+	logrus.Infof("Starting scenario test of %d endpoints with up to %d connections at %s qps", len(endpoints), numConns, profile.requestedQPS(0, opts.TotalDuration()))
+	runCtx, cancelRun := loadTestRunContext(ctx, opts)
+	defer cancelRun()
+	start := time.Now()
+	measured := start.Add(opts.WarmUp)
+	startRecorderAfterWarmUp(runCtx, opts, start)
+	elapsed := runLoadSchedule(runCtx, start, profile, opts.LoadModel, func(conn int, origin time.Time, warmingUp bool) {
+		c := conns[conn]
+		pick := c.rand.Float64() * totalWeight
+		i := 0
+		for i < len(endpoints)-1 && pick >= endpoints[i].cumulativeWeight {
+			i++
+		}
+		code, size := sendScenarioRequest(runCtx, client, opts, endpoints[i])
+		if runCtx.Err() != nil || warmingUp {
+			return
+		}
+		latency := time.Since(origin)
+		if opts.Recorder != nil {
+			opts.Recorder.Record(code, latency)
+		}
+		st := c.stats[profile.stageAt(origin.Sub(measured))]
+		st.latencies[i].Record(latency.Seconds())
+		st.retCodes[i][code]++
+		st.sizes.Record(float64(size))
+	})
+	if ctx.Err() != nil {
+		err := errors.Wrap(ctx.Err(), "error while running tests")
+		logrus.Error(err)
+		return nil, nil, err
+	}
+
+	stageStats := make([]*scenarioStats, profile.stageCount())
+	for s := range stageStats {
+		stageStats[s] = newScenarioStats(len(endpoints))
+		for _, c := range conns {
+			stageStats[s].transfer(c.stats[s])
+		}
+	}
+	// results returns the results of the requests recorded by st, sent between from and to after the warm-up
+	results := func(st *scenarioStats, from, to, ran time.Duration, numConns int) (map[string]interface{}, *periodic.RunnerResults, error) {
+		latencies := stats.NewHistogram(0, periodic.DefaultRunnerOptions.Resolution)
+		retCodes := map[int]int64{}
+		endpointResults := []*scenarioEndpointResult{}
+		for i, e := range endpoints {
+			er := &scenarioEndpointResult{
+				Name:     e.Name,
+				Method:   e.Method(),
+				URL:      e.URL,
+				Weight:   e.Weight,
+				RetCodes: st.retCodes[i],
+			}
+			for code, count := range st.retCodes[i] {
+				retCodes[code] += count
+			}
+			er.DurationHistogram = exportHistogram(st.latencies[i], percentiles)
+			er.ActualQPS = ratePerSecond(st.latencies[i].Count, ran)
+			latencies.Transfer(st.latencies[i].Clone())
+			endpointResults = append(endpointResults, er)
+		}
+		res := &fhttp.HTTPRunnerResults{
+			RunnerResults: periodic.RunnerResults{
+				RunType:           "HTTP",
+				Labels:            opts.Name + " -_- " + strings.TrimLeft(opts.URL, " \t\r\n"),
+				StartTime:         measured.Add(from),
+				RequestedQPS:      profile.requestedQPS(from, to),
+				RequestedDuration: (to - from).String(),
+				ActualQPS:         ratePerSecond(latencies.Count, ran),
+				ActualDuration:    ran,
+				NumThreads:        numConns,
+				Version:           version.Short(),
+				DurationHistogram: exportHistogram(latencies, percentiles),
+			},
+			RetCodes:    retCodes,
+			Sizes:       exportHistogram(st.sizes, nil),
+			HeaderSizes: &stats.HistogramData{},
+			URL:         opts.URL,
+		}
+		resultsMap := map[string]interface{}{}
+		if err := remarshal(res, &resultsMap); err != nil {
+			return nil, nil, err
+		}
+		scenario := []interface{}{}
+		if err := remarshal(endpointResults, &scenario); err != nil {
+			return nil, nil, err
+		}
+		resultsMap[models.ScenarioResultKey] = scenario
+		return resultsMap, res.Result(), nil
+	}
+
+	total := newScenarioStats(len(endpoints))
+	stages := []map[string]interface{}{}
+	for s, stage := range profile.stages {
+		if ran := stage.ran(elapsed); ran > 0 {
+			stageMap, _, err := results(stageStats[s], stage.offset, stage.offset+stage.length(), ran, stage.connections)
+			if err != nil {
+				logrus.Error(err)
+				return nil, nil, err
+			}
+			stages = append(stages, stage.results(stageMap))
+		}
+	}
+	for _, st := range stageStats {
+		total.transfer(st)
+	}
+	resultsMap, result, err := results(total, 0, opts.TotalDuration(), elapsed, numConns)
+	if err != nil {
+		logrus.Error(err)
+		return nil, nil, err
+	}
+	if len(profile.stages) > 0 {
+		resultsMap["stages"] = stages
+	}
+	logrus.Debugf("Mapped version of the test: %+#v", resultsMap)
+	return resultsMap, result, nil
+}
+
+// exportHistogram exports the histogram along with the given percentiles, an empty histogram, like the one of an
+// endpoint which got no requests, being exported as zeros as its average is not a number
+func exportHistogram(h *stats.Histogram, percentiles []float64) *stats.HistogramData {
+	if h.Count == 0 {
+		return &stats.HistogramData{}
+	}
+	if len(percentiles) == 0 {
+		return h.Export()
+	}
+	return h.Export().CalcPercentiles(percentiles)
+}
+
+// scenarioHTTPClient returns the client shared by the connections of a scenario test, which opens at most one
+// connection per connection of the test to each host, like Fortio
+func scenarioHTTPClient(opts *models.LoadTestOptions) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: opts.IsInsecure,
+		ServerName:         opts.CertOverride,
+	}
+	if opts.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(opts.CACert)) {
+			return nil, errors.New("unable to parse the CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if opts.Cert != "" {
+		cert, err := tls.X509KeyPair([]byte(opts.Cert), []byte(opts.Key))
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to parse the client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	numConns := opts.HTTPNumThreads
+	if numConns < 1 {
+		numConns = 1
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			MaxConnsPerHost:     numConns,
+			MaxIdleConnsPerHost: numConns,
+			DisableKeepAlives:   opts.DisableKeepAlive,
+			DisableCompression:  !opts.Compression,
+			TLSClientConfig:     tlsConfig,
+		},
+		Timeout: fhttp.HTTPReqTimeOutDefaultValue,
+	}, nil
+}
+
+// sendScenarioRequest sends a request to the endpoint and returns the response code, -1 on socket errors like
+// Fortio, along with the size of the response body
+func sendScenarioRequest(ctx context.Context, client *http.Client, opts *models.LoadTestOptions, e *scenarioEndpoint) (int, int64) {
+	value := ""
+	if len(e.Values) > 0 {
+		n := atomic.AddUint64(&e.nextValue, 1) - 1
+		value = e.Values[n%uint64(len(e.Values))]
+	}
+	req, err := newScenarioRequest(ctx, opts, e.LoadTestEndpoint, value)
+	if err != nil {
+		logrus.Debugf("unable to create the request to %s: %v", e.Name, err)
+		return -1, 0
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		logrus.Debugf("request to %s failed: %v", e.Name, err)
+		return -1, 0
+	}
+	size, _ := io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return resp.StatusCode, size
+}
+
+// newScenarioRequest creates a request to the endpoint with the placeholder of its URL replaced by the value, the
+// headers of the endpoint taking precedence over the ones of the test
+func newScenarioRequest(ctx context.Context, opts *models.LoadTestOptions, e *models.LoadTestEndpoint, value string) (*http.Request, error) {
+	var body io.Reader
+	if len(e.Payload) > 0 {
+		body = bytes.NewReader(e.Payload)
+	}
+	u := e.URL
+	if len(e.Values) > 0 {
+		u = ScenarioEndpointURL(e, value)
+	}
+	req, err := http.NewRequestWithContext(ctx, e.Method(), u, body)
+	if err != nil {
+		return nil, err
+	}
+	for _, headers := range []http.Header{opts.HTTPHeaders, e.HTTPHeaders} {
+		for key, values := range headers {
+			if strings.EqualFold(key, "Host") {
+				req.Host = headers.Get(key)
+				continue
+			}
+			req.Header[http.CanonicalHeaderKey(key)] = values
+		}
+	}
+	if e.ContentType != "" {
+		req.Header.Set("Content-Type", e.ContentType)
+	}
+	return req, nil
+}
+
+// mergeScenarioResults merges the results of the endpoints of several runs of the same scenario test by endpoint
+func mergeScenarioResults(resultsMaps []map[string]interface{}, percentiles []float64) ([]interface{}, error) {
+	merged := []*scenarioEndpointResult{}
+	hists := map[string][]*stats.HistogramData{}
+	for _, rm := range resultsMaps {
+		sc, ok := rm[models.ScenarioResultKey]
+		if !ok {
+			continue
+		}
+		ers := []*scenarioEndpointResult{}
+		if err := remarshal(sc, &ers); err != nil {
+			return nil, err
+		}
+		for _, er := range ers {
+			var m *scenarioEndpointResult
+			for _, candidate := range merged {
+				if candidate.Name == er.Name {
+					m = candidate
+				}
+			}
+			if m == nil {
+				m = &scenarioEndpointResult{Name: er.Name, Method: er.Method, URL: er.URL, Weight: er.Weight, RetCodes: map[int]int64{}}
+				merged = append(merged, m)
+			}
+			for code, count := range er.RetCodes {
+				m.RetCodes[code] += count
+			}
+			m.ActualQPS += er.ActualQPS
+			hists[er.Name] = append(hists[er.Name], er.DurationHistogram)
+		}
+	}
+	if len(merged) == 0 {
+		return nil, nil
+	}
+	for _, m := range merged {
+		m.DurationHistogram = MergeHistogramData(percentiles, hists[m.Name]...)
+	}
+	scenario := []interface{}{}
+	if err := remarshal(merged, &scenario); err != nil {
+		return nil, err
+	}
+	return scenario, nil
+}
@@ -0,0 +1,173 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"fortio.org/fortio/periodic"
+	"github.com/layer5io/meshery/models"
+	promModel "github.com/prometheus/common/model"
+	"github.com/sirupsen/logrus"
+)
+
+// EvaluateSLOs checks the results of a load test against its SLO assertions, the Prometheus
+// assertions are evaluated over the window of the test using the Prometheus at promURL
+func EvaluateSLOs(ctx context.Context, opts *models.LoadTestOptions, resultsMap map[string]interface{}, result *periodic.RunnerResults,
+	promClient *models.PrometheusClient, promURL string) *models.SLOVerdict {
+	verdict := &models.SLOVerdict{
+		Passed: true,
+	}
+	for _, a := range opts.SLOs {
+		value, err := sloValue(ctx, a, opts, resultsMap, result, promClient, promURL)
+		res := &models.SLOAssertionResult{
+			SLOAssertion: a,
+			Value:        value,
+		}
+		if err != nil {
+			logrus.Warnf("unable to evaluate the SLO assertion %q: %v", a.Expr, err)
+			res.Error = err.Error()
+		} else {
+			res.Passed = a.Check(value)
+		}
+		verdict.Passed = verdict.Passed && res.Passed
+		verdict.Results = append(verdict.Results, res)
+	}
+	return verdict
+}
+
+func sloValue(ctx context.Context, a *models.SLOAssertion, opts *models.LoadTestOptions, resultsMap map[string]interface{}, result *periodic.RunnerResults,
+	promClient *models.PrometheusClient, promURL string) (float64, error) {
+	hist := result.DurationHistogram
+	switch a.Metric {
+	case models.SLOLatencyPercentile:
+		if hist == nil || hist.Count == 0 {
+			return 0, fmt.Errorf("no latencies were recorded")
+		}
+		for _, p := range hist.Percentiles {
+			if p.Percentile == a.Percentile {
+				return p.Value * 1000, nil
+			}
+		}
+		if len(hist.Data) == 0 {
+			return 0, fmt.Errorf("the percentile %g was not computed by the load generator", a.Percentile)
+		}
+		return bucketsPercentile(hist, a.Percentile) * 1000, nil
+	case models.SLOLatencyAvg:
+		if hist == nil || hist.Count == 0 {
+			return 0, fmt.Errorf("no latencies were recorded")
+		}
+		return hist.Avg * 1000, nil
+	case models.SLOLatencyMax:
+		if hist == nil || hist.Count == 0 {
+			return 0, fmt.Errorf("no latencies were recorded")
+		}
+		return hist.Max * 1000, nil
+	case models.SLOErrorRate:
+		return errorRate(opts, resultsMap)
+	case models.SLOQPSRatio:
+		if len(opts.Stages) > 0 {
+			return 0, fmt.Errorf("the QPS ratio does not apply to multi-stage load profiles")
+		}
+		if opts.HTTPQPS <= 0 {
+			return 0, fmt.Errorf("the QPS ratio does not apply to load tests run at max speed")
+		}
+		return 100 * result.ActualQPS / opts.HTTPQPS, nil
+	case models.SLOPrometheusMax, models.SLOPrometheusAvg:
+		if promURL == "" {
+			return 0, fmt.Errorf("Prometheus is not configured")
+		}
+		start, end := result.StartTime, result.StartTime.Add(result.ActualDuration)
+		step := promClient.ComputeStep(ctx, start, end)
+		data, err := promClient.QueryRangeUsingClient(ctx, promURL, a.Query, start, end, step)
+		if err != nil {
+			return 0, err
+		}
+		return aggregatePrometheusValue(data, a.Metric == models.SLOPrometheusMax)
+	}
+	return 0, fmt.Errorf("unknown SLO metric %s", a.Metric)
+}
+
+// errorRate computes the percentage of failed requests from the return codes
+func errorRate(opts *models.LoadTestOptions, resultsMap map[string]interface{}) (float64, error) {
+	if resultsMap["RetCodes"] == nil {
+		return 0, fmt.Errorf("the results do not contain any return codes")
+	}
+	retCodes := map[string]int64{}
+	if err := remarshal(resultsMap["RetCodes"], &retCodes); err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, count := range retCodes {
+		total += count
+	}
+	if total == 0 {
+		return 0, fmt.Errorf("no requests were recorded")
+	}
+	return 100 * float64(failedRequests(retCodes, opts.IsGRPC)) / float64(total), nil
+}
+
+// failedRequests counts the failed requests from the return codes: non 2xx status codes
+// for HTTP and anything but SERVING for gRPC
+func failedRequests(retCodes map[string]int64, grpc bool) int64 {
+	var failed int64
+	for code, count := range retCodes {
+		if grpc {
+			if code != "SERVING" {
+				failed += count
+			}
+			continue
+		}
+		if c, err := strconv.Atoi(code); err != nil || c < 200 || c > 299 {
+			failed += count
+		}
+	}
+	return failed
+}
+
+// aggregatePrometheusValue returns either the max or the average of all the samples of a query result
+func aggregatePrometheusValue(data promModel.Value, max bool) (float64, error) {
+	samples := []float64{}
+	switch v := data.(type) {
+	case promModel.Matrix:
+		for _, series := range v {
+			for _, sp := range series.Values {
+				samples = append(samples, float64(sp.Value))
+			}
+		}
+	case promModel.Vector:
+		for _, s := range v {
+			samples = append(samples, float64(s.Value))
+		}
+	case *promModel.Scalar:
+		samples = append(samples, float64(v.Value))
+	}
+	// the NaN samples, eg. the quantiles of empty histograms, are not values
+	n := 0
+	for _, s := range samples {
+		if !math.IsNaN(s) {
+			samples[n] = s
+			n++
+		}
+	}
+	samples = samples[:n]
+	if len(samples) == 0 {
+		return 0, fmt.Errorf("the query did not return any data")
+	}
+	var agg float64
+	if max {
+		agg = math.Inf(-1)
+	}
+	for _, s := range samples {
+		if max {
+			agg = math.Max(agg, s)
+		} else {
+			agg += s
+		}
+	}
+	if !max {
+		agg /= float64(len(samples))
+	}
+	return agg, nil
+}
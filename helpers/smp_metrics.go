@@ -0,0 +1,230 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/layer5io/meshery/models"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// smpMetricQuery is a PromQL query whose result, aggregated over the window of the test, is a field of the SMP metrics
+type smpMetricQuery struct {
+	query string
+	// max aggregates the samples with their maximum instead of their average
+	max bool
+	set func(m *models.Metrics, v float64)
+}
+
+// smpMeshProfile holds the cAdvisor label selectors of the containers of a mesh, an empty selector is skipped
+type smpMeshProfile struct {
+	sidecars     string
+	gateway      string
+	controlPlane string
+	telemetry    string
+	policy       string
+	// proxyNames matches the names of the proxy containers, which are not accounted for in the workload
+	proxyNames string
+	// extraQueries are the queries of the metrics exposed by the mesh itself
+	extraQueries []smpMetricQuery
+}
+
+// the selectors exclude the pod wide cgroup and the pause containers reported by cAdvisor
+const smpContainerSelector = `container!="",container!="POD"`
+
+var smpMeshProfiles = map[string]smpMeshProfile{
+	"istio": {
+		sidecars:     `container="istio-proxy",pod!~"istio-ingressgateway.*"`,
+		gateway:      `pod=~"istio-ingressgateway.*"`,
+		controlPlane: `namespace="istio-system",pod=~"istiod.*|istio-pilot.*|istio-citadel.*|istio-galley.*"`,
+		telemetry:    `namespace="istio-system",pod=~"istio-telemetry.*"`,
+		policy:       `namespace="istio-system",pod=~"istio-policy.*"`,
+		proxyNames:   "istio-proxy",
+		extraQueries: []smpMetricQuery{
+			{`sum(rate(istio_requests_total{reporter="destination"}[1m]))`, false, func(m *models.Metrics, v float64) { m.Sidecars.Rps = v }},
+			{`sum(rate(istio_request_bytes_sum{reporter="destination"}[1m])) + sum(rate(istio_response_bytes_sum{reporter="destination"}[1m]))`, false,
+				func(m *models.Metrics, v float64) { m.Sidecars.Bps = v }},
+			{`sum(rate(istio_requests_total{reporter="source",source_workload="istio-ingressgateway"}[1m]))`, false,
+				func(m *models.Metrics, v float64) { m.IngressGateway.Rps = v }},
+			{`sum(rate(istio_request_bytes_sum{reporter="source",source_workload="istio-ingressgateway"}[1m])) + sum(rate(istio_response_bytes_sum{reporter="source",source_workload="istio-ingressgateway"}[1m]))`, false,
+				func(m *models.Metrics, v float64) { m.IngressGateway.Bps = v }},
+			{`max(pilot_xds)`, true, func(m *models.Metrics, v float64) { m.MeshControlPlane.Sidecars = int(v) }},
+			{`max(pilot_services)`, true, func(m *models.Metrics, v float64) { m.MeshControlPlane.Services = int(v) }},
+			{`sum(pilot_xds_eds_instances)`, true, func(m *models.Metrics, v float64) { m.MeshControlPlane.Endpoints = int(v) }},
+			{`max(pilot_virt_services)`, true, func(m *models.Metrics, v float64) { m.MeshControlPlane.VirtualServices = int(v) }},
+			{`max(galley_istio_networking_destinationrules)`, true, func(m *models.Metrics, v float64) { m.MeshControlPlane.DestinationRules = int(v) }},
+			// the xDS latencies are the 99th percentile of the push times, in milliseconds
+			{`histogram_quantile(0.99, sum(rate(pilot_xds_push_time_bucket{type="lds"}[1m])) by (le)) * 1000`, false,
+				func(m *models.Metrics, v float64) { m.MeshControlPlane.LdsLatencyMs = v }},
+			{`histogram_quantile(0.99, sum(rate(pilot_xds_push_time_bucket{type="cds"}[1m])) by (le)) * 1000`, false,
+				func(m *models.Metrics, v float64) { m.MeshControlPlane.CdsLatencyMs = v }},
+			{`sum(rate(mixer_runtime_dispatches_total[1m]))`, false, func(m *models.Metrics, v float64) { m.MeshTelemetry.Rps = v }},
+			{`sum(rate(grpc_server_handled_total{grpc_method="Check"}[1m]))`, false, func(m *models.Metrics, v float64) { m.MeshPolicy.Rps = v }},
+		},
+	},
+	"linkerd": {
+		sidecars:     `container="linkerd-proxy",namespace!="linkerd"`,
+		controlPlane: `namespace="linkerd",container!="linkerd-proxy"`,
+		proxyNames:   "linkerd-proxy",
+		extraQueries: []smpMetricQuery{
+			{`sum(rate(request_total{direction="inbound",namespace!="linkerd"}[1m]))`, false, func(m *models.Metrics, v float64) { m.Sidecars.Rps = v }},
+			{`sum(rate(tcp_read_bytes_total{direction="inbound",namespace!="linkerd"}[1m])) + sum(rate(tcp_write_bytes_total{direction="inbound",namespace!="linkerd"}[1m]))`, false,
+				func(m *models.Metrics, v float64) { m.Sidecars.Bps = v }},
+		},
+	},
+	"consul": {
+		sidecars:     `container="envoy-sidecar"`,
+		controlPlane: `pod=~"consul-.*",container!="envoy-sidecar"`,
+		proxyNames:   "envoy-sidecar",
+	},
+}
+
+// smpDefaultProfile is used for the meshes without a profile, only the resources used by the sidecars are collected
+var smpDefaultProfile = smpMeshProfile{
+	sidecars:   `container=~"istio-proxy|linkerd-proxy|envoy-sidecar|envoy"`,
+	proxyNames: "istio-proxy|linkerd-proxy|envoy-sidecar|envoy",
+}
+
+// smpWorkloadName keeps the characters of a kubernetes name so that it can be used in a label selector
+var smpWorkloadName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// smpMeshProfileFor returns the profile of the mesh, matched on its name, eg. istio for "Istio 1.5"
+func smpMeshProfileFor(mesh string) smpMeshProfile {
+	mesh = strings.ToLower(mesh)
+	for name, profile := range smpMeshProfiles {
+		if strings.Contains(mesh, name) {
+			return profile
+		}
+	}
+	return smpDefaultProfile
+}
+
+// smpResourceQueries returns the queries of the number of pods and of the CPU, in millicores, and memory, in MiB,
+// used by the containers matching the selector
+func smpResourceQueries(selector string, count, cpu, mem func(m *models.Metrics, v float64)) []smpMetricQuery {
+	if selector == "" {
+		return nil
+	}
+	selector += "," + smpContainerSelector
+	return []smpMetricQuery{
+		{fmt.Sprintf(`count(count by (namespace, pod) (container_memory_working_set_bytes{%s}))`, selector), true, count},
+		{fmt.Sprintf(`sum(rate(container_cpu_usage_seconds_total{%s}[1m])) * 1000`, selector), false, cpu},
+		{fmt.Sprintf(`sum(container_memory_working_set_bytes{%s}) / 1048576`, selector), false, mem},
+	}
+}
+
+// smpMetricQueries returns the queries of the SMP metrics for the mesh, the workload is the one behind the endpoint
+// of the test, which is assumed to be a kubernetes service named after its pods
+func smpMetricQueries(mesh, endpoint string) []smpMetricQuery {
+	profile := smpMeshProfileFor(mesh)
+	queries := []smpMetricQuery{}
+	queries = append(queries, smpResourceQueries(profile.sidecars,
+		func(m *models.Metrics, v float64) { m.Sidecars.Count = int(v) },
+		func(m *models.Metrics, v float64) { m.Sidecars.CPUMCores = v },
+		func(m *models.Metrics, v float64) { m.Sidecars.MemMb = v })...)
+	queries = append(queries, smpResourceQueries(profile.gateway,
+		func(m *models.Metrics, v float64) { m.IngressGateway.Count = int(v) },
+		func(m *models.Metrics, v float64) { m.IngressGateway.CPUMCores = v },
+		func(m *models.Metrics, v float64) { m.IngressGateway.MemMb = v })...)
+	queries = append(queries, smpResourceQueries(profile.controlPlane,
+		func(m *models.Metrics, v float64) { m.MeshControlPlane.Count = int(v) },
+		func(m *models.Metrics, v float64) { m.MeshControlPlane.CPUMCores = v },
+		func(m *models.Metrics, v float64) { m.MeshControlPlane.MemMb = v })...)
+	queries = append(queries, smpResourceQueries(profile.telemetry,
+		func(m *models.Metrics, v float64) { m.MeshTelemetry.Count = int(v) },
+		func(m *models.Metrics, v float64) { m.MeshTelemetry.CPUMCores = v },
+		func(m *models.Metrics, v float64) { m.MeshTelemetry.MemMb = v })...)
+	queries = append(queries, smpResourceQueries(profile.policy,
+		func(m *models.Metrics, v float64) { m.MeshPolicy.Count = int(v) },
+		func(m *models.Metrics, v float64) { m.MeshPolicy.CPUMCores = v },
+		func(m *models.Metrics, v float64) { m.MeshPolicy.MemMb = v })...)
+	queries = append(queries, profile.extraQueries...)
+
+	if workload := smpWorkload(endpoint); workload != "" {
+		selector := fmt.Sprintf(`pod=~"%s-.*",container!~"%s"`, workload, profile.proxyNames)
+		queries = append(queries, smpResourceQueries(selector,
+			func(m *models.Metrics, v float64) { m.IndividualWorkload.Count = int(v) },
+			func(m *models.Metrics, v float64) { m.IndividualWorkload.CPUMCores = v },
+			func(m *models.Metrics, v float64) { m.IndividualWorkload.MemMb = v })...)
+	}
+	return queries
+}
+
+// smpWorkload returns the first label of the host of the endpoint if it is a valid kubernetes name
+func smpWorkload(endpoint string) string {
+	host := endpoint
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		host = u.Hostname()
+	} else if i := strings.LastIndex(host, ":"); i >= 0 {
+		// gRPC destinations are host:port
+		host = host[:i]
+	}
+	name := strings.SplitN(host, ".", 2)[0]
+	if !smpWorkloadName.MatchString(name) || name == "localhost" {
+		return ""
+	}
+	return name
+}
+
+// CollectSMPMetrics computes the SMP metrics of the mesh over the window of a test: the number of pods and the
+// resources used by the sidecars, the ingress gateway, the control plane and the workload behind the endpoint,
+// along with the requests handled and the xDS latencies when the mesh exposes them. The queries which fail or
+// return no data are left out
+func CollectSMPMetrics(ctx context.Context, promClient *models.PrometheusClient, promURL, mesh, endpoint string, start, end time.Time) (*models.Metrics, error) {
+	m := &models.Metrics{
+		IngressGateway:     &models.IngressGateway{},
+		Sidecars:           &models.Sidecars{},
+		MeshTelemetry:      &models.MeshTelemetry{},
+		MeshPolicy:         &models.MeshPolicy{},
+		MeshControlPlane:   &models.MeshControlPlane{},
+		IndividualWorkload: &models.Workload{},
+	}
+	step := promClient.ComputeStep(ctx, start, end)
+	succeeded := false
+	for _, q := range smpMetricQueries(mesh, endpoint) {
+		data, err := promClient.QueryRangeUsingClient(ctx, promURL, q.query, start, end, step)
+		if err != nil {
+			// Prometheus is most likely unreachable when the first query fails
+			if !succeeded {
+				return nil, errors.Wrap(err, "unable to collect the SMP metrics")
+			}
+			logrus.Warnf("unable to collect an SMP metric: %v", err)
+			continue
+		}
+		succeeded = true
+		v, err := aggregatePrometheusValue(data, q.max)
+		if err != nil {
+			logrus.Debugf("no SMP metric for the query %s: %v", q.query, err)
+			continue
+		}
+		q.set(m, v)
+	}
+
+	// the groups without any value are left out of the spec
+	if *m.IngressGateway == (models.IngressGateway{}) {
+		m.IngressGateway = nil
+	}
+	if *m.Sidecars == (models.Sidecars{}) {
+		m.Sidecars = nil
+	}
+	if *m.MeshTelemetry == (models.MeshTelemetry{}) {
+		m.MeshTelemetry = nil
+	}
+	if *m.MeshPolicy == (models.MeshPolicy{}) {
+		m.MeshPolicy = nil
+	}
+	if *m.MeshControlPlane == (models.MeshControlPlane{}) {
+		m.MeshControlPlane = nil
+	}
+	if *m.IndividualWorkload == (models.Workload{}) {
+		m.IndividualWorkload = nil
+	} else {
+		m.IndividualWorkload.Name = smpWorkload(endpoint)
+	}
+	return m, nil
+}
@@ -15,11 +15,16 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"math/rand"
 	"net/http"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -36,6 +41,32 @@ var (
 	testDuration       = ""
 	loadGenerator      = ""
 	testCookie         = ""
+	httpMethod         = ""
+	httpHeaders        = []string{}
+	contentType        = ""
+	requestBody        = ""
+	requestBodyFile    = ""
+	http10             = false
+	compression        = false
+	disableKeepAlive   = false
+	grpc               = false
+	grpcPing           = false
+	grpcPingDelay      = ""
+	grpcHealthSvc      = ""
+	grpcStreams        = 0
+	caCertFile         = ""
+	certFile           = ""
+	keyFile            = ""
+	certOverride       = ""
+	sloAssertions      = []string{}
+	guardrails         = []string{}
+	perfProfile        = ""
+	inCluster          = false
+	jobNamespace       = ""
+	injectSidecar      = false
+	loadModel          = ""
+	warmUp             = ""
+	scenarioFile       = ""
 )
 
 var seededRand = rand.New(
@@ -60,6 +91,11 @@ var perfCmd = &cobra.Command{
 		//Check prerequisite
 		preReqCheck()
 
+		if perfProfile != "" {
+			runPerformanceProfile(cmd)
+			return
+		}
+
 		println("Test name used : ", testName)
 
 		const mesheryURL string = "http://localhost:9081/api/load-test-smps?"
@@ -76,9 +112,20 @@ var perfCmd = &cobra.Command{
 		postData = postData + "start_time: " + startTime.Format(time.RFC3339)
 		postData = postData + "\nend_time: " + endTime.Format(time.RFC3339)
 
+		scenario := ""
+		if scenarioFile != "" {
+			b, err := ioutil.ReadFile(scenarioFile)
+			if err != nil {
+				println("Error: unable to read the scenario file: " + err.Error())
+				return
+			}
+			scenario = string(b)
+		}
+
+		// the url of a scenario test defaults to the one of its first endpoint
 		if len(testURL) > 0 {
 			postData = postData + "\nendpoint_url: " + testURL
-		} else {
+		} else if scenario == "" {
 			println("Error: Please enter a TestURL")
 			return
 		}
@@ -86,6 +133,33 @@ var perfCmd = &cobra.Command{
 		postData = postData + "\nclient:"
 		postData = postData + "\n connections: " + concurrentRequests
 		postData = postData + "\n rps: " + qps
+		if inCluster {
+			postData = postData + "\n internal: true"
+		}
+		if loadModel != "" {
+			postData = postData + "\n load_model: " + strconv.Quote(loadModel)
+		}
+		if warmUp != "" {
+			postData = postData + "\n warm_up: " + strconv.Quote(warmUp)
+		}
+
+		if grpc {
+			postData = postData + "\n protocol: grpc" + grpcSpec()
+		} else {
+			request, err := requestSpec()
+			if err != nil {
+				println("Error: " + err.Error())
+				return
+			}
+			postData = postData + request
+		}
+
+		tls, err := tlsSpec()
+		if err != nil {
+			println("Error: " + err.Error())
+			return
+		}
+		postData = postData + tls
 
 		req, err := http.NewRequest("POST", mesheryURL, bytes.NewBuffer([]byte(postData)))
 		if err != nil {
@@ -99,9 +173,22 @@ var perfCmd = &cobra.Command{
 		q := req.URL.Query()
 		q.Add("name", testName)
 		q.Add("loadGenerator", loadGenerator)
+		for _, slo := range sloAssertions {
+			q.Add("slo", slo)
+		}
+		for _, guardrail := range guardrails {
+			q.Add("guardrail", guardrail)
+		}
 		if len(testMesh) > 0 {
 			q.Add("mesh", testMesh)
 		}
+		if scenario != "" {
+			q.Add("scenario", scenario)
+		}
+		if inCluster {
+			q.Add("namespace", jobNamespace)
+			q.Add("injectSidecar", strconv.FormatBool(injectSidecar))
+		}
 		req.URL.RawQuery = q.Encode()
 
 		client := &http.Client{}
@@ -110,16 +197,329 @@ var perfCmd = &cobra.Command{
 			return
 		}
 
-		buf := make([]byte, 4)
-		for {
-			n, err := resp.Body.Read(buf)
-			fmt.Print(string(buf[:n]))
-			if err == io.EOF {
-				break
+		defer resp.Body.Close()
+		printLoadTestResults(resp)
+	},
+}
+
+// printLoadTestResults prints the events streamed by Meshery during a load test and exits with a non-zero
+// status when the test or any of its SLO assertions failed, when it was aborted by a guardrail, or when Meshery
+// dropped the stream as it fell behind
+func printLoadTestResults(resp *http.Response) {
+	var (
+		failed  bool
+		dropped bool
+		verdict *sloVerdict
+		abort   *loadTestAbort
+	)
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		event := &loadTestEvent{}
+		if !strings.HasPrefix(line, "data: ") || json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), event) != nil {
+			// the lines which are not events are printed as is, except for the blank lines separating the events
+			if line != "" {
+				fmt.Println(line)
 			}
+			continue
 		}
-		println("\nTest Completed Successfully!")
-	},
+		if event.Status == "progress" && event.Progress != nil {
+			printLoadTestProgress(event.Progress)
+			continue
+		}
+		if event.Status == "queued" {
+			fmt.Printf("Waiting in the run queue, position %d\n", event.QueuePosition)
+			continue
+		}
+		fmt.Println(line)
+		if event.Status == "error" {
+			failed = true
+		}
+		if event.Status == "dropped" {
+			dropped = true
+		}
+		if event.Result != nil && event.Result.Verdict != nil {
+			verdict = event.Result.Verdict
+		}
+		if event.Result != nil && event.Result.RunnerResults.Aborted != nil {
+			abort = event.Result.RunnerResults.Aborted
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		println("Error: unable to read the test results: " + err.Error())
+		os.Exit(1)
+	}
+	if dropped {
+		println("\nUnable to keep up with the test results, the test is still running on Meshery")
+		os.Exit(1)
+	}
+	if failed || (resp.StatusCode != http.StatusOK) {
+		println("\nTest Failed!")
+		os.Exit(1)
+	}
+	if verdict != nil {
+		println("\nSLO assertions:")
+		for _, r := range verdict.Results {
+			status := "PASS"
+			if !r.Passed {
+				status = "FAIL"
+			}
+			if r.Error != "" {
+				fmt.Printf(" %s  %s: %s\n", status, r.Expr, r.Error)
+			} else {
+				fmt.Printf(" %s  %s (actual: %g)\n", status, r.Expr, r.Value)
+			}
+		}
+		if !verdict.Passed {
+			println("\nTest Completed, SLO assertions failed!")
+			os.Exit(1)
+		}
+	}
+	if abort != nil {
+		fmt.Printf("\nTest Aborted by the guardrail %q: %s\n", abort.Guardrail, abort.Reason)
+		os.Exit(1)
+	}
+	println("\nTest Completed Successfully!")
+}
+
+// runPerformanceProfile runs a saved performance profile, the flags which were explicitly set override the settings of the profile
+func runPerformanceProfile(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	for _, flag := range []string{"method", "header", "content-type", "body", "body-file", "http10", "compression", "disable-keep-alive",
+		"grpc", "grpc-ping", "grpc-ping-delay", "grpc-health-svc", "grpc-streams", "ca-cert", "cert", "key", "cert-override", "scenario"} {
+		if flags.Changed(flag) {
+			println("Error: --" + flag + " cannot be combined with --profile, please update the profile instead")
+			os.Exit(1)
+		}
+	}
+	println("Performance profile used : ", perfProfile)
+
+	req, err := http.NewRequest("POST", url+"/api/performance/profiles/"+perfProfile+"/run", nil)
+	if err != nil {
+		println("Error in building the request")
+		return
+	}
+	cookieConf := strings.SplitN(testCookie, "=", 2)
+	if len(cookieConf) != 2 {
+		println("Error: invalid cookie " + testCookie + ", expecting name=value")
+		os.Exit(1)
+	}
+	req.AddCookie(&http.Cookie{Name: cookieConf[0], Value: cookieConf[1]})
+	q := req.URL.Query()
+	for flag, param := range map[string]string{
+		"url":                 "url",
+		"name":                "name",
+		"mesh":                "mesh",
+		"qps":                 "qps",
+		"concurrent-requests": "c",
+		"load-generator":      "loadGenerator",
+		"in-cluster":          "inCluster",
+		"namespace":           "namespace",
+		"inject-sidecar":      "injectSidecar",
+		"load-model":          "loadModel",
+		"warm-up":             "warmUp",
+	} {
+		if flags.Changed(flag) {
+			q.Set(param, flags.Lookup(flag).Value.String())
+		}
+	}
+	if flags.Changed("duration") {
+		duration, err := time.ParseDuration(testDuration)
+		if err != nil || duration <= 0 {
+			println("Error: Test duration invalid")
+			os.Exit(1)
+		}
+		q.Set("t", strconv.Itoa(int((duration+time.Second-1)/time.Second)))
+		q.Set("dur", "s")
+	}
+	for _, slo := range sloAssertions {
+		q.Add("slo", slo)
+	}
+	for _, guardrail := range guardrails {
+		q.Add("guardrail", guardrail)
+	}
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		println("Error: unable to reach Meshery: " + err.Error())
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+	printLoadTestResults(resp)
+}
+
+// printLoadTestProgress prints a snapshot of the live metrics of a running load test on a single line
+func printLoadTestProgress(p *loadTestProgress) {
+	seconds := func(s float64) time.Duration {
+		return time.Duration(s * float64(time.Second))
+	}
+	if p.ElapsedOnly {
+		fmt.Printf("[%8v] running, the requests of the test are not reported as it runs\n", seconds(p.Elapsed).Round(time.Second))
+		return
+	}
+	line := fmt.Sprintf("[%8v] %d requests, %.1f qps, p50 %v, p90 %v, p99 %v, %d errors", seconds(p.Elapsed).Round(time.Second),
+		p.Requests, p.QPS, seconds(p.P50).Round(time.Microsecond), seconds(p.P90).Round(time.Microsecond),
+		seconds(p.P99).Round(time.Microsecond), p.Errors)
+	if len(p.ErrorCodes) > 0 {
+		codes := make([]int, 0, len(p.ErrorCodes))
+		for code := range p.ErrorCodes {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		counts := []string{}
+		for _, code := range codes {
+			name := strconv.Itoa(code)
+			if code == -1 {
+				name = "socket"
+			}
+			counts = append(counts, fmt.Sprintf("%s: %d", name, p.ErrorCodes[code]))
+		}
+		line = line + " (" + strings.Join(counts, ", ") + ")"
+	}
+	fmt.Println(line)
+}
+
+// loadTestEvent is the part of the events streamed by Meshery during a load test used by perf
+type loadTestEvent struct {
+	Status string `json:"status"`
+	Result *struct {
+		Verdict       *sloVerdict `json:"verdict"`
+		RunnerResults struct {
+			Aborted *loadTestAbort `json:"aborted"`
+		} `json:"runner_results"`
+	} `json:"result"`
+	Progress      *loadTestProgress `json:"progress"`
+	QueuePosition int               `json:"queue_position"`
+}
+
+// loadTestProgress is a snapshot of the live metrics of a running load test, the durations being in seconds
+type loadTestProgress struct {
+	Elapsed    float64       `json:"elapsed"`
+	Requests   int64         `json:"requests"`
+	QPS        float64       `json:"qps"`
+	P50        float64       `json:"p50"`
+	P90        float64       `json:"p90"`
+	P99        float64       `json:"p99"`
+	Errors     int64         `json:"errors"`
+	ErrorCodes map[int]int64 `json:"error_codes"`
+	// ElapsedOnly is set when the snapshot only holds the elapsed time, the requests not being reported as they run
+	ElapsedOnly bool `json:"elapsed_only"`
+}
+
+// loadTestAbort is why a load test was ended early by a guardrail
+type loadTestAbort struct {
+	Guardrail string `json:"guardrail"`
+	Reason    string `json:"reason"`
+}
+
+type sloVerdict struct {
+	Passed  bool `json:"passed"`
+	Results []struct {
+		Expr   string  `json:"expr"`
+		Value  float64 `json:"value"`
+		Passed bool    `json:"passed"`
+		Error  string  `json:"error"`
+	} `json:"results"`
+}
+
+// requestSpec builds the request section of the client in the SMP spec from the flags
+func requestSpec() (string, error) {
+	body := requestBody
+	if requestBodyFile != "" {
+		if body != "" {
+			return "", fmt.Errorf("please provide either --body or --body-file")
+		}
+		b, err := ioutil.ReadFile(requestBodyFile)
+		if err != nil {
+			return "", fmt.Errorf("unable to read the body file: %v", err)
+		}
+		body = string(b)
+	}
+	spec := ""
+	if httpMethod != "" {
+		spec = spec + "\n  method: " + strconv.Quote(httpMethod)
+	}
+	if len(httpHeaders) > 0 {
+		spec = spec + "\n  headers:"
+		for _, hdr := range httpHeaders {
+			kv := strings.SplitN(hdr, ":", 2)
+			if len(kv) != 2 {
+				return "", fmt.Errorf("invalid header %q, expecting Key: Value", hdr)
+			}
+			spec = spec + "\n   " + strconv.Quote(strings.TrimSpace(kv[0])) + ": " + strconv.Quote(strings.TrimSpace(kv[1]))
+		}
+	}
+	if contentType != "" {
+		spec = spec + "\n  content_type: " + strconv.Quote(contentType)
+	}
+	if body != "" {
+		spec = spec + "\n  body: " + strconv.Quote(body)
+	}
+	if http10 {
+		spec = spec + "\n  http10: true"
+	}
+	if compression {
+		spec = spec + "\n  compression: true"
+	}
+	if disableKeepAlive {
+		spec = spec + "\n  disable_keep_alive: true"
+	}
+	if spec == "" {
+		return "", nil
+	}
+	return "\n request:" + spec, nil
+}
+
+// grpcSpec builds the grpc section of the client in the SMP spec from the flags
+func grpcSpec() string {
+	spec := ""
+	if grpcPing {
+		spec = spec + "\n  ping: true"
+	}
+	if grpcPingDelay != "" {
+		spec = spec + "\n  ping_delay: " + strconv.Quote(grpcPingDelay)
+	}
+	if grpcHealthSvc != "" {
+		spec = spec + "\n  health_service: " + strconv.Quote(grpcHealthSvc)
+	}
+	if grpcStreams > 0 {
+		spec = spec + "\n  streams: " + strconv.Itoa(grpcStreams)
+	}
+	if spec == "" {
+		return ""
+	}
+	return "\n grpc:" + spec
+}
+
+// tlsSpec builds the tls section of the client in the SMP spec from the certificate files
+func tlsSpec() (string, error) {
+	if (certFile == "") != (keyFile == "") {
+		return "", fmt.Errorf("please provide both --cert and --key")
+	}
+	spec := ""
+	for _, f := range []struct{ key, file string }{
+		{"ca_cert", caCertFile},
+		{"cert", certFile},
+		{"key", keyFile},
+	} {
+		if f.file == "" {
+			continue
+		}
+		pem, err := ioutil.ReadFile(f.file)
+		if err != nil {
+			return "", fmt.Errorf("unable to read %s: %v", f.file, err)
+		}
+		spec = spec + "\n  " + f.key + ": " + strconv.Quote(string(pem))
+	}
+	if certOverride != "" {
+		spec = spec + "\n  server_name: " + strconv.Quote(certOverride)
+	}
+	if spec == "" {
+		return "", nil
+	}
+	return "\n tls:" + spec, nil
 }
 
 func init() {
@@ -130,7 +530,33 @@ func init() {
 	perfCmd.Flags().StringVar(&qps, "qps", "0", "(optional) Queries per second")
 	perfCmd.Flags().StringVar(&concurrentRequests, "concurrent-requests", "1", "DESCRIPTION")
 	perfCmd.Flags().StringVar(&testDuration, "duration", "30s", "(optional) Duration of the test like 10s, 5m, 2h. We are following the convention described at https://golang.org/pkg/time/#ParseDuration")
-	perfCmd.Flags().StringVar(&testCookie, "cookie", "meshery-provider=Default Local Provider", "(required) identification of choice of provider.")
-	perfCmd.Flags().StringVar(&loadGenerator, "load-generator", "fortio", "	(optional) choice of load generator: fortio (OR) wrk2")
+	perfCmd.PersistentFlags().StringVar(&testCookie, "cookie", "meshery-provider=Default Local Provider", "(required) identification of choice of provider.")
+	perfCmd.Flags().StringVar(&loadGenerator, "load-generator", "fortio", "	(optional) choice of load generator: fortio, wrk2 (OR) nighthawk")
+	perfCmd.Flags().StringVar(&httpMethod, "method", "", "(optional) HTTP method of the requests, defaults to POST when a body is given and to GET otherwise")
+	perfCmd.Flags().StringArrayVarP(&httpHeaders, "header", "H", []string{}, "(optional) Header added to the requests like \"Host: example.com\", can be repeated")
+	perfCmd.Flags().StringVar(&contentType, "content-type", "", "(optional) Content type of the request body")
+	perfCmd.Flags().StringVar(&requestBody, "body", "", "(optional) Body of the requests")
+	perfCmd.Flags().StringVar(&requestBodyFile, "body-file", "", "(optional) File holding the body of the requests")
+	perfCmd.Flags().BoolVar(&http10, "http10", false, "(optional) Use HTTP/1.0 instead of HTTP/1.1")
+	perfCmd.Flags().BoolVar(&compression, "compression", false, "(optional) Request compressed responses")
+	perfCmd.Flags().BoolVar(&disableKeepAlive, "disable-keep-alive", false, "(optional) Open a new connection for every request")
+	perfCmd.Flags().BoolVar(&grpc, "grpc", false, "(optional) Run a gRPC load test, the URL can then be given as host:port")
+	perfCmd.Flags().BoolVar(&grpcPing, "grpc-ping", false, "(optional) Use the fortio ping service instead of the gRPC health check")
+	perfCmd.Flags().StringVar(&grpcPingDelay, "grpc-ping-delay", "", "(optional) Delay requested from the ping service like 10ms")
+	perfCmd.Flags().StringVar(&grpcHealthSvc, "grpc-health-svc", "", "(optional) Service checked by the gRPC health check")
+	perfCmd.Flags().IntVar(&grpcStreams, "grpc-streams", 0, "(optional) Number of gRPC streams per connection")
+	perfCmd.Flags().StringVar(&caCertFile, "ca-cert", "", "(optional) File holding the CA certificate used for verifying the server")
+	perfCmd.Flags().StringVar(&certFile, "cert", "", "(optional) File holding the TLS client certificate presented by HTTP tests")
+	perfCmd.Flags().StringVar(&keyFile, "key", "", "(optional) File holding the TLS client key")
+	perfCmd.Flags().StringVar(&certOverride, "cert-override", "", "(optional) Server name verified against the server certificate")
+	perfCmd.Flags().StringArrayVar(&sloAssertions, "slo", []string{}, "(optional) SLO assertion like \"p99 < 200ms\", \"error_rate < 0.1%\", \"qps_ratio >= 95\" or \"prom_max(<query>) < 2\", can be repeated. perf exits with a non-zero status when any of them fails")
+	perfCmd.Flags().StringArrayVar(&guardrails, "guardrail", []string{}, "(optional) Guardrail ending the test early once met like \"status == 503\", \"status == 5xx\", \"error_rate > 5% over 30s\", \"p99 > 2s over 1m\" or \"prom_max(<query>) > 0.9 over 1m\", can be repeated. The windows default to 10s and perf exits with a non-zero status when the test is aborted")
+	perfCmd.Flags().BoolVar(&inCluster, "in-cluster", false, "(optional) Generate the load from a Kubernetes job in the configured cluster instead of from Meshery")
+	perfCmd.Flags().StringVar(&jobNamespace, "namespace", "", "(optional) Namespace of the load generator job with --in-cluster, defaults to default")
+	perfCmd.Flags().BoolVar(&injectSidecar, "inject-sidecar", false, "(optional) Inject the sidecar of the mesh in the load generator job with --in-cluster")
+	perfCmd.Flags().StringVar(&loadModel, "load-model", "", "(optional) How the requests are paced: closed, where each connection waits for a response before its next request, or open, at a constant arrival rate, with the latencies corrected for coordinated omission by fortio and wrk2. Defaults to the model of the load generator")
+	perfCmd.Flags().StringVar(&warmUp, "warm-up", "", "(optional) Duration of the warm-up at the start of the test like 30s, its requests are left out of the results (fortio HTTP tests only)")
+	perfCmd.Flags().StringVar(&scenarioFile, "scenario", "", "(optional) JSON file holding the endpoints the requests are spread across according to their weights, like [{\"name\": \"item\", \"weight\": 20, \"url\": \"http://shop/item/{id}\", \"values\": [\"1\", \"2\"]}], each endpoint can set its method, headers, content_type and body")
+	perfCmd.Flags().StringVar(&perfProfile, "profile", "", "(optional) ID of a saved performance profile to run, the --url, --name, --mesh, --qps, --concurrent-requests, --duration, --load-generator, --slo and --guardrail flags override its settings when given")
 	rootCmd.AddCommand(perfCmd)
 }
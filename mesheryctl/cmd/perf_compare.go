@@ -0,0 +1,213 @@
+// Copyright 2019 The Meshery Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	compareBaseline  = ""
+	compareThreshold = 0.0
+	compareAlpha     = 0.0
+)
+
+// resultComparison is the part of the comparison returned by Meshery used by perf compare
+type resultComparison struct {
+	Baseline struct {
+		ID   string `json:"meshery_id"`
+		Name string `json:"name"`
+	} `json:"baseline"`
+	Candidates []struct {
+		Candidate struct {
+			ID   string `json:"meshery_id"`
+			Name string `json:"name"`
+		} `json:"candidate"`
+		Latencies []struct {
+			Metric       string  `json:"metric"`
+			Baseline     float64 `json:"baseline"`
+			Candidate    float64 `json:"candidate"`
+			DeltaPercent float64 `json:"delta_percent"`
+		} `json:"latencies"`
+		Throughput struct {
+			Baseline     float64 `json:"baseline"`
+			Candidate    float64 `json:"candidate"`
+			DeltaPercent float64 `json:"delta_percent"`
+		} `json:"throughput"`
+		ErrorRate struct {
+			Baseline  float64 `json:"baseline"`
+			Candidate float64 `json:"candidate"`
+		} `json:"error_rate"`
+		LatencyMean struct {
+			PValue float64 `json:"p_value"`
+		} `json:"latency_mean"`
+		HistogramShape struct {
+			PValue float64 `json:"p_value"`
+		} `json:"histogram_shape"`
+		Regression bool     `json:"regression"`
+		Reasons    []string `json:"reasons"`
+	} `json:"candidates"`
+	Regression bool `json:"regression"`
+}
+
+// mesheryRequest sends a request to Meshery along with the provider cookie and returns the response body
+func mesheryRequest(method, path string, params map[string][]string) ([]byte, error) {
+	return mesheryRequestWithBody(method, path, params, nil, "")
+}
+
+// mesheryRequestWithBody sends a request with the given body to Meshery along with the provider cookie
+// and returns the response body
+func mesheryRequestWithBody(method, path string, params map[string][]string, body io.Reader, contentType string) ([]byte, error) {
+	req, err := http.NewRequest(method, url+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	cookieConf := strings.SplitN(testCookie, "=", 2)
+	if len(cookieConf) != 2 {
+		return nil, fmt.Errorf("invalid cookie %q, expecting name=value", testCookie)
+	}
+	req.AddCookie(&http.Cookie{Name: cookieConf[0], Value: cookieConf[1]})
+	q := req.URL.Query()
+	for k, vs := range params {
+		for _, v := range vs {
+			q.Add(k, v)
+		}
+	}
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	return respBody, nil
+}
+
+// perfCompareCmd represents the perf compare command
+var perfCompareCmd = &cobra.Command{
+	Use:   "compare [result-id...]",
+	Short: "Compare results with a baseline",
+	Long: `Compare load test results with a baseline, which is either the named baseline given with --baseline or the first of the given results.
+Exits with a non-zero status when a regression is detected.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		params := map[string][]string{
+			"id": args,
+		}
+		if compareBaseline != "" {
+			params["baseline"] = []string{compareBaseline}
+		}
+		if compareThreshold > 0 {
+			params["threshold"] = []string{strconv.FormatFloat(compareThreshold, 'g', -1, 64)}
+		}
+		if compareAlpha > 0 {
+			params["alpha"] = []string{strconv.FormatFloat(compareAlpha, 'g', -1, 64)}
+		}
+		body, err := mesheryRequest(http.MethodGet, "/api/results/compare", params)
+		if err != nil {
+			log.Fatal("compare cmd: ", err)
+		}
+		comparison := &resultComparison{}
+		if err := json.Unmarshal(body, comparison); err != nil {
+			log.Fatal("compare cmd: unable to parse the comparison: ", err)
+		}
+
+		fmt.Printf("Baseline: %s %s\n", comparison.Baseline.ID, comparison.Baseline.Name)
+		for _, c := range comparison.Candidates {
+			fmt.Printf("\nResult: %s %s\n", c.Candidate.ID, c.Candidate.Name)
+			for _, l := range c.Latencies {
+				fmt.Printf("  %-8s %10.3fms -> %10.3fms  %+7.1f%%\n", l.Metric, l.Baseline, l.Candidate, l.DeltaPercent)
+			}
+			fmt.Printf("  %-8s %10.1f   -> %10.1f    %+7.1f%%\n", "qps", c.Throughput.Baseline, c.Throughput.Candidate, c.Throughput.DeltaPercent)
+			fmt.Printf("  %-8s %10.3f%%  -> %10.3f%%\n", "errors", c.ErrorRate.Baseline, c.ErrorRate.Candidate)
+			fmt.Printf("  p-values: latency mean %.4f, histogram shape %.4f\n", c.LatencyMean.PValue, c.HistogramShape.PValue)
+			if c.Regression {
+				fmt.Println("  REGRESSION:")
+				for _, r := range c.Reasons {
+					fmt.Println("   - " + r)
+				}
+			}
+		}
+		if comparison.Regression {
+			os.Exit(1)
+		}
+	},
+}
+
+// perfBaselineCmd represents the perf baseline command
+var perfBaselineCmd = &cobra.Command{
+	Use:   "baseline [name] [result-id]",
+	Short: "Manage the named baselines",
+	Long: `Name a result as a baseline when given a name and a result id, remove the named baseline with --delete,
+or list the named baselines when given no arguments.`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		deleteBaseline, _ := cmd.Flags().GetBool("delete")
+		var (
+			body []byte
+			err  error
+		)
+		switch {
+		case len(args) == 0:
+			body, err = mesheryRequest(http.MethodGet, "/api/results/baselines", nil)
+		case len(args) == 1 && deleteBaseline:
+			body, err = mesheryRequest(http.MethodDelete, "/api/results/baselines", map[string][]string{"name": {args[0]}})
+		case len(args) == 2 && !deleteBaseline:
+			body, err = mesheryRequest(http.MethodPost, "/api/results/baselines", map[string][]string{"name": {args[0]}, "id": {args[1]}})
+		default:
+			log.Fatal("baseline cmd: please provide either a name and a result id, or a name along with --delete")
+		}
+		if err != nil {
+			log.Fatal("baseline cmd: ", err)
+		}
+		baselines := map[string]string{}
+		if err := json.Unmarshal(body, &baselines); err != nil {
+			log.Fatal("baseline cmd: unable to parse the baselines: ", err)
+		}
+		for name, id := range baselines {
+			fmt.Printf("%s\t%s\n", name, id)
+		}
+	},
+}
+
+func init() {
+	perfCompareCmd.Flags().StringVar(&compareBaseline, "baseline", "", "(optional) Name of the baseline the results are compared with")
+	perfCompareCmd.Flags().Float64Var(&compareThreshold, "threshold", 0, "(optional) Change in percent above which a significant change is a regression, defaults to 10")
+	perfCompareCmd.Flags().Float64Var(&compareAlpha, "alpha", 0, "(optional) Significance level of the statistical tests, defaults to 0.05")
+	perfBaselineCmd.Flags().Bool("delete", false, "(optional) Remove the named baseline")
+	perfCmd.AddCommand(perfCompareCmd)
+	perfCmd.AddCommand(perfBaselineCmd)
+}
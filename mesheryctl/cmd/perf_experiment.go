@@ -0,0 +1,282 @@
+// Copyright 2019 The Meshery Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	experimentName        = ""
+	experimentDescription = ""
+	experimentProfiles    = []string{}
+	experimentMeshes      = []string{}
+	experimentQPS         = []string{}
+	experimentWait        = false
+)
+
+// experimentMesh is a mesh of an experiment, along with the url of the endpoint under test through that mesh
+type experimentMesh struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// experiment is the part of the experiment returned by Meshery used by perf experiment
+type experiment struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Runs   []struct {
+		Status string `json:"status"`
+	} `json:"runs"`
+}
+
+// experimentReport is the part of the experiment report returned by Meshery used by perf experiment
+type experimentReport struct {
+	Experiment experiment `json:"experiment"`
+	Runs       []struct {
+		ProfileName string  `json:"profile_name"`
+		Mesh        string  `json:"mesh"`
+		QPS         float64 `json:"qps"`
+		Status      string  `json:"status"`
+		ResultID    string  `json:"result_id"`
+		Error       string  `json:"error"`
+		Missing     string  `json:"missing"`
+		Summary     *struct {
+			ActualQPS     float64            `json:"actual_qps"`
+			ErrorRate     float64            `json:"error_rate"`
+			AvgMs         float64            `json:"avg_ms"`
+			PercentilesMs map[string]float64 `json:"percentiles_ms"`
+		} `json:"summary"`
+	} `json:"runs"`
+	Comparisons []struct {
+		ProfileName  string            `json:"profile_name"`
+		QPS          float64           `json:"qps"`
+		BaselineMesh string            `json:"baseline_mesh"`
+		Comparison   *resultComparison `json:"comparison"`
+	} `json:"comparisons"`
+}
+
+// finished tells whether all the runs of the experiment are over
+func (e *experiment) finished() bool {
+	return e.Status != "running" && e.Status != "pending"
+}
+
+// formatQPS formats a load level, 0 being the maximum throughput
+func formatQPS(qps float64) string {
+	if qps == 0 {
+		return "max"
+	}
+	return fmt.Sprintf("%g", qps)
+}
+
+// perfExperimentCmd represents the perf experiment command
+var perfExperimentCmd = &cobra.Command{
+	Use:   "experiment",
+	Short: "Run experiments comparing meshes",
+	Long: `Run experiments, ie. a matrix of performance profiles, meshes and load levels executed sequentially,
+whose results are grouped and compared in an aggregated report.`,
+}
+
+// perfExperimentRunCmd represents the perf experiment run command
+var perfExperimentRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Start an experiment",
+	Long: `Start an experiment running each of the given performance profiles against each of the given meshes at each of
+the given load levels. A mesh is given as name=url, where the url is the endpoint under test through that mesh,
+use "none" as the name for the runs without a mesh. With --wait, the command waits for the experiment to finish
+and prints its report, exiting with a non-zero status when a run failed or a mesh regressed.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if experimentName == "" || len(experimentProfiles) == 0 {
+			log.Fatal("experiment cmd: please provide a name with --name and at least a performance profile with --profile")
+		}
+		meshes := []experimentMesh{}
+		for _, m := range experimentMeshes {
+			parts := strings.SplitN(m, "=", 2)
+			mesh := experimentMesh{Name: parts[0]}
+			if strings.EqualFold(mesh.Name, "none") {
+				mesh.Name = ""
+			}
+			if len(parts) == 2 {
+				mesh.URL = parts[1]
+			}
+			meshes = append(meshes, mesh)
+		}
+		levels := []float64{}
+		for _, q := range experimentQPS {
+			qps, err := strconv.ParseFloat(q, 64)
+			if err != nil || qps < 0 {
+				log.Fatalf("experiment cmd: invalid load level %q", q)
+			}
+			levels = append(levels, qps)
+		}
+		data, err := json.Marshal(map[string]interface{}{
+			"name":        experimentName,
+			"description": experimentDescription,
+			"profiles":    experimentProfiles,
+			"meshes":      meshes,
+			"qps":         levels,
+		})
+		if err != nil {
+			log.Fatal("experiment cmd: ", err)
+		}
+		body, err := mesheryRequestWithBody(http.MethodPost, "/api/experiments", nil, bytes.NewReader(data), "application/json")
+		if err != nil {
+			log.Fatal("experiment cmd: ", err)
+		}
+		exp := &experiment{}
+		if err := json.Unmarshal(body, exp); err != nil {
+			log.Fatal("experiment cmd: unable to parse the experiment: ", err)
+		}
+		fmt.Printf("Started the experiment %s with %d runs\n", exp.ID, len(exp.Runs))
+		if !experimentWait {
+			return
+		}
+
+		for !exp.finished() {
+			time.Sleep(5 * time.Second)
+			body, err := mesheryRequest(http.MethodGet, "/api/experiments/"+exp.ID, nil)
+			if err != nil {
+				log.Fatal("experiment cmd: ", err)
+			}
+			if err := json.Unmarshal(body, exp); err != nil {
+				log.Fatal("experiment cmd: unable to parse the experiment: ", err)
+			}
+		}
+		if !printExperimentReport(exp.ID) || exp.Status != "completed" {
+			os.Exit(1)
+		}
+	},
+}
+
+// perfExperimentReportCmd represents the perf experiment report command
+var perfExperimentReportCmd = &cobra.Command{
+	Use:   "report [experiment-id]",
+	Short: "Print the report of an experiment",
+	Long: `Print the figures of the runs of an experiment and the comparison of its meshes with the first one for each
+performance profile and load level. Exits with a non-zero status when a mesh regressed.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !printExperimentReport(args[0]) {
+			os.Exit(1)
+		}
+	},
+}
+
+// perfExperimentCancelCmd represents the perf experiment cancel command
+var perfExperimentCancelCmd = &cobra.Command{
+	Use:   "cancel [experiment-id]",
+	Short: "Cancel an experiment",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if _, err := mesheryRequest(http.MethodPost, "/api/experiments/"+args[0]+"/cancel", nil); err != nil {
+			log.Fatal("experiment cmd: ", err)
+		}
+		fmt.Printf("Cancelled the experiment %s\n", args[0])
+	},
+}
+
+// printExperimentReport prints the report of the experiment and returns false when a mesh regressed
+func printExperimentReport(id string) bool {
+	body, err := mesheryRequest(http.MethodGet, "/api/experiments/"+id+"/report", nil)
+	if err != nil {
+		log.Fatal("experiment cmd: ", err)
+	}
+	report := &experimentReport{}
+	if err := json.Unmarshal(body, report); err != nil {
+		log.Fatal("experiment cmd: unable to parse the report: ", err)
+	}
+
+	fmt.Printf("Experiment: %s %s (%s)\n", report.Experiment.ID, report.Experiment.Name, report.Experiment.Status)
+	if report.Experiment.Error != "" {
+		fmt.Println("  " + report.Experiment.Error)
+	}
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tMESH\tQPS\tSTATUS\tACTUAL QPS\tAVG MS\tP50 MS\tP99 MS\tERRORS\tRESULT")
+	for _, r := range report.Runs {
+		mesh := r.Mesh
+		if mesh == "" {
+			mesh = "none"
+		}
+		if r.Summary == nil {
+			reason := r.Error
+			if reason == "" {
+				reason = r.Missing
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\t\t\t\t\t%s\n", r.ProfileName, mesh, formatQPS(r.QPS), r.Status, reason)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.1f\t%.3f\t%.3f\t%.3f\t%.3f%%\t%s\n", r.ProfileName, mesh, formatQPS(r.QPS), r.Status,
+			r.Summary.ActualQPS, r.Summary.AvgMs, r.Summary.PercentilesMs["p50"], r.Summary.PercentilesMs["p99"],
+			r.Summary.ErrorRate, r.ResultID)
+	}
+	_ = w.Flush()
+
+	ok := true
+	for _, c := range report.Comparisons {
+		if c.Comparison == nil {
+			continue
+		}
+		baseline := c.BaselineMesh
+		if baseline == "" {
+			baseline = "none"
+		}
+		fmt.Printf("\n%s at %s qps, compared with %s:\n", c.ProfileName, formatQPS(c.QPS), baseline)
+		for _, cand := range c.Comparison.Candidates {
+			fmt.Printf("  %s\n", cand.Candidate.Name)
+			for _, l := range cand.Latencies {
+				fmt.Printf("    %-8s %10.3fms -> %10.3fms  %+7.1f%%\n", l.Metric, l.Baseline, l.Candidate, l.DeltaPercent)
+			}
+			fmt.Printf("    %-8s %10.1f   -> %10.1f    %+7.1f%%\n", "qps", cand.Throughput.Baseline, cand.Throughput.Candidate, cand.Throughput.DeltaPercent)
+			if cand.Regression {
+				fmt.Println("    REGRESSION:")
+				for _, r := range cand.Reasons {
+					fmt.Println("     - " + r)
+				}
+			}
+		}
+		if c.Comparison.Regression {
+			ok = false
+		}
+	}
+	return ok
+}
+
+func init() {
+	perfExperimentRunCmd.Flags().StringVar(&experimentName, "name", "", "Name of the experiment")
+	perfExperimentRunCmd.Flags().StringVar(&experimentDescription, "description", "", "(optional) Description of the experiment")
+	perfExperimentRunCmd.Flags().StringSliceVar(&experimentProfiles, "profile", nil, "ID of a performance profile to run, can be repeated")
+	perfExperimentRunCmd.Flags().StringArrayVar(&experimentMeshes, "mesh", nil, "(optional) Mesh to run the profiles against as name=url, none for no mesh, can be repeated, defaults to the mesh of each profile")
+	perfExperimentRunCmd.Flags().StringSliceVar(&experimentQPS, "qps", nil, "(optional) Load levels in queries per second, 0 for max, defaults to the qps of each profile")
+	perfExperimentRunCmd.Flags().BoolVar(&experimentWait, "wait", false, "(optional) Wait for the experiment to finish and print its report")
+	perfExperimentCmd.AddCommand(perfExperimentRunCmd)
+	perfExperimentCmd.AddCommand(perfExperimentReportCmd)
+	perfExperimentCmd.AddCommand(perfExperimentCancelCmd)
+	perfCmd.AddCommand(perfExperimentCmd)
+}
@@ -0,0 +1,103 @@
+// Copyright 2019 The Meshery Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat = ""
+	exportOutput = ""
+)
+
+// perfExportCmd represents the perf export command
+var perfExportCmd = &cobra.Command{
+	Use:   "export [result-id]",
+	Short: "Export results",
+	Long: `Export a result in the given format: yaml (SMP benchmark spec), json, csv (latency percentiles and histogram buckets),
+hdr (HdrHistogram log) or junit (SLO assertions). Without a result id, the results matching the filters are exported
+as a zip archive holding a file per result.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var (
+			body []byte
+			err  error
+			name string
+		)
+		if len(args) == 1 {
+			format := exportFormat
+			if format == "" {
+				format = "yaml"
+			}
+			body, err = mesheryRequest(http.MethodGet, "/api/result", map[string][]string{"id": {args[0]}, "format": {format}})
+		} else {
+			if exportOutput == "" {
+				log.Fatal("export cmd: please provide the file the archive is written to with --output")
+			}
+			params := map[string][]string{}
+			if exportFormat != "" {
+				params["format"] = []string{exportFormat}
+			}
+			for _, filter := range []struct{ flag, param string }{
+				{"search", "search"},
+				{"mesh", "mesh"},
+				{"load-generator", "loadGenerator"},
+				{"from", "from"},
+				{"to", "to"},
+				{"profile", "profile"},
+			} {
+				if v, _ := cmd.Flags().GetString(filter.flag); v != "" {
+					params[filter.param] = []string{v}
+				}
+			}
+			name = "results archive"
+			body, err = mesheryRequest(http.MethodGet, "/api/results/export", params)
+		}
+		if err != nil {
+			log.Fatal("export cmd: ", err)
+		}
+
+		if exportOutput == "" {
+			_, _ = os.Stdout.Write(body)
+			return
+		}
+		if err := ioutil.WriteFile(exportOutput, body, 0644); err != nil {
+			log.Fatal("export cmd: ", err)
+		}
+		if name == "" {
+			name = "result " + args[0]
+		}
+		fmt.Printf("Exported the %s to %s\n", name, exportOutput)
+	},
+}
+
+func init() {
+	perfExportCmd.Flags().StringVar(&exportFormat, "format", "", "(optional) Export format: yaml, json, csv, hdr or junit, defaults to yaml for a result and json for an archive")
+	perfExportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "File the export is written to, the standard output by default for a result")
+	perfExportCmd.Flags().String("search", "", "(optional) Export the results whose name, mesh or url contain the given terms")
+	perfExportCmd.Flags().String("mesh", "", "(optional) Export the results of the given mesh")
+	perfExportCmd.Flags().String("load-generator", "", "(optional) Export the results of the given load generator")
+	perfExportCmd.Flags().String("from", "", "(optional) Export the results of the tests started from the given date or RFC 3339 time")
+	perfExportCmd.Flags().String("to", "", "(optional) Export the results of the tests started until the given date or RFC 3339 time")
+	perfExportCmd.Flags().String("profile", "", "(optional) Export the results of the given performance profile")
+	perfCmd.AddCommand(perfExportCmd)
+}
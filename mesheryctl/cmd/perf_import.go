@@ -0,0 +1,75 @@
+// Copyright 2019 The Meshery Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importMesh = ""
+	importName = ""
+)
+
+// perfImportCmd represents the perf import command
+var perfImportCmd = &cobra.Command{
+	Use:   "import [file...]",
+	Short: "Import results",
+	Long: `Import the results of SMP benchmark specs in yaml, eg. produced by other tools or exported by another Meshery instance.
+Each file holds one or more specs, either as separate yaml documents or as a list.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		params := map[string][]string{}
+		if importMesh != "" {
+			params["mesh"] = []string{importMesh}
+		}
+		if importName != "" {
+			params["name"] = []string{importName}
+		}
+		for _, file := range args {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				log.Fatal("import cmd: ", err)
+			}
+			body, err := mesheryRequestWithBody(http.MethodPost, "/api/results/import", params, bytes.NewReader(data), "application/yaml")
+			if err != nil {
+				log.Fatalf("import cmd: unable to import %s: %v", file, err)
+			}
+			imported := []map[string]string{}
+			if err := json.Unmarshal(body, &imported); err != nil {
+				log.Fatal("import cmd: ", err)
+			}
+			for _, result := range imported {
+				fmt.Printf("Imported %s as the result %s %s\n", file, result["id"], result["name"])
+				if result["warning"] != "" {
+					fmt.Printf("Warning: %s\n", result["warning"])
+				}
+			}
+		}
+	},
+}
+
+func init() {
+	perfImportCmd.Flags().StringVar(&importMesh, "mesh", "", "(optional) Mesh of the imported results")
+	perfImportCmd.Flags().StringVar(&importName, "name", "", "(optional) Name of the imported results, defaults to the profile of the specs")
+	perfCmd.AddCommand(perfImportCmd)
+}
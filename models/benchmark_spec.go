@@ -1,11 +1,40 @@
 package models
 
-import "time"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"fortio.org/fortio/fgrpc"
+	"fortio.org/fortio/fhttp"
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
 
 // Environment - represents a kubernetes environment
 type Environment struct {
 	Kubernetes string `yaml:"kubernetes,omitempty"`
 	NodeCount  int    `yaml:"node_count,omitempty"`
+	// Nodes are the nodes of the cluster as introspected when the test was run
+	Nodes []*EnvironmentNode `yaml:"nodes,omitempty"`
+}
+
+// EnvironmentNode - represents a node of a kubernetes environment
+type EnvironmentNode struct {
+	Hostname         string `yaml:"hostname,omitempty"`
+	OSImage          string `yaml:"os_image,omitempty"`
+	Architecture     string `yaml:"architecture,omitempty"`
+	KubeletVersion   string `yaml:"kubelet_version,omitempty"`
+	ContainerRuntime string `yaml:"container_runtime,omitempty"`
+	CPU              string `yaml:"cpu,omitempty"`
+	Memory           string `yaml:"memory,omitempty"`
 }
 
 // MeshConfig - represents a service mesh config
@@ -23,6 +52,40 @@ type MeshClientConfig struct {
 	Connections int          `yaml:"connections,omitempty"`
 	Rps         float64      `yaml:"rps,omitempty"`
 	LatenciesMs *LatenciesMs `yaml:"latencies_ms,omitempty"`
+	Request     *HTTPRequest `yaml:"request,omitempty"`
+	GRPC        *GRPCClient  `yaml:"grpc,omitempty"`
+	TLS         *TLSConfig   `yaml:"tls,omitempty"`
+
+	// LoadModel is either closed or open, WarmUp is the duration of the warm-up left out of the results like 30s
+	LoadModel string `yaml:"load_model,omitempty"`
+	WarmUp    string `yaml:"warm_up,omitempty"`
+}
+
+// GRPCClient - represents the settings of a gRPC load test client, used when the protocol is grpc
+type GRPCClient struct {
+	Ping          bool   `yaml:"ping,omitempty"`
+	PingDelay     string `yaml:"ping_delay,omitempty"`
+	HealthService string `yaml:"health_service,omitempty"`
+	Streams       int    `yaml:"streams,omitempty"`
+}
+
+// TLSConfig - holds the PEM encoded TLS material used by the load test client
+type TLSConfig struct {
+	CACert     string `yaml:"ca_cert,omitempty"`
+	Cert       string `yaml:"cert,omitempty"`
+	Key        string `yaml:"key,omitempty"`
+	ServerName string `yaml:"server_name,omitempty"`
+}
+
+// HTTPRequest - represents the customizations of the requests sent by the load test client
+type HTTPRequest struct {
+	Method           string            `yaml:"method,omitempty"`
+	Headers          map[string]string `yaml:"headers,omitempty"`
+	ContentType      string            `yaml:"content_type,omitempty"`
+	Body             string            `yaml:"body,omitempty"`
+	HTTP10           bool              `yaml:"http10,omitempty"`
+	Compression      bool              `yaml:"compression,omitempty"`
+	DisableKeepAlive bool              `yaml:"disable_keep_alive,omitempty"`
 }
 
 // LatenciesMs - represents a collection of important latencies
@@ -102,8 +165,13 @@ type Metrics struct {
 	IndividualWorkload *Workload         `yaml:"individual_workload_1,omitempty"`
 }
 
+// SMPVersion - version of the benchmark specs written by MesheryResult.ConvertToSpec, the specs exported before
+// it was set held their latencies in seconds instead of milliseconds
+const SMPVersion = "v1"
+
 // BenchmarkSpec - represents SMPS
 type BenchmarkSpec struct {
+	SMPVersion   string            `yaml:"smp_version,omitempty"`
 	StartTime    time.Time         `yaml:"start_time,omitempty"`
 	EndTime      time.Time         `yaml:"end_time,omitempty"`
 	MeshBuild    string            `yaml:"mesh_build,omitempty"`
@@ -119,6 +187,231 @@ type BenchmarkSpec struct {
 	Metrics      *Metrics          `yaml:"metrics,omitempty"`
 }
 
-// func (b *BenchmarkSpec) ConvertToMesheryResult() *MesheryResult {
+// smpResultKey is the key of the runner results holding the fields of an imported benchmark spec
+// which have no counterpart in the runner results, so that they are kept when it is converted back
+const smpResultKey = "smp"
 
-// }
+// smpResult holds the fields of an imported benchmark spec kept in the runner results
+type smpResult struct {
+	MeshBuild    string      `json:"mesh_build,omitempty"`
+	ProxyBuild   string      `json:"proxy_build,omitempty"`
+	ExpGroupUUID string      `json:"exp_group_uuid,omitempty"`
+	ExpUUID      string      `json:"exp_uuid,omitempty"`
+	DetailsURI   string      `json:"details_uri,omitempty"`
+	Config       *MeshConfig `json:"config,omitempty"`
+	Metrics      *Metrics    `json:"metrics,omitempty"`
+}
+
+// SetSpecMetrics - sets the metrics of the benchmark spec the result is converted to
+func (m *MesheryResult) SetSpecMetrics(metrics *Metrics) error {
+	return m.updateSMPResult(func(smp *smpResult) {
+		smp.Metrics = metrics
+	})
+}
+
+// SetSpecMesh - sets the builds and the config of the mesh of the benchmark spec the result is converted to
+func (m *MesheryResult) SetSpecMesh(meshBuild, proxyBuild string, config *MeshConfig) error {
+	return m.updateSMPResult(func(smp *smpResult) {
+		smp.MeshBuild = meshBuild
+		smp.ProxyBuild = proxyBuild
+		smp.Config = config
+	})
+}
+
+func (m *MesheryResult) updateSMPResult(update func(*smpResult)) error {
+	smp := &smpResult{}
+	if smpI, ok := m.Result[smpResultKey]; ok {
+		resJ, err := json.Marshal(smpI)
+		if err == nil {
+			err = json.Unmarshal(resJ, smp)
+		}
+		if err != nil {
+			return errors.Wrap(err, "unable to update the benchmark spec of the result")
+		}
+	}
+	update(smp)
+	if m.Result == nil {
+		m.Result = map[string]interface{}{}
+	}
+	m.Result[smpResultKey] = smp
+	return nil
+}
+
+// ParseBenchmarkSpecs parses the given yaml which holds one or more benchmark specs, either as a stream of documents
+// or as sequences of benchmark specs
+func ParseBenchmarkSpecs(data []byte) ([]*BenchmarkSpec, error) {
+	specs := []*BenchmarkSpec{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for doc := 1; ; doc++ {
+		var raw interface{}
+		err := dec.Decode(&raw)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse the document %d", doc)
+		}
+		if raw == nil {
+			continue
+		}
+		items, ok := raw.([]interface{})
+		if !ok {
+			items = []interface{}{raw}
+		}
+		for _, item := range items {
+			// the document is decoded again for its fields to be checked against the ones of the spec
+			itemData, err := yaml.Marshal(item)
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to parse the document %d", doc)
+			}
+			spec := &BenchmarkSpec{}
+			if err := yaml.UnmarshalStrict(itemData, spec); err != nil {
+				return nil, errors.Wrapf(err, "unable to parse the document %d", doc)
+			}
+			specs = append(specs, spec)
+		}
+	}
+	if len(specs) == 0 {
+		return nil, errors.New("no benchmark spec found")
+	}
+	return specs, nil
+}
+
+// LatenciesInSeconds - tells whether the latencies of the spec were exported in seconds by a Meshery release which
+// did not set the version of its specs. Such specs are recognized by their max latency below a millisecond
+func (b *BenchmarkSpec) LatenciesInSeconds() bool {
+	if b.SMPVersion != "" || b.Client == nil || b.Client.LatenciesMs == nil {
+		return false
+	}
+	return b.Client.LatenciesMs.Max > 0 && b.Client.LatenciesMs.Max < 1
+}
+
+// ConvertToMesheryResult - converts SMP to meshery result, the inverse of MesheryResult.ConvertToSpec.
+// The latency histogram is limited to the latencies of the spec and its count is estimated from the rps
+// and the duration of the test
+func (b *BenchmarkSpec) ConvertToMesheryResult() (*MesheryResult, error) {
+	if b.EndpointURL == "" {
+		return nil, errors.New("the benchmark spec has no endpoint_url")
+	}
+	if b.StartTime.IsZero() {
+		return nil, errors.New("the benchmark spec has no start_time")
+	}
+	if b.EndTime.IsZero() {
+		return nil, errors.New("the benchmark spec has no end_time")
+	}
+	if b.EndTime.Before(b.StartTime) {
+		return nil, errors.New("the end_time of the benchmark spec is before its start_time")
+	}
+	client := b.Client
+	if client == nil {
+		client = &MeshClientConfig{}
+	}
+
+	duration := b.EndTime.Sub(b.StartTime)
+	count := int64(math.Round(client.Rps * duration.Seconds()))
+	hist := &stats.HistogramData{Count: count}
+	if l := client.LatenciesMs; l != nil {
+		// the latencies of the runner results are in seconds
+		scale := 1000.0
+		if b.LatenciesInSeconds() {
+			scale = 1
+		}
+		hist.Min = l.Min / scale
+		hist.Max = l.Max / scale
+		hist.Avg = l.Average / scale
+		hist.Sum = hist.Avg * float64(count)
+		for _, p := range []struct{ percentile, value float64 }{{50, l.P50}, {90, l.P90}, {99, l.P99}} {
+			if p.value > 0 {
+				hist.Percentiles = append(hist.Percentiles, stats.Percentile{Percentile: p.percentile, Value: p.value / scale})
+			}
+		}
+	}
+	rr := periodic.RunnerResults{
+		Labels:            b.Profile + " -_- " + b.EndpointURL,
+		StartTime:         b.StartTime,
+		ActualDuration:    duration,
+		ActualQPS:         client.Rps,
+		NumThreads:        client.Connections,
+		DurationHistogram: hist,
+	}
+
+	var results interface{}
+	switch strings.ToLower(client.Protocol) {
+	case "", "http", "https":
+		rr.RunType = "HTTP"
+		results = &fhttp.HTTPRunnerResults{
+			RunnerResults: rr,
+			URL:           b.EndpointURL,
+		}
+	case "grpc":
+		grpcResults := &fgrpc.GRPCRunnerResults{
+			RunnerResults: rr,
+			Destination:   b.EndpointURL,
+		}
+		grpcResults.RunType = "GRPC Health"
+		if client.GRPC != nil {
+			grpcResults.Ping = client.GRPC.Ping
+			grpcResults.Streams = client.GRPC.Streams
+			if client.GRPC.Ping {
+				grpcResults.RunType = "GRPC Ping"
+			}
+		}
+		results = grpcResults
+	default:
+		return nil, fmt.Errorf("unable to convert the results of the protocol %q to Meshery result", client.Protocol)
+	}
+
+	resJ, err := json.Marshal(results)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to convert the benchmark spec to Meshery result")
+	}
+	m := &MesheryResult{
+		ID:     uuid.FromStringOrNil(b.ExpUUID),
+		Name:   b.Profile,
+		Result: map[string]interface{}{},
+	}
+	if err := json.Unmarshal(resJ, &m.Result); err != nil {
+		return nil, errors.Wrap(err, "unable to convert the benchmark spec to Meshery result")
+	}
+	if b.Env != nil && (b.Env.Kubernetes != "" || b.Env.NodeCount > 0 || len(b.Env.Nodes) > 0) {
+		k8s := map[string]interface{}{
+			"server_version": b.Env.Kubernetes,
+			"node_count":     b.Env.NodeCount,
+		}
+		if len(b.Env.Nodes) > 0 {
+			nodes := []*K8SNode{}
+			for _, n := range b.Env.Nodes {
+				nodes = append(nodes, &K8SNode{
+					HostName:                n.Hostname,
+					OSImage:                 n.OSImage,
+					Architecture:            n.Architecture,
+					KubeletVersion:          n.KubeletVersion,
+					ContainerRuntimeVersion: n.ContainerRuntime,
+					CapacityCPU:             n.CPU,
+					CapacityMemory:          n.Memory,
+				})
+			}
+			k8s["nodes"] = nodes
+		}
+		m.Result["kubernetes"] = k8s
+	}
+	if client.Internal {
+		m.Result[InClusterLoadTestResultKey] = map[string]interface{}{}
+	}
+	if client.LoadModel != "" {
+		m.Result[LoadModelResultKey] = client.LoadModel
+	}
+	if client.WarmUp != "" {
+		m.Result[WarmUpResultKey] = client.WarmUp
+	}
+	m.Result[smpResultKey] = &smpResult{
+		MeshBuild:    b.MeshBuild,
+		ProxyBuild:   b.ProxyBuild,
+		ExpGroupUUID: b.ExpGroupUUID,
+		ExpUUID:      b.ExpUUID,
+		DetailsURI:   b.DetailsURI,
+		Config:       b.Config,
+		Metrics:      b.Metrics,
+	}
+	return m, nil
+}
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/prologic/bitcask"
+	"github.com/sirupsen/logrus"
+)
+
+// BitCaskExperimentPersister assists with persisting experiments in a Bitcask store
+type BitCaskExperimentPersister struct {
+	fileName string
+	db       *bitcask.Bitcask
+}
+
+// NewBitCaskExperimentPersister creates a new BitCaskExperimentPersister instance
+func NewBitCaskExperimentPersister(folderName string) (*BitCaskExperimentPersister, error) {
+	_, err := os.Stat(folderName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = os.MkdirAll(folderName, os.ModePerm)
+			if err != nil {
+				logrus.Errorf("Unable to create the directory '%s' due to error: %v.", folderName, err)
+				return nil, err
+			}
+		} else {
+			logrus.Errorf("Unable to find/stat the folder '%s': %v,", folderName, err)
+			return nil, err
+		}
+	}
+
+	fileName := path.Join(folderName, "experimentDB")
+	db, err := bitcask.Open(fileName, bitcask.WithSync(true))
+	if err != nil {
+		logrus.Errorf("Unable to open database: %v.", err)
+		return nil, err
+	}
+	bd := &BitCaskExperimentPersister{
+		fileName: fileName,
+		db:       db,
+	}
+	return bd, nil
+}
+
+// GetExperiments - gets all the persisted experiments
+func (s *BitCaskExperimentPersister) GetExperiments() ([]*Experiment, error) {
+	if s.db == nil {
+		return nil, errors.New("connection to DB does not exist")
+	}
+
+RETRY:
+	locked, err := s.db.TryRLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain read lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	experiments := []*Experiment{}
+	for k := range s.db.Keys() {
+		data, err := s.db.Get(k)
+		if err != nil {
+			err = errors.Wrapf(err, "Unable to read data from bitcask store")
+			logrus.Error(err)
+			return nil, err
+		}
+		experiment := &Experiment{}
+		if err := json.Unmarshal(data, experiment); err != nil {
+			err = errors.Wrapf(err, "Unable to unmarshal data.")
+			logrus.Error(err)
+			return nil, err
+		}
+		experiments = append(experiments, experiment)
+	}
+	return experiments, nil
+}
+
+// GetExperiment - gets the experiment with the given ID
+func (s *BitCaskExperimentPersister) GetExperiment(id uuid.UUID) (*Experiment, error) {
+	if s.db == nil {
+		return nil, errors.New("connection to DB does not exist")
+	}
+
+RETRY:
+	locked, err := s.db.TryRLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain read lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	if !s.db.Has(id.Bytes()) {
+		return nil, errors.New("given key not found")
+	}
+	data, err := s.db.Get(id.Bytes())
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to fetch experiment data")
+		logrus.Error(err)
+		return nil, err
+	}
+	experiment := &Experiment{}
+	if err := json.Unmarshal(data, experiment); err != nil {
+		err = errors.Wrapf(err, "Unable to unmarshal experiment data.")
+		logrus.Error(err)
+		return nil, err
+	}
+	return experiment, nil
+}
+
+// WriteExperiment persists the experiment
+func (s *BitCaskExperimentPersister) WriteExperiment(experiment *Experiment) error {
+	if s.db == nil {
+		return errors.New("connection to DB does not exist")
+	}
+	if experiment == nil || experiment.ID == uuid.Nil {
+		return errors.New("given experiment is invalid")
+	}
+	data, err := json.Marshal(experiment)
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to marshal experiment data.")
+		logrus.Error(err)
+		return err
+	}
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	if err := s.db.Put(experiment.ID.Bytes(), data); err != nil {
+		err = errors.Wrapf(err, "Unable to persist experiment data.")
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// DeleteExperiment removes the experiment with the given ID
+func (s *BitCaskExperimentPersister) DeleteExperiment(id uuid.UUID) error {
+	if s.db == nil {
+		return errors.New("connection to DB does not exist")
+	}
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	if err := s.db.Delete(id.Bytes()); err != nil {
+		err = errors.Wrapf(err, "Unable to delete experiment data.")
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// CloseExperimentPersister closes the bitcask store
+func (s *BitCaskExperimentPersister) CloseExperimentPersister() {
+	if s.db == nil {
+		return
+	}
+	_ = s.db.Close()
+}
@@ -0,0 +1,240 @@
+package models
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/prologic/bitcask"
+	"github.com/sirupsen/logrus"
+)
+
+// BitCaskPerformanceProfilesPersister assists with persisting performance profiles in a Bitcask store,
+// the latest version of a profile is stored under its ID and every version under <ID>/<version>
+type BitCaskPerformanceProfilesPersister struct {
+	fileName string
+	db       *bitcask.Bitcask
+}
+
+// NewBitCaskPerformanceProfilesPersister creates a new BitCaskPerformanceProfilesPersister instance
+func NewBitCaskPerformanceProfilesPersister(folderName string) (*BitCaskPerformanceProfilesPersister, error) {
+	_, err := os.Stat(folderName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = os.MkdirAll(folderName, os.ModePerm)
+			if err != nil {
+				logrus.Errorf("Unable to create the directory '%s' due to error: %v.", folderName, err)
+				return nil, err
+			}
+		} else {
+			logrus.Errorf("Unable to find/stat the folder '%s': %v,", folderName, err)
+			return nil, err
+		}
+	}
+
+	fileName := path.Join(folderName, "performanceProfilesDB")
+	db, err := bitcask.Open(fileName, bitcask.WithSync(true))
+	if err != nil {
+		logrus.Errorf("Unable to open database: %v.", err)
+		return nil, err
+	}
+	bd := &BitCaskPerformanceProfilesPersister{
+		fileName: fileName,
+		db:       db,
+	}
+	return bd, nil
+}
+
+func performanceProfileVersionKey(id uuid.UUID, version int) []byte {
+	return []byte(id.String() + "/" + strconv.Itoa(version))
+}
+
+// GetPerformanceProfiles - gets the latest version of the profiles whose name contains search, most recently updated first
+func (s *BitCaskPerformanceProfilesPersister) GetPerformanceProfiles(search string) ([]*PerformanceProfile, error) {
+	if s.db == nil {
+		return nil, errors.New("connection to DB does not exist")
+	}
+
+RETRY:
+	locked, err := s.db.TryRLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain read lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	search = strings.ToLower(search)
+	profiles := []*PerformanceProfile{}
+	for k := range s.db.Keys() {
+		if strings.Contains(string(k), "/") {
+			continue
+		}
+		data, err := s.db.Get(k)
+		if err != nil {
+			err = errors.Wrapf(err, "Unable to read data from bitcask store")
+			logrus.Error(err)
+			return nil, err
+		}
+		profile := &PerformanceProfile{}
+		if err := json.Unmarshal(data, profile); err != nil {
+			err = errors.Wrapf(err, "Unable to unmarshal data.")
+			logrus.Error(err)
+			return nil, err
+		}
+		if search == "" || strings.Contains(strings.ToLower(profile.Name), search) {
+			profiles = append(profiles, profile)
+		}
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].UpdatedAt.After(profiles[j].UpdatedAt)
+	})
+	return profiles, nil
+}
+
+// GetPerformanceProfile - gets the given version of a profile, the latest one when version is 0
+func (s *BitCaskPerformanceProfilesPersister) GetPerformanceProfile(id uuid.UUID, version int) (*PerformanceProfile, error) {
+	if s.db == nil {
+		return nil, errors.New("connection to DB does not exist")
+	}
+
+RETRY:
+	locked, err := s.db.TryRLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain read lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	return s.getPerformanceProfile(id, version)
+}
+
+// getPerformanceProfile must be called with the lock held
+func (s *BitCaskPerformanceProfilesPersister) getPerformanceProfile(id uuid.UUID, version int) (*PerformanceProfile, error) {
+	key := []byte(id.String())
+	if version > 0 {
+		key = performanceProfileVersionKey(id, version)
+	}
+	if !s.db.Has(key) {
+		return nil, errors.New("given key not found")
+	}
+	data, err := s.db.Get(key)
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to fetch performance profile data")
+		logrus.Error(err)
+		return nil, err
+	}
+	profile := &PerformanceProfile{}
+	if err := json.Unmarshal(data, profile); err != nil {
+		err = errors.Wrapf(err, "Unable to unmarshal performance profile data.")
+		logrus.Error(err)
+		return nil, err
+	}
+	return profile, nil
+}
+
+// WritePerformanceProfile persists the profile as a new version
+func (s *BitCaskPerformanceProfilesPersister) WritePerformanceProfile(profile *PerformanceProfile) error {
+	if s.db == nil {
+		return errors.New("connection to DB does not exist")
+	}
+	if profile == nil || profile.ID == uuid.Nil {
+		return errors.New("given performance profile is invalid")
+	}
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	profile.Version = 1
+	if latest, err := s.getPerformanceProfile(profile.ID, 0); err == nil {
+		profile.Version = latest.Version + 1
+		profile.CreatedAt = latest.CreatedAt
+	}
+	data, err := json.Marshal(profile)
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to marshal performance profile data.")
+		logrus.Error(err)
+		return err
+	}
+	for _, key := range [][]byte{performanceProfileVersionKey(profile.ID, profile.Version), []byte(profile.ID.String())} {
+		if err := s.db.Put(key, data); err != nil {
+			err = errors.Wrapf(err, "Unable to persist performance profile data.")
+			logrus.Error(err)
+			return err
+		}
+	}
+	return nil
+}
+
+// DeletePerformanceProfile removes all the versions of a profile
+func (s *BitCaskPerformanceProfilesPersister) DeletePerformanceProfile(id uuid.UUID) error {
+	if s.db == nil {
+		return errors.New("connection to DB does not exist")
+	}
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	if !s.db.Has([]byte(id.String())) {
+		return errors.New("given key not found")
+	}
+	keys := [][]byte{[]byte(id.String())}
+	if err := s.db.Scan([]byte(id.String()+"/"), func(key []byte) error {
+		keys = append(keys, key)
+		return nil
+	}); err != nil {
+		err = errors.Wrapf(err, "Unable to list the performance profile versions")
+		logrus.Error(err)
+		return err
+	}
+	for _, key := range keys {
+		if err := s.db.Delete(key); err != nil {
+			err = errors.Wrapf(err, "Unable to delete performance profile data.")
+			logrus.Error(err)
+			return err
+		}
+	}
+	return nil
+}
+
+// ClosePerformanceProfilesPersister closes the bitcask store
+func (s *BitCaskPerformanceProfilesPersister) ClosePerformanceProfilesPersister() {
+	if s.db == nil {
+		return
+	}
+	_ = s.db.Close()
+}
@@ -2,9 +2,10 @@ package models
 
 import (
 	"encoding/json"
-	"fmt"
 	"os"
 	"path"
+	"sync"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/pkg/errors"
@@ -12,12 +13,24 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// BitCaskResultsPersister assists with persisting session in a Bitcask store
+// BitCaskResultsPersister assists with persisting session in a Bitcask store,
+// a summary of every result is kept in a separate store to index the results
 type BitCaskResultsPersister struct {
 	fileName string
 	db       *bitcask.Bitcask
+
+	indexDB *bitcask.Bitcask
+	index   *resultsIndex
+
+	// compactLock is held for writing while the stores are merged, which closes and reopens them
+	compactLock sync.RWMutex
+	// deleted is the number of results deleted since the last merge
+	deleted int
 }
 
+// ErrResultNotFound is returned when the requested result does not exist
+var ErrResultNotFound = errors.New("given key not found")
+
 // MesheryResultPage - represents a page of meshery results
 type MesheryResultPage struct {
 	Page       uint64           `json:"page"`
@@ -48,15 +61,121 @@ func NewBitCaskResultsPersister(folderName string) (*BitCaskResultsPersister, er
 		logrus.Errorf("Unable to open database: %v.", err)
 		return nil, err
 	}
+	indexDB, err := bitcask.Open(path.Join(folderName, "resultIndexDB"), bitcask.WithSync(true))
+	if err != nil {
+		logrus.Errorf("Unable to open database: %v.", err)
+		_ = db.Close()
+		return nil, err
+	}
 	bd := &BitCaskResultsPersister{
 		fileName: fileName,
 		db:       db,
+		indexDB:  indexDB,
+		index:    newResultsIndex(),
+	}
+	if err := bd.loadIndex(); err != nil {
+		bd.CloseResultPersister()
+		return nil, err
 	}
 	return bd, nil
 }
 
-// GetResults - gets result for the page and pageSize
-func (s *BitCaskResultsPersister) GetResults(page, pageSize uint64) ([]byte, error) {
+// loadIndex loads the summaries of the results in memory, they are rebuilt from the results when
+// the index store is out of sync, eg. on the first start after an upgrade
+func (s *BitCaskResultsPersister) loadIndex() error {
+	if s.indexDB.Len() == s.db.Len() {
+		outdated := []*resultIndexEntry{}
+		err := s.indexDB.Fold(func(key []byte) error {
+			data, err := s.indexDB.Get(key)
+			if err != nil {
+				return err
+			}
+			e := &resultIndexEntry{}
+			if err := json.Unmarshal(data, e); err != nil {
+				return err
+			}
+			e.init()
+			if e.Size == 0 {
+				// the size is missing from the summaries indexed by earlier versions
+				data, err := s.db.Get(e.ID.Bytes())
+				if err != nil {
+					return err
+				}
+				e.Size = int64(len(data))
+				outdated = append(outdated, e)
+			}
+			s.index.put(e)
+			return nil
+		})
+		for _, e := range outdated {
+			if err := s.writeIndexEntry(e); err != nil {
+				logrus.Warnf("Unable to update the index of the result %s: %v", e.ID, err)
+			}
+		}
+		if err == nil && s.index.len() == s.db.Len() {
+			return nil
+		}
+		if err != nil {
+			logrus.Warnf("Unable to load the results index, rebuilding it: %v", err)
+		}
+	}
+
+	logrus.Infof("Indexing %d results", s.db.Len())
+	s.index = newResultsIndex()
+	keys := [][]byte{}
+	for k := range s.indexDB.Keys() {
+		keys = append(keys, k)
+	}
+	for _, k := range keys {
+		if err := s.indexDB.Delete(k); err != nil {
+			err = errors.Wrapf(err, "Unable to reset the results index")
+			logrus.Error(err)
+			return err
+		}
+	}
+	err := s.db.Fold(func(key []byte) error {
+		data, err := s.db.Get(key)
+		if err != nil {
+			return err
+		}
+		id, err := uuid.FromBytes(key)
+		if err != nil {
+			logrus.Warnf("Skipping the result with an invalid key: %v", err)
+			return nil
+		}
+		result := &MesheryResult{}
+		if err := json.Unmarshal(data, result); err != nil {
+			logrus.Warnf("Skipping the result %s which cannot be unmarshalled: %v", id, err)
+			return nil
+		}
+		return s.writeIndexEntry(newResultIndexEntry(id, result, len(data)))
+	})
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to index the results")
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// writeIndexEntry must be called with the lock held
+func (s *BitCaskResultsPersister) writeIndexEntry(e *resultIndexEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := s.indexDB.Put(e.ID.Bytes(), data); err != nil {
+		return err
+	}
+	s.index.put(e)
+	return nil
+}
+
+// GetResults - gets the page of results matching the query
+func (s *BitCaskResultsPersister) GetResults(q *ResultsQuery) ([]byte, error) {
+	s.compactLock.RLock()
+	defer s.compactLock.RUnlock()
+
 	if s.db == nil {
 		return nil, errors.New("Connection to DB does not exist.")
 	}
@@ -74,44 +193,31 @@ RETRY:
 		_ = s.db.Unlock()
 	}()
 
-	total := s.db.Len()
+	ids, total := s.index.query(q)
+	logrus.Debugf("received page: %d, page size: %d, matching: %d", q.Page, q.PageSize, total)
 
 	results := []*MesheryResult{}
-
-	start := page * pageSize
-	end := (page+1)*pageSize - 1
-	logrus.Debugf("received page: %d, page size: %d, total: %d", page, pageSize, total)
-	logrus.Debugf("computed start index: %d, end index: %d", start, end)
-
-	if start > uint64(total) {
-		return nil, fmt.Errorf("index out of range")
-	}
-	var localIndex uint64
-
-	for k := range s.db.Keys() {
-		if localIndex >= start && localIndex <= end {
-			dd, err := s.db.Get(k)
-			if err != nil {
-				err = errors.Wrapf(err, "Unable to read data from bitcask store")
+	for _, id := range ids {
+		dd, err := s.db.Get(id.Bytes())
+		if err != nil {
+			err = errors.Wrapf(err, "Unable to read data from bitcask store")
+			logrus.Error(err)
+			return nil, err
+		}
+		if len(dd) > 0 {
+			result := &MesheryResult{}
+			if err := json.Unmarshal(dd, result); err != nil {
+				err = errors.Wrapf(err, "Unable to unmarshal data.")
 				logrus.Error(err)
 				return nil, err
 			}
-			if len(dd) > 0 {
-				result := &MesheryResult{}
-				if err := json.Unmarshal(dd, result); err != nil {
-					err = errors.Wrapf(err, "Unable to unmarshal data.")
-					logrus.Error(err)
-					return nil, err
-				}
-				results = append(results, result)
-			}
+			results = append(results, result)
 		}
-		localIndex++
 	}
 
 	bd, err := json.Marshal(&MesheryResultPage{
-		Page:       page,
-		PageSize:   pageSize,
+		Page:       q.Page,
+		PageSize:   q.PageSize,
 		TotalCount: total,
 		Results:    results,
 	})
@@ -126,6 +232,9 @@ RETRY:
 
 // GetResult - gets result for a specific key
 func (s *BitCaskResultsPersister) GetResult(key uuid.UUID) (*MesheryResult, error) {
+	s.compactLock.RLock()
+	defer s.compactLock.RUnlock()
+
 	if s.db == nil {
 		return nil, errors.New("Connection to DB does not exist.")
 	}
@@ -170,6 +279,9 @@ RETRY:
 
 // WriteResult persists the result
 func (s *BitCaskResultsPersister) WriteResult(key uuid.UUID, result []byte) error {
+	s.compactLock.RLock()
+	defer s.compactLock.RUnlock()
+
 	if s.db == nil {
 		return errors.New("connection to DB does not exist")
 	}
@@ -191,16 +303,151 @@ RETRY:
 		_ = s.db.Unlock()
 	}()
 
+	mr := &MesheryResult{}
+	if err := json.Unmarshal(result, mr); err != nil {
+		err = errors.Wrapf(err, "Unable to unmarshal result data.")
+		logrus.Error(err)
+		return err
+	}
 	if err := s.db.Put(key.Bytes(), result); err != nil {
 		err = errors.Wrapf(err, "Unable to persist result data.")
 		logrus.Error(err)
 		return err
 	}
+	if err := s.writeIndexEntry(newResultIndexEntry(key, mr, len(result))); err != nil {
+		err = errors.Wrapf(err, "Unable to index result data.")
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// DeleteResult removes the result
+func (s *BitCaskResultsPersister) DeleteResult(key uuid.UUID) error {
+	s.compactLock.RLock()
+	defer s.compactLock.RUnlock()
+
+	if s.db == nil {
+		return errors.New("connection to DB does not exist")
+	}
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	return s.deleteResult(key)
+}
+
+// deleteResult must be called with the lock held
+func (s *BitCaskResultsPersister) deleteResult(key uuid.UUID) error {
+	keyb := key.Bytes()
+	if !s.db.Has(keyb) {
+		return ErrResultNotFound
+	}
+	if err := s.db.Delete(keyb); err != nil {
+		err = errors.Wrapf(err, "Unable to delete result data.")
+		logrus.Error(err)
+		return err
+	}
+	s.index.delete(key)
+	if err := s.indexDB.Delete(keyb); err != nil {
+		err = errors.Wrapf(err, "Unable to delete result index data.")
+		logrus.Error(err)
+		return err
+	}
+	s.deleted++
 	return nil
 }
 
+// EnforceRetention deletes the results exceeding the retention policy, except the ones in keep,
+// and returns the number of deleted results
+func (s *BitCaskResultsPersister) EnforceRetention(policy ResultsRetention, keep map[uuid.UUID]bool) (int, error) {
+	s.compactLock.RLock()
+	defer s.compactLock.RUnlock()
+
+	if s.db == nil {
+		return 0, errors.New("connection to DB does not exist")
+	}
+	if !policy.Enabled() {
+		return 0, nil
+	}
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	deleted := 0
+	for _, id := range s.index.expired(policy, time.Now(), keep) {
+		if err := s.deleteResult(id); err != nil && err != ErrResultNotFound {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// Compact merges the stores to reclaim the space of the deleted results, it does nothing when no
+// result was deleted since the last merge
+func (s *BitCaskResultsPersister) Compact() error {
+	s.compactLock.Lock()
+	defer s.compactLock.Unlock()
+
+	if s.db == nil {
+		return errors.New("connection to DB does not exist")
+	}
+	if s.deleted == 0 {
+		return nil
+	}
+	for _, db := range []*bitcask.Bitcask{s.db, s.indexDB} {
+		if err := db.Merge(); err != nil {
+			err = errors.Wrapf(err, "Unable to merge the bitcask store")
+			logrus.Error(err)
+			return err
+		}
+	}
+	s.deleted = 0
+	return nil
+}
+
+// Size returns the size of the stores on disk, in bytes
+func (s *BitCaskResultsPersister) Size() (int64, error) {
+	s.compactLock.RLock()
+	defer s.compactLock.RUnlock()
+
+	var size int64
+	for _, db := range []*bitcask.Bitcask{s.db, s.indexDB} {
+		stats, err := db.Stats()
+		if err != nil {
+			return 0, errors.Wrapf(err, "Unable to get the size of the bitcask store")
+		}
+		size += stats.Size
+	}
+	return size, nil
+}
+
 // CloseResultPersister closes the badger store
 func (s *BitCaskResultsPersister) CloseResultPersister() {
+	if s.indexDB != nil {
+		_ = s.indexDB.Close()
+	}
 	if s.db == nil {
 		return
 	}
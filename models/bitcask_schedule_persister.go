@@ -0,0 +1,192 @@
+package models
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/prologic/bitcask"
+	"github.com/sirupsen/logrus"
+)
+
+// BitCaskSchedulePersister assists with persisting load test schedules in a Bitcask store
+type BitCaskSchedulePersister struct {
+	fileName string
+	db       *bitcask.Bitcask
+}
+
+// NewBitCaskSchedulePersister creates a new BitCaskSchedulePersister instance
+func NewBitCaskSchedulePersister(folderName string) (*BitCaskSchedulePersister, error) {
+	_, err := os.Stat(folderName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = os.MkdirAll(folderName, os.ModePerm)
+			if err != nil {
+				logrus.Errorf("Unable to create the directory '%s' due to error: %v.", folderName, err)
+				return nil, err
+			}
+		} else {
+			logrus.Errorf("Unable to find/stat the folder '%s': %v,", folderName, err)
+			return nil, err
+		}
+	}
+
+	fileName := path.Join(folderName, "scheduleDB")
+	db, err := bitcask.Open(fileName, bitcask.WithSync(true))
+	if err != nil {
+		logrus.Errorf("Unable to open database: %v.", err)
+		return nil, err
+	}
+	bd := &BitCaskSchedulePersister{
+		fileName: fileName,
+		db:       db,
+	}
+	return bd, nil
+}
+
+// GetSchedules - gets all the persisted schedules
+func (s *BitCaskSchedulePersister) GetSchedules() ([]*LoadTestSchedule, error) {
+	if s.db == nil {
+		return nil, errors.New("connection to DB does not exist")
+	}
+
+RETRY:
+	locked, err := s.db.TryRLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain read lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	schedules := []*LoadTestSchedule{}
+	for k := range s.db.Keys() {
+		data, err := s.db.Get(k)
+		if err != nil {
+			err = errors.Wrapf(err, "Unable to read data from bitcask store")
+			logrus.Error(err)
+			return nil, err
+		}
+		schedule := &LoadTestSchedule{}
+		if err := json.Unmarshal(data, schedule); err != nil {
+			err = errors.Wrapf(err, "Unable to unmarshal data.")
+			logrus.Error(err)
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+// GetSchedule - gets the schedule with the given ID
+func (s *BitCaskSchedulePersister) GetSchedule(id string) (*LoadTestSchedule, error) {
+	if s.db == nil {
+		return nil, errors.New("connection to DB does not exist")
+	}
+
+RETRY:
+	locked, err := s.db.TryRLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain read lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	if !s.db.Has([]byte(id)) {
+		return nil, errors.New("given key not found")
+	}
+	data, err := s.db.Get([]byte(id))
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to fetch schedule data")
+		logrus.Error(err)
+		return nil, err
+	}
+	schedule := &LoadTestSchedule{}
+	if err := json.Unmarshal(data, schedule); err != nil {
+		err = errors.Wrapf(err, "Unable to unmarshal schedule data.")
+		logrus.Error(err)
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// WriteSchedule persists the schedule
+func (s *BitCaskSchedulePersister) WriteSchedule(schedule *LoadTestSchedule) error {
+	if s.db == nil {
+		return errors.New("connection to DB does not exist")
+	}
+	if schedule == nil || schedule.ID == "" {
+		return errors.New("given schedule is invalid")
+	}
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to marshal schedule data.")
+		logrus.Error(err)
+		return err
+	}
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	if err := s.db.Put([]byte(schedule.ID), data); err != nil {
+		err = errors.Wrapf(err, "Unable to persist schedule data.")
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// DeleteSchedule removes the schedule with the given ID
+func (s *BitCaskSchedulePersister) DeleteSchedule(id string) error {
+	if s.db == nil {
+		return errors.New("connection to DB does not exist")
+	}
+
+RETRY:
+	locked, err := s.db.TryLock()
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to obtain write lock from bitcask store")
+		logrus.Error(err)
+	}
+	if !locked {
+		goto RETRY
+	}
+	defer func() {
+		_ = s.db.Unlock()
+	}()
+
+	if err := s.db.Delete([]byte(id)); err != nil {
+		err = errors.Wrapf(err, "Unable to delete schedule data.")
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// CloseSchedulePersister closes the bitcask store
+func (s *BitCaskSchedulePersister) CloseSchedulePersister() {
+	if s.db == nil {
+		return
+	}
+	_ = s.db.Close()
+}
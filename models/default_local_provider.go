@@ -7,7 +7,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"strconv"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/gorilla/sessions"
@@ -19,7 +19,11 @@ import (
 type DefaultLocalProvider struct {
 	*MapPreferencePersister
 	SaaSBaseURL     string
-	ResultPersister *BitCaskResultsPersister
+	ResultPersister ResultsPersister
+
+	PerformanceProfilesPersister *BitCaskPerformanceProfilesPersister
+
+	janitorStopChan chan struct{}
 }
 
 // Name - Returns Provider's friendly name
@@ -126,21 +130,16 @@ func (l *DefaultLocalProvider) Logout(w http.ResponseWriter, req *http.Request)
 	http.Redirect(w, req, "/login", http.StatusFound)
 }
 
-// FetchResults - fetches results from provider backend
+// FetchResults - fetches results from provider backend, the results can be filtered by the mesh, loadGenerator,
+// from, to and profile parameters of the request
 func (l *DefaultLocalProvider) FetchResults(req *http.Request, page, pageSize, search, order string) ([]byte, error) {
-	pg, err := strconv.ParseUint(page, 10, 32)
-	if err != nil {
-		err = errors.Wrapf(err, "unable to parse page number")
-		logrus.Error(err)
-		return nil, err
-	}
-	pgs, err := strconv.ParseUint(pageSize, 10, 32)
+	q, err := ParseResultsQuery(page, pageSize, search, order, req.URL.Query())
 	if err != nil {
-		err = errors.Wrapf(err, "unable to parse page size")
+		err = errors.Wrapf(err, "unable to parse the results query")
 		logrus.Error(err)
 		return nil, err
 	}
-	return l.ResultPersister.GetResults(pg, pgs)
+	return l.ResultPersister.GetResults(q)
 }
 
 // GetResult - fetches result from provider backend for the given result id
@@ -152,6 +151,65 @@ func (l *DefaultLocalProvider) GetResult(req *http.Request, resultID uuid.UUID)
 	return l.ResultPersister.GetResult(resultID)
 }
 
+// DeleteResult - deletes the result with the given id
+func (l *DefaultLocalProvider) DeleteResult(req *http.Request, resultID uuid.UUID) error {
+	if resultID == uuid.Nil {
+		return fmt.Errorf("given resultID is not valid")
+	}
+	return l.ResultPersister.DeleteResult(resultID)
+}
+
+// StartResultsJanitor - periodically deletes the results exceeding the retention policy and merges the
+// result store to reclaim the disk space, the results used as baselines are kept
+func (l *DefaultLocalProvider) StartResultsJanitor(policy ResultsRetention, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	l.janitorStopChan = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			l.cleanupResults(policy)
+			select {
+			case <-ticker.C:
+			case <-l.janitorStopChan:
+				return
+			}
+		}
+	}()
+}
+
+// StopResultsJanitor - used to stop the results janitor
+func (l *DefaultLocalProvider) StopResultsJanitor() {
+	if l.janitorStopChan != nil {
+		l.janitorStopChan <- struct{}{}
+	}
+}
+
+func (l *DefaultLocalProvider) cleanupResults(policy ResultsRetention) {
+	keep := map[uuid.UUID]bool{}
+	if pref, err := l.ReadFromPersister(l.fetchUserDetails().UserID); err == nil && pref != nil {
+		for _, id := range pref.ResultBaselines {
+			keep[uuid.FromStringOrNil(id)] = true
+		}
+	}
+	deleted, err := l.ResultPersister.EnforceRetention(policy, keep)
+	if err != nil {
+		logrus.Errorf("unable to enforce the results retention policy: %v", err)
+	}
+	if deleted > 0 {
+		logrus.Infof("deleted %d results exceeding the retention policy", deleted)
+	}
+	if err := l.ResultPersister.Compact(); err != nil {
+		logrus.Errorf("unable to compact the results store: %v", err)
+		return
+	}
+	if size, err := l.ResultPersister.Size(); err == nil {
+		logrus.Debugf("results store size: %d bytes", size)
+	}
+}
+
 // PublishResults - publishes results to the provider backend syncronously
 func (l *DefaultLocalProvider) PublishResults(req *http.Request, result *MesheryResult) (string, error) {
 	data, err := json.Marshal(result)
@@ -263,3 +321,29 @@ func (l *DefaultLocalProvider) PublishMetrics(_ string, result *MesheryResult) e
 func (l *DefaultLocalProvider) RecordPreferences(req *http.Request, userID string, data *Preference) error {
 	return l.MapPreferencePersister.WriteToPersister(userID, data)
 }
+
+// SavePerformanceProfile - persists the profile as a new version
+func (l *DefaultLocalProvider) SavePerformanceProfile(req *http.Request, profile *PerformanceProfile) (*PerformanceProfile, error) {
+	if err := l.PerformanceProfilesPersister.WritePerformanceProfile(profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// GetPerformanceProfiles - fetches the latest version of the profiles whose name contains search
+func (l *DefaultLocalProvider) GetPerformanceProfiles(req *http.Request, search string) ([]*PerformanceProfile, error) {
+	return l.PerformanceProfilesPersister.GetPerformanceProfiles(search)
+}
+
+// GetPerformanceProfile - fetches the given version of a profile, the latest one when version is 0
+func (l *DefaultLocalProvider) GetPerformanceProfile(req *http.Request, profileID uuid.UUID, version int) (*PerformanceProfile, error) {
+	if profileID == uuid.Nil {
+		return nil, fmt.Errorf("given profileID is not valid")
+	}
+	return l.PerformanceProfilesPersister.GetPerformanceProfile(profileID, version)
+}
+
+// DeletePerformanceProfile - removes all the versions of a profile
+func (l *DefaultLocalProvider) DeletePerformanceProfile(req *http.Request, profileID uuid.UUID) error {
+	return l.PerformanceProfilesPersister.DeletePerformanceProfile(profileID)
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// ExperimentRunPending - represents a run of an experiment which has not started yet
+const ExperimentRunPending LoadTestJobStatus = "pending"
+
+// ExperimentMesh - represents a mesh compared by an experiment
+type ExperimentMesh struct {
+	// Name is the mesh the runs are labelled with, empty for the runs without a mesh
+	Name string `json:"name"`
+	// URL is the endpoint targeted through this mesh, the one of the profile when empty
+	URL string `json:"url,omitempty"`
+}
+
+// ExperimentRun - represents a load test of an experiment, a cell of its matrix
+type ExperimentRun struct {
+	Profile        uuid.UUID `json:"profile"`
+	ProfileVersion int       `json:"profile_version"`
+	ProfileName    string    `json:"profile_name"`
+	Mesh           string    `json:"mesh"`
+	URL            string    `json:"url,omitempty"`
+	// QPS is the load level of the run, 0 for max speed
+	QPS float64 `json:"qps"`
+
+	Status    LoadTestJobStatus `json:"status"`
+	JobID     string            `json:"job_id,omitempty"`
+	ResultID  string            `json:"result_id,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	StartTime *time.Time        `json:"start_time,omitempty"`
+	EndTime   *time.Time        `json:"end_time,omitempty"`
+}
+
+// Experiment - represents a group of load tests run sequentially over the matrix of its performance profiles,
+// meshes and load levels, its ID is the experiment group of the benchmark specs of the results
+type Experiment struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	UserID      string    `json:"user_id"`
+
+	Profiles []uuid.UUID      `json:"profiles"`
+	Meshes   []ExperimentMesh `json:"meshes,omitempty"`
+	QPS      []float64        `json:"qps,omitempty"`
+
+	Runs   []*ExperimentRun  `json:"runs"`
+	Status LoadTestJobStatus `json:"status"`
+	Error  string            `json:"error,omitempty"`
+
+	// Provider is the name of the provider of the user, the load tests are run on behalf of the user with it
+	Provider string `json:"provider"`
+
+	CreatedAt time.Time  `json:"created_at"`
+	EndTime   *time.Time `json:"end_time,omitempty"`
+}
+
+// PlanRuns - sets the runs of the experiment to the matrix of its profiles, meshes and load levels, in that
+// order, the runs keep the mesh and the load level of their profile when the experiment has none
+func (e *Experiment) PlanRuns(profiles []*PerformanceProfile) {
+	e.Runs = []*ExperimentRun{}
+	for _, profile := range profiles {
+		meshes := e.Meshes
+		if len(meshes) == 0 {
+			meshes = []ExperimentMesh{{Name: profile.Mesh}}
+		}
+		levels := e.QPS
+		if len(levels) == 0 {
+			levels = []float64{profile.QPS}
+		}
+		for _, mesh := range meshes {
+			for _, qps := range levels {
+				e.Runs = append(e.Runs, &ExperimentRun{
+					Profile:        profile.ID,
+					ProfileVersion: profile.Version,
+					ProfileName:    profile.Name,
+					Mesh:           mesh.Name,
+					URL:            mesh.URL,
+					QPS:            qps,
+					Status:         ExperimentRunPending,
+				})
+			}
+		}
+	}
+}
+
+// ExperimentRunReport - represents a run of an experiment along with the figures of its result
+type ExperimentRunReport struct {
+	*ExperimentRun
+	Summary *ResultSummary `json:"summary,omitempty"`
+	// Metrics are the SMP metrics of the mesh during the run, if Prometheus was configured
+	Metrics *Metrics `json:"metrics,omitempty"`
+	// Missing tells why the result of a run which did not fail is not in the report, nor in its comparisons
+	Missing string `json:"missing,omitempty"`
+}
+
+// ExperimentComparison - represents the comparison of the meshes of an experiment for a profile and a load level,
+// the baseline is the first of the meshes of the experiment
+type ExperimentComparison struct {
+	Profile      uuid.UUID         `json:"profile"`
+	ProfileName  string            `json:"profile_name"`
+	QPS          float64           `json:"qps,omitempty"`
+	BaselineMesh string            `json:"baseline_mesh"`
+	Comparison   *ResultComparison `json:"comparison"`
+}
+
+// ExperimentReport - represents the aggregated results of an experiment
+type ExperimentReport struct {
+	Experiment  *Experiment             `json:"experiment"`
+	Runs        []*ExperimentRunReport  `json:"runs"`
+	Comparisons []*ExperimentComparison `json:"comparisons"`
+}
+
+// ExperimentPersister defines the methods for persisting experiments
+type ExperimentPersister interface {
+	// GetExperiments - returns all the persisted experiments
+	GetExperiments() ([]*Experiment, error)
+	// GetExperiment - returns the experiment with the given ID
+	GetExperiment(id uuid.UUID) (*Experiment, error)
+	// WriteExperiment - persists the experiment, replacing any experiment with the same ID
+	WriteExperiment(experiment *Experiment) error
+	// DeleteExperiment - removes the experiment with the given ID
+	DeleteExperiment(id uuid.UUID) error
+}
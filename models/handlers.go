@@ -30,9 +30,20 @@ type HandlerInterface interface {
 
 	LoadTestHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
 	LoadTestUsingSMPSHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	LoadTestJobHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	LoadTestQueueHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	LoadTestWorkersHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	LoadTestWorkerHandler(w http.ResponseWriter, req *http.Request)
+	LoadTestSchedulesHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
 	CollectStaticMetrics(config *SubmitMetricsConfig) error
 	FetchResultsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
 	GetResultHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	ExportResultsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	ImportResultsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	ExperimentsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	CompareResultsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	ResultBaselinesHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
+	PerformanceProfilesHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
 
 	MeshAdapterConfigHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
 	MeshOpsHandler(w http.ResponseWriter, req *http.Request, session *sessions.Session, prefObj *Preference, user *User, provider Provider)
@@ -74,6 +85,22 @@ type HandlerConfig struct {
 	AdapterTracker AdaptersTrackerInterface
 	QueryTracker   QueryTrackerInterface
 
+	LoadTestJobTracker LoadTestJobTrackerInterface
+	LoadTestQueue      LoadTestQueueInterface
+	LoadGenerators     map[string]LoadGenerator
+
+	LoadTestWorkersTracker LoadTestWorkersTrackerInterface
+	LoadTestWorkerToken    string
+
+	// LoadTestJobImage is the Meshery image run by the load tests generating their load from within the cluster
+	LoadTestJobImage string
+
+	SchedulePersister   LoadTestSchedulePersister
+	ExperimentPersister ExperimentPersister
+
+	// ResultPersister is the store of the results of the local provider
+	ResultPersister ResultsPersister
+
 	Queue taskq.Queue
 
 	KubeConfigFolder string
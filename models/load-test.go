@@ -2,9 +2,13 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"fortio.org/fortio/fgrpc"
 	"fortio.org/fortio/fhttp"
 	"fortio.org/fortio/periodic"
 	"github.com/gofrs/uuid"
@@ -12,22 +16,38 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// LoadGenerator - represents the load generator type
-type LoadGenerator string
+// LoadGeneratorType - represents the load generator type
+type LoadGeneratorType string
 
 const (
 	// FortioLG - represents the Fortio load generator
-	FortioLG LoadGenerator = "fortio"
+	FortioLG LoadGeneratorType = "fortio"
 
 	// Wrk2LG - represents the wrk2 load generator
-	Wrk2LG LoadGenerator = "wrk2"
+	Wrk2LG LoadGeneratorType = "wrk2"
+
+	// NighthawkLG - represents the Envoy Nighthawk load generator
+	NighthawkLG LoadGeneratorType = "nighthawk"
 )
 
 // Name - retrieves a string value for the generator
-func (l LoadGenerator) Name() string {
+func (l LoadGeneratorType) Name() string {
 	return string(l)
 }
 
+// LoadModel - represents how the load generator paces the requests
+type LoadModel string
+
+const (
+	// ClosedLoadModel - each connection sends its next request once the previous one completed, so that a stalled
+	// target delays the requests which would have measured the stall
+	ClosedLoadModel LoadModel = "closed"
+
+	// OpenLoadModel - the requests are sent at a constant arrival rate regardless of the response times, their
+	// latencies being measured from the time they were scheduled at, which corrects the coordinated omission
+	OpenLoadModel LoadModel = "open"
+)
+
 // LoadTestOptions represents the load test options
 type LoadTestOptions struct {
 	Name string
@@ -37,12 +57,62 @@ type LoadTestOptions struct {
 
 	HTTPNumThreads int
 
+	// LoadModel is how the requests are paced, the default model of the load generator is used when empty
+	LoadModel LoadModel
+	// WarmUp is run at the start of the test at its initial load and over its connections to warm up the target,
+	// its requests, along with the setup of the connections, are not part of the results
+	WarmUp time.Duration
+
 	IsInsecure bool
 	Duration   time.Duration
 
-	LoadGenerator LoadGenerator
+	HTTP2 bool
+
+	LoadGenerator LoadGeneratorType
+
+	// Percentiles to be computed, the load generator defaults are used when empty
+	Percentiles []float64
+
+	// Distributed spreads the load across the registered load test workers
+	Distributed bool
 
+	// InCluster runs the load generator as a Kubernetes job instead of in the Meshery process
+	InCluster *InClusterLoadTest
+
+	// Stages make up a multi-stage load profile, when set they take precedence over HTTPQPS, HTTPNumThreads and Duration
+	Stages []*LoadTestStage
+
+	// Scenario spreads the requests across several endpoints according to their weights, when set it takes
+	// precedence over URL and the HTTP request settings, HTTPHeaders being added to the requests of every endpoint
+	Scenario []*LoadTestEndpoint
+
+	// SLOs are the assertions the results are checked against
+	SLOs []*SLOAssertion
+	// Guardrails end the test early once any of them trips, all but the Prometheus ones need the load generator
+	// to report the outcome of the requests to the recorder
+	Guardrails []*LoadTestGuardrail
+
+	// PerformanceProfile is the profile the test is run from, if any
+	PerformanceProfile *PerformanceProfile
+	// ExperimentGroup is the experiment the test is run for, if any
+	ExperimentGroup *uuid.UUID
+
+	// HTTPMethod is the method of the requests, GET when empty
+	HTTPMethod string
+	// HTTPHeaders are added to every request, a Host header overrides the virtual host
+	HTTPHeaders http.Header
+	ContentType string
+	// Payload is the body of every request
+	Payload []byte
+
+	HTTP10           bool
+	Compression      bool
+	DisableKeepAlive bool
+
+	// Cert, Key and CACert hold PEM encoded TLS material, CertOverride overrides the server name
+	// verified against the server certificate
 	Cert, Key, CACert string
+	CertOverride      string
 
 	AllowInitialErrors bool
 
@@ -52,6 +122,99 @@ type LoadTestOptions struct {
 	GRPCHealthSvc    string
 	GRPCDoPing       bool
 	GRPCPingDelay    time.Duration
+
+	// Recorder receives the outcome of the requests as the test runs for its live metrics, if supported by
+	// the load generator. It is not shipped along with the options
+	Recorder LoadTestRecorder `json:"-"`
+}
+
+// LoadTestRecorder - receives the outcome of each request of a running load test
+type LoadTestRecorder interface {
+	// Start - marks the start of the test, once its warm-up is over, the load generator calling it itself when
+	// the test has a warm-up
+	Start()
+	// Record - records the status code, -1 on socket errors, and the latency of a request
+	Record(code int, latency time.Duration)
+	// Stopped - returns a channel which is closed when the test is to be ended early, like when a guardrail
+	// trips, the results gathered until then being returned
+	Stopped() <-chan struct{}
+}
+
+// Method - returns the HTTP method of the requests
+func (o *LoadTestOptions) Method() string {
+	if o.HTTPMethod == "" {
+		return http.MethodGet
+	}
+	return o.HTTPMethod
+}
+
+const (
+	// LoadModelResultKey is the key of the results holding the load model of the test
+	LoadModelResultKey = "load_model"
+	// WarmUpResultKey is the key of the results holding the duration of the warm-up of the test, if any
+	WarmUpResultKey = "warm_up"
+)
+
+// InClusterLoadTestResultKey is the key of the results of a load test run as a Kubernetes job, which holds
+// where the load generator was run
+const InClusterLoadTestResultKey = "in_cluster"
+
+// InClusterLoadTest - represents the settings of a load generator run as a Kubernetes job
+type InClusterLoadTest struct {
+	// Namespace the job is created in
+	Namespace string
+	// InjectSidecar has the mesh inject its proxy in the load generator pod, so that the load goes through the mesh
+	InjectSidecar bool
+	// Image is the Meshery image run by the job
+	Image string
+}
+
+// ScenarioResultKey is the key of the results of a scenario test holding the results of each of its endpoints
+const ScenarioResultKey = "scenario"
+
+// LoadTestEndpoint - represents an endpoint of a scenario test along with its share of the requests
+type LoadTestEndpoint struct {
+	Name string
+	// Weight is the share of the requests sent to the endpoint relative to the weights of the other endpoints
+	Weight float64
+	// URL may hold a placeholder in braces like {id}, which is replaced by each of Values in turn
+	URL    string
+	Values []string
+
+	HTTPMethod  string
+	HTTPHeaders http.Header
+	ContentType string
+	Payload     []byte
+}
+
+// Method - returns the HTTP method of the requests to the endpoint
+func (e *LoadTestEndpoint) Method() string {
+	if e.HTTPMethod == "" {
+		return http.MethodGet
+	}
+	return e.HTTPMethod
+}
+
+// LoadTestStage - represents a stage of a multi-stage load profile, the load is linearly ramped
+// from the previous stage targets over Ramp and then held at the stage targets for Duration
+type LoadTestStage struct {
+	Name        string
+	QPS         float64
+	Connections int
+	Ramp        time.Duration
+	Duration    time.Duration
+}
+
+// TotalDuration - returns the time it takes to run all the stages
+func (o *LoadTestOptions) TotalDuration() time.Duration {
+	if len(o.Stages) == 0 {
+		return o.Duration
+	}
+	var d time.Duration
+	for _, st := range o.Stages {
+		d += st.Ramp + st.Duration
+	}
+	return d
 }
 
 // LoadTestStatus - used for representing load test status
@@ -66,13 +229,49 @@ const (
 
 	// LoadTestSuccess - represents a success status
 	LoadTestSuccess LoadTestStatus = "success"
+
+	// LoadTestProgress - represents a snapshot of the live metrics of a running test
+	LoadTestProgress LoadTestStatus = "progress"
+
+	// LoadTestQueued - represents a test waiting in the run queue for the tests ahead of it to complete
+	LoadTestQueued LoadTestStatus = "queued"
+
+	// LoadTestDropped - represents the end of an event stream which fell behind, while the test itself goes on
+	LoadTestDropped LoadTestStatus = "dropped"
 )
 
 // LoadTestResponse - used to bundle the response with status to the client
 type LoadTestResponse struct {
-	Status  LoadTestStatus `json:"status,omitempty"`
-	Message string         `json:"message,omitempty"`
-	Result  *MesheryResult `json:"result,omitempty"`
+	Status   LoadTestStatus    `json:"status,omitempty"`
+	Message  string            `json:"message,omitempty"`
+	Result   *MesheryResult    `json:"result,omitempty"`
+	Progress *LoadTestSnapshot `json:"progress,omitempty"`
+	JobID    string            `json:"job_id,omitempty"`
+	// ResultPersisted is set on the response of a successful test when its result was stored, the ID of a result
+	// which was not stored is only a key for the client
+	ResultPersisted bool `json:"result_persisted,omitempty"`
+	// QueuePosition is the position of a queued test in the run queue, 1 being the next one to run
+	QueuePosition int `json:"queue_position,omitempty"`
+}
+
+// LoadTestSnapshot - represents the live metrics of a running load test, the rate and the latencies being the
+// ones of the requests completed since the previous snapshot while the counts are since the start of the test
+type LoadTestSnapshot struct {
+	// Elapsed is the time since the start of the test in seconds
+	Elapsed  float64 `json:"elapsed"`
+	Requests int64   `json:"requests"`
+	QPS      float64 `json:"qps"`
+	// P50, P90 and P99 are the latency percentiles in seconds
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	// Errors counts the requests which got a non 2xx status code, ErrorCodes breaks them down by status code
+	// with -1 standing for socket errors
+	Errors     int64         `json:"errors"`
+	ErrorCodes map[int]int64 `json:"error_codes,omitempty"`
+	// ElapsedOnly is set when the requests of the test are not reported as it runs, like with the load generators
+	// without live metrics or the tests run out of the Meshery process, the snapshot then only holding Elapsed
+	ElapsedOnly bool `json:"elapsed_only,omitempty"`
 }
 
 // MesheryResult - represents the results from Meshery test run to be shipped
@@ -84,30 +283,43 @@ type MesheryResult struct {
 
 	ServerMetrics     interface{} `json:"server_metrics,omitempty"`
 	ServerBoardConfig interface{} `json:"server_board_config,omitempty"`
+
+	// Verdict is the outcome of the SLO assertions of the test, if any
+	Verdict *SLOVerdict `json:"verdict,omitempty"`
+
+	// PerformanceProfile and PerformanceProfileVersion identify the performance profile the test was run from, if any
+	PerformanceProfile        *uuid.UUID `json:"performance_profile,omitempty"`
+	PerformanceProfileVersion int        `json:"performance_profile_version,omitempty"`
+
+	// ExperimentGroup identifies the experiment the test was run for, if any
+	ExperimentGroup *uuid.UUID `json:"exp_group_uuid,omitempty"`
 }
 
 // ConvertToSpec - converts meshery result to SMP
 func (m *MesheryResult) ConvertToSpec() (*BenchmarkSpec, error) {
 	b := &BenchmarkSpec{
-		Env:     &Environment{},
-		Client:  &MeshClientConfig{},
-		Metrics: &Metrics{},
-		ExpUUID: m.ID.String(),
+		SMPVersion: SMPVersion,
+		Env:        &Environment{},
+		Client:     &MeshClientConfig{},
+		Metrics:    &Metrics{},
+		ExpUUID:    m.ID.String(),
 	}
 	var (
 		results periodic.HasRunnerResult
 	)
-	retcodesString, _ := m.Result["RetCodes"].(map[string]interface{})
-	logrus.Debugf("retcodes: %+v, %T", m.Result["RetCodes"], m.Result["RetCodes"])
-	retcodes := map[int]int64{}
-	for k, v := range retcodesString {
-		k1, _ := strconv.Atoi(k)
-		retcodes[k1], _ = v.(int64)
-	}
-	// retcodes[200] = 10
-	m.Result["RetCodes"] = retcodes
+	runType, _ := m.Result["RunType"].(string)
 	logrus.Debugf("result to be converted: %+v", m)
-	if m.Result["RunType"].(string) == "HTTP" {
+	switch {
+	case runType == "HTTP":
+		retcodesString, _ := m.Result["RetCodes"].(map[string]interface{})
+		logrus.Debugf("retcodes: %+v, %T", m.Result["RetCodes"], m.Result["RetCodes"])
+		retcodes := map[int]int64{}
+		for k, v := range retcodesString {
+			k1, _ := strconv.Atoi(k)
+			retcodes[k1], _ = v.(int64)
+		}
+		// retcodes[200] = 10
+		m.Result["RetCodes"] = retcodes
 		httpResults := &fhttp.HTTPRunnerResults{}
 		resJ, err := json.Marshal(m.Result)
 		if err != nil {
@@ -125,8 +337,35 @@ func (m *MesheryResult) ConvertToSpec() (*BenchmarkSpec, error) {
 		results = httpResults
 		logrus.Debugf("httpresults: %+v", httpResults)
 		b.EndpointURL = httpResults.URL
-	} else {
-		// TODO: GRPC
+		b.Client.Protocol = "http"
+	case strings.HasPrefix(runType, "GRPC"):
+		// the run type is either "GRPC Health" or "GRPC Ping", optionally followed by the ping delay
+		grpcResults := &fgrpc.GRPCRunnerResults{}
+		resJ, err := json.Marshal(m.Result)
+		if err != nil {
+			err = errors.Wrap(err, "unable while converting Meshery result to Benchmark Spec")
+			logrus.Error(err)
+			return nil, err
+		}
+		err = json.Unmarshal(resJ, grpcResults)
+		if err != nil {
+			err = errors.Wrap(err, "unable while converting Meshery result to Benchmark Spec")
+			logrus.Error(err)
+			return nil, err
+		}
+
+		results = grpcResults
+		logrus.Debugf("grpcresults: %+v", grpcResults)
+		b.EndpointURL = grpcResults.Destination
+		b.Client.Protocol = "grpc"
+		b.Client.GRPC = &GRPCClient{
+			Ping:    grpcResults.Ping,
+			Streams: grpcResults.Streams,
+		}
+	default:
+		err := fmt.Errorf("unable to convert results of the run type %q to Benchmark Spec", runType)
+		logrus.Error(err)
+		return nil, err
 	}
 
 	result := results.Result()
@@ -134,20 +373,23 @@ func (m *MesheryResult) ConvertToSpec() (*BenchmarkSpec, error) {
 	b.EndTime = result.StartTime.Add(result.ActualDuration)
 	b.Client.Connections = result.NumThreads
 	b.Client.Rps = result.ActualQPS
-	b.Client.Internal = false
+	_, b.Client.Internal = m.Result[InClusterLoadTestResultKey]
+	b.Client.LoadModel, _ = m.Result[LoadModelResultKey].(string)
+	b.Client.WarmUp, _ = m.Result[WarmUpResultKey].(string)
+	// the latencies of the runner results are in seconds
 	b.Client.LatenciesMs = &LatenciesMs{
-		Min:     result.DurationHistogram.Min,
-		Max:     result.DurationHistogram.Max,
-		Average: result.DurationHistogram.Avg,
+		Min:     result.DurationHistogram.Min * 1000,
+		Max:     result.DurationHistogram.Max * 1000,
+		Average: result.DurationHistogram.Avg * 1000,
 	}
 	for _, p := range result.DurationHistogram.Percentiles {
 		switch p.Percentile {
 		case 50:
-			b.Client.LatenciesMs.P50 = p.Value
+			b.Client.LatenciesMs.P50 = p.Value * 1000
 		case 90:
-			b.Client.LatenciesMs.P90 = p.Value
+			b.Client.LatenciesMs.P90 = p.Value * 1000
 		case 99:
-			b.Client.LatenciesMs.P99 = p.Value
+			b.Client.LatenciesMs.P99 = p.Value * 1000
 		}
 	}
 
@@ -155,13 +397,60 @@ func (m *MesheryResult) ConvertToSpec() (*BenchmarkSpec, error) {
 	if ok {
 		k8s, _ := k8sI.(map[string]interface{})
 		b.Env.Kubernetes, _ = k8s["server_version"].(string)
-		nodesI, okk := k8s["nodes"]
-		if okk {
-			nodes, okkk := nodesI.([]*K8SNode)
-			if okkk {
-				b.Env.NodeCount = len(nodes)
+		// the nodes are either the ones of the test or the ones read back from the store
+		nodes := []*K8SNode{}
+		if nodesI, ok := k8s["nodes"]; ok {
+			nodesJ, err := json.Marshal(nodesI)
+			if err == nil {
+				err = json.Unmarshal(nodesJ, &nodes)
+			}
+			if err != nil {
+				logrus.Warn(errors.Wrap(err, "unable to read the kubernetes nodes of the result"))
 			}
 		}
+		for _, n := range nodes {
+			b.Env.Nodes = append(b.Env.Nodes, &EnvironmentNode{
+				Hostname:         n.HostName,
+				OSImage:          n.OSImage,
+				Architecture:     n.Architecture,
+				KubeletVersion:   n.KubeletVersion,
+				ContainerRuntime: n.ContainerRuntimeVersion,
+				CPU:              n.CapacityCPU,
+				Memory:           n.CapacityMemory,
+			})
+		}
+		b.Env.NodeCount = len(nodes)
+		// the results imported from benchmark specs may only hold the node count
+		if count, ok := k8s["node_count"].(float64); ok && len(nodes) == 0 {
+			b.Env.NodeCount = int(count)
+		} else if count, ok := k8s["node_count"].(int); ok && len(nodes) == 0 {
+			b.Env.NodeCount = count
+		}
+	}
+
+	if smpI, ok := m.Result[smpResultKey]; ok {
+		smp := &smpResult{}
+		resJ, err := json.Marshal(smpI)
+		if err == nil {
+			err = json.Unmarshal(resJ, smp)
+		}
+		if err != nil {
+			err = errors.Wrap(err, "unable while converting Meshery result to Benchmark Spec")
+			logrus.Error(err)
+			return nil, err
+		}
+		b.MeshBuild = smp.MeshBuild
+		b.ProxyBuild = smp.ProxyBuild
+		b.ExpGroupUUID = smp.ExpGroupUUID
+		b.DetailsURI = smp.DetailsURI
+		b.Config = smp.Config
+		if smp.Metrics != nil {
+			b.Metrics = smp.Metrics
+		}
+	}
+	if m.ExperimentGroup != nil {
+		b.ExpGroupUUID = m.ExperimentGroup.String()
 	}
+	b.Profile = m.Name
 	return b, nil
 }
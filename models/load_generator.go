@@ -0,0 +1,143 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"fortio.org/fortio/periodic"
+	"github.com/pkg/errors"
+)
+
+// LoadGenerator - interface to be implemented by the load generator backends
+type LoadGenerator interface {
+	// Name - returns the name used for selecting the load generator
+	Name() string
+	// Capabilities - returns the features supported by the load generator
+	Capabilities() LoadGeneratorCapabilities
+	// Run - runs the load test until it completes or the context is cancelled and returns the results
+	// both as a map and in the normalized fortio runner results form
+	Run(ctx context.Context, opts *LoadTestOptions) (map[string]interface{}, *periodic.RunnerResults, error)
+}
+
+// LoadGeneratorCapabilities - represents the features supported by a load generator
+type LoadGeneratorCapabilities struct {
+	GRPC   bool `json:"grpc"`
+	HTTP2  bool `json:"http2"`
+	Stages bool `json:"stages"`
+	// Scenarios is set when the requests can be spread across several weighted endpoints
+	Scenarios bool `json:"scenarios"`
+	// LiveMetrics is set when the outcome of the requests is reported to the recorder of the options as the test
+	// runs, the tests of the other load generators only reporting their elapsed time
+	LiveMetrics bool `json:"live_metrics"`
+
+	// HTTPMethods lists the supported HTTP methods, empty means only GET
+	HTTPMethods []string `json:"http_methods,omitempty"`
+	HTTPHeaders bool     `json:"http_headers"`
+	HTTPBody    bool     `json:"http_body"`
+	// HTTPClientOptions is set when the HTTP/1.0, compression and keep-alive toggles are supported
+	HTTPClientOptions bool `json:"http_client_options"`
+
+	// CACert is set when a custom CA certificate can be used for verifying the server, ClientCerts when
+	// TLS client certificates can be presented by HTTP tests
+	CACert      bool `json:"ca_cert"`
+	ClientCerts bool `json:"client_certs"`
+
+	// Percentiles lists the percentiles the load generator is able to compute, empty means any percentile
+	Percentiles []float64 `json:"percentiles,omitempty"`
+
+	// LoadModels lists the supported load models, the first one being the default, empty means only the closed one
+	LoadModels []LoadModel `json:"load_models,omitempty"`
+	// WarmUp is set when the warm-up can be run at the start of HTTP tests, over the same connections as the test
+	// and with its requests left out of the results
+	WarmUp bool `json:"warm_up"`
+}
+
+// DefaultLoadModel - returns the load model used when none is requested
+func (c LoadGeneratorCapabilities) DefaultLoadModel() LoadModel {
+	if len(c.LoadModels) == 0 {
+		return ClosedLoadModel
+	}
+	return c.LoadModels[0]
+}
+
+// ValidateOptions - checks if the given load test options are supported
+func (c LoadGeneratorCapabilities) ValidateOptions(opts *LoadTestOptions) error {
+	if opts.IsGRPC && !c.GRPC {
+		return fmt.Errorf("load generator %s does not support gRPC", opts.LoadGenerator)
+	}
+	if opts.HTTP2 && !c.HTTP2 {
+		return fmt.Errorf("load generator %s does not support HTTP/2", opts.LoadGenerator)
+	}
+	if opts.LoadModel != "" {
+		supported := false
+		for _, m := range c.LoadModels {
+			supported = supported || m == opts.LoadModel
+		}
+		if !supported && !(len(c.LoadModels) == 0 && opts.LoadModel == ClosedLoadModel) {
+			return fmt.Errorf("load generator %s does not support the %s load model", opts.LoadGenerator, opts.LoadModel)
+		}
+	}
+	if opts.LoadModel == OpenLoadModel && opts.HTTPQPS <= 0 && len(opts.Stages) == 0 {
+		return errors.New("the open load model needs a target rate, please provide the qps")
+	}
+	if opts.WarmUp > 0 && (!c.WarmUp || opts.IsGRPC) {
+		return fmt.Errorf("load generator %s does not support a warm-up for this test", opts.LoadGenerator)
+	}
+	if !c.LiveMetrics {
+		// the Prometheus guardrails are checked without the outcome of the requests
+		for _, g := range opts.Guardrails {
+			if !g.IsPrometheus() {
+				return fmt.Errorf("load generator %s does not support the guardrail %q for this test, only the Prometheus ones", opts.LoadGenerator, g.Expr)
+			}
+		}
+	}
+	if len(opts.Scenario) > 0 && !c.Scenarios {
+		return fmt.Errorf("load generator %s does not support scenario tests", opts.LoadGenerator)
+	}
+	if len(opts.Stages) > 0 && (!c.Stages || opts.IsGRPC) {
+		return fmt.Errorf("load generator %s does not support multi-stage load profiles for this test", opts.LoadGenerator)
+	}
+	if method := opts.Method(); method != http.MethodGet {
+		supported := false
+		for _, m := range c.HTTPMethods {
+			if m == method {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return fmt.Errorf("load generator %s does not support the HTTP method %s", opts.LoadGenerator, method)
+		}
+	}
+	if len(opts.HTTPHeaders) > 0 && !c.HTTPHeaders {
+		return fmt.Errorf("load generator %s does not support custom HTTP headers", opts.LoadGenerator)
+	}
+	if (len(opts.Payload) > 0 || opts.ContentType != "") && !c.HTTPBody {
+		return fmt.Errorf("load generator %s does not support sending a request body", opts.LoadGenerator)
+	}
+	if (opts.HTTP10 || opts.Compression || opts.DisableKeepAlive) && !c.HTTPClientOptions {
+		return fmt.Errorf("load generator %s does not support the HTTP/1.0, compression and keep-alive options", opts.LoadGenerator)
+	}
+	if opts.CACert != "" && !c.CACert {
+		return fmt.Errorf("load generator %s does not support custom CA certificates", opts.LoadGenerator)
+	}
+	if (opts.Cert != "" || opts.Key != "") && (!c.ClientCerts || opts.IsGRPC) {
+		return fmt.Errorf("load generator %s does not support TLS client certificates for this test", opts.LoadGenerator)
+	}
+	if len(c.Percentiles) > 0 {
+		for _, p := range opts.Percentiles {
+			supported := false
+			for _, sp := range c.Percentiles {
+				if p == sp {
+					supported = true
+					break
+				}
+			}
+			if !supported {
+				return fmt.Errorf("load generator %s does not support the percentile %g", opts.LoadGenerator, p)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultGuardrailWindow - the period the metric of a guardrail is computed over when none is given
+const DefaultGuardrailWindow = 10 * time.Second
+
+// AbortResultKey is the key of the results of a load test ended early by a guardrail, which holds why it was
+const AbortResultKey = "aborted"
+
+// LoadTestGuardrail - represents a condition which ends a running load test early once met, the results gathered
+// until then being kept along with the reason of the abort
+type LoadTestGuardrail struct {
+	Expr string `json:"expr"`
+	// Status is a status code like 503, or a class of status codes like 5xx, which aborts the test as soon as a
+	// request gets it, socket standing for socket errors
+	Status string `json:"status,omitempty"`
+	// Assertion is checked against the requests completed over the last Window, or against a Prometheus query
+	// evaluated over the last Window, the test being aborted once it holds
+	Assertion *SLOAssertion `json:"assertion,omitempty"`
+	Window    time.Duration `json:"window,omitempty"`
+}
+
+// LoadTestAbort - represents why a load test was ended early by a guardrail
+type LoadTestAbort struct {
+	Guardrail string    `json:"guardrail"`
+	Value     float64   `json:"value"`
+	Time      time.Time `json:"time"`
+	Reason    string    `json:"reason"`
+}
+
+// MatchesStatus - returns true if a request which got the status code, -1 on socket errors, trips the guardrail
+func (g *LoadTestGuardrail) MatchesStatus(code int) bool {
+	switch {
+	case g.Status == "":
+		return false
+	case g.Status == "socket":
+		return code == -1
+	case strings.HasSuffix(g.Status, "xx"):
+		return code/100 == int(g.Status[0]-'0')
+	}
+	return strconv.Itoa(code) == g.Status
+}
+
+// IsPrometheus - returns true if the guardrail is evaluated with a Prometheus query rather than from the outcome
+// of the requests
+func (g *LoadTestGuardrail) IsPrometheus() bool {
+	return g.Assertion != nil && (g.Assertion.Metric == SLOPrometheusMax || g.Assertion.Metric == SLOPrometheusAvg)
+}
+
+// ParseLoadTestGuardrail - parses a guardrail written either as "status == <code>", where the code can also be a
+// class like 5xx or socket for socket errors, or as "<metric> <operator> <threshold> [over <window>]", where the
+// metric is one of those of the SLO assertions but qps_ratio and is computed over the last window.
+// eg. "status == 503", "error_rate > 5% over 30s", "p99 > 2s", "prom_max(avg(node_load1)) > 8 over 1m"
+func ParseLoadTestGuardrail(expr string) (*LoadTestGuardrail, error) {
+	expr = strings.TrimSpace(expr)
+	g := &LoadTestGuardrail{
+		Expr: expr,
+	}
+
+	if strings.HasPrefix(strings.ToLower(expr), "status") {
+		status := strings.TrimSpace(expr[len("status"):])
+		if !strings.HasPrefix(status, "==") {
+			return nil, fmt.Errorf("invalid guardrail %q, expecting status == <code>", expr)
+		}
+		status = strings.ToLower(strings.TrimSpace(status[2:]))
+		if status == "-1" {
+			status = "socket"
+		}
+		class := len(status) == 3 && status[0] >= '1' && status[0] <= '5' && status[1:] == "xx"
+		code, err := strconv.Atoi(status)
+		if !class && status != "socket" && (err != nil || code < 100 || code > 599) {
+			return nil, fmt.Errorf("invalid status code in guardrail %q", expr)
+		}
+		g.Status = status
+		return g, nil
+	}
+
+	assertion := expr
+	g.Window = DefaultGuardrailWindow
+	if i := strings.LastIndex(strings.ToLower(expr), " over "); i > 0 {
+		window, err := time.ParseDuration(strings.TrimSpace(expr[i+len(" over "):]))
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid window in guardrail %q", expr)
+		}
+		assertion, g.Window = expr[:i], window
+	}
+	a, err := ParseSLOAssertion(assertion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid guardrail %q: %v", expr, err)
+	}
+	if a.Metric == SLOQPSRatio {
+		return nil, fmt.Errorf("the QPS ratio cannot be used in guardrail %q", expr)
+	}
+	g.Assertion = a
+	return g, nil
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// LoadTestJobStatus - used for representing the state of a load test job
+type LoadTestJobStatus string
+
+const (
+	// LoadTestJobQueued - represents a job waiting in the run queue
+	LoadTestJobQueued LoadTestJobStatus = "queued"
+
+	// LoadTestJobRunning - represents a job which is currently running
+	LoadTestJobRunning LoadTestJobStatus = "running"
+
+	// LoadTestJobCompleted - represents a job which completed successfully
+	LoadTestJobCompleted LoadTestJobStatus = "completed"
+
+	// LoadTestJobFailed - represents a job which ended with an error
+	LoadTestJobFailed LoadTestJobStatus = "failed"
+
+	// LoadTestJobCancelled - represents a job which was cancelled by the user
+	LoadTestJobCancelled LoadTestJobStatus = "cancelled"
+)
+
+// Done - returns true if the job is no longer running
+func (s LoadTestJobStatus) Done() bool {
+	return s == LoadTestJobCompleted || s == LoadTestJobFailed || s == LoadTestJobCancelled
+}
+
+// LoadTestJob - represents a load test run tracked by Meshery independent of the client connection, the ResultID
+// of a completed job being empty when its result was not stored
+type LoadTestJob struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Mesh      string            `json:"mesh,omitempty"`
+	UserID    string            `json:"user_id,omitempty"`
+	Status    LoadTestJobStatus `json:"status"`
+	Message   string            `json:"message,omitempty"`
+	ResultID  string            `json:"result_id,omitempty"`
+	SLOPassed *bool             `json:"slo_passed,omitempty"`
+	StartTime time.Time         `json:"start_time"`
+	EndTime   *time.Time        `json:"end_time,omitempty"`
+
+	// QueuePosition is the position of the job in the run queue while it is queued, RunTime the time at which
+	// it left the queue and started running
+	QueuePosition int        `json:"queue_position,omitempty"`
+	RunTime       *time.Time `json:"run_time,omitempty"`
+
+	// Progress is the latest snapshot of the live metrics of the test, if any
+	Progress *LoadTestSnapshot `json:"progress,omitempty"`
+}
+
+// LoadTestJobTrackerInterface defines the methods for tracking load test jobs
+type LoadTestJobTrackerInterface interface {
+	// AddJob - registers a new job along with the func which cancels it
+	AddJob(ctx context.Context, job *LoadTestJob, cancel context.CancelFunc)
+	// GetJob - returns a copy of the job with the given ID
+	GetJob(ctx context.Context, id string) (*LoadTestJob, bool)
+	// GetJobs - returns copies of all the tracked jobs
+	GetJobs(ctx context.Context) []*LoadTestJob
+	// CancelJob - cancels a running job
+	CancelJob(ctx context.Context, id string) error
+	// Publish - records a response for the job and ships it to all the subscribers
+	Publish(ctx context.Context, id string, resp *LoadTestResponse)
+	// StartJob - marks a queued job as running
+	StartJob(ctx context.Context, id string)
+	// FinishJob - marks the job as done and closes all the subscriber channels
+	FinishJob(ctx context.Context, id string)
+	// Subscribe - returns a channel which replays the responses published so far followed by the live ones,
+	// along with a func to unsubscribe
+	Subscribe(ctx context.Context, id string) (<-chan *LoadTestResponse, func(), error)
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// LoadTestQueueEntry - represents a load test either running or waiting in the run queue
+type LoadTestQueueEntry struct {
+	JobID  string            `json:"job_id"`
+	Name   string            `json:"name"`
+	UserID string            `json:"user_id,omitempty"`
+	Status LoadTestJobStatus `json:"status"`
+	// Position is the position of a queued test in the queue, 1 being the next one to run
+	Position   int        `json:"position,omitempty"`
+	EnqueuedAt time.Time  `json:"enqueued_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+}
+
+// LoadTestQueueInterface defines the methods of the run queue which limits the number of load tests run at once
+type LoadTestQueueInterface interface {
+	// Enqueue - adds the job at the end of the queue, it fails when the user already has too many tests in it
+	Enqueue(ctx context.Context, job *LoadTestJob) error
+	// EnqueueIfIdle - adds the job to the queue only when it is allowed to run right away, it returns false otherwise
+	EnqueueIfIdle(ctx context.Context, job *LoadTestJob) bool
+	// Wait - blocks until the job is allowed to run or the context is done, positionChanged being called with the
+	// position of the job in the queue whenever it changes
+	Wait(ctx context.Context, id string, positionChanged func(position int)) error
+	// Release - removes the job from the queue once it is done, letting the next one run
+	Release(ctx context.Context, id string)
+	// GetEntries - returns the running tests followed by the queued ones in their order
+	GetEntries(ctx context.Context) []*LoadTestQueueEntry
+}
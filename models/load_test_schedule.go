@@ -0,0 +1,47 @@
+package models
+
+import (
+	"net/url"
+	"time"
+)
+
+// LoadTestSchedule - represents a saved load test definition run on a cron schedule
+type LoadTestSchedule struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	UserID string `json:"user_id"`
+
+	// Cron is a standard 5 field cron expression (minute hour day-of-month month day-of-week) or one of the
+	// @yearly, @monthly, @weekly, @daily and @hourly macros, evaluated in Timezone (the server's one when empty)
+	Cron     string `json:"cron"`
+	Timezone string `json:"timezone,omitempty"`
+	Enabled  bool   `json:"enabled"`
+
+	// Params is the load test definition, it holds the same parameters as the ones accepted by /api/load-test
+	Params url.Values `json:"params"`
+
+	// Provider is the name of the provider of the user, the load tests are run on behalf of the user with it
+	Provider string `json:"provider"`
+
+	NextRun      *time.Time        `json:"next_run,omitempty"`
+	LastRun      *time.Time        `json:"last_run,omitempty"`
+	LastJobID    string            `json:"last_job_id,omitempty"`
+	LastStatus   LoadTestJobStatus `json:"last_status,omitempty"`
+	LastResultID string            `json:"last_result_id,omitempty"`
+	LastError    string            `json:"last_error,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LoadTestSchedulePersister defines the methods for persisting load test schedules
+type LoadTestSchedulePersister interface {
+	// GetSchedules - returns all the persisted schedules
+	GetSchedules() ([]*LoadTestSchedule, error)
+	// GetSchedule - returns the schedule with the given ID
+	GetSchedule(id string) (*LoadTestSchedule, error)
+	// WriteSchedule - persists the schedule, replacing any schedule with the same ID
+	WriteSchedule(schedule *LoadTestSchedule) error
+	// DeleteSchedule - removes the schedule with the given ID
+	DeleteSchedule(id string) error
+}
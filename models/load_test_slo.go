@@ -0,0 +1,160 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SLOMetric - represents the metric checked by an SLO assertion
+type SLOMetric string
+
+const (
+	// SLOLatencyPercentile - represents a latency percentile in milliseconds
+	SLOLatencyPercentile SLOMetric = "latency_percentile"
+
+	// SLOLatencyAvg - represents the average latency in milliseconds
+	SLOLatencyAvg SLOMetric = "latency_avg"
+
+	// SLOLatencyMax - represents the maximum latency in milliseconds
+	SLOLatencyMax SLOMetric = "latency_max"
+
+	// SLOErrorRate - represents the percentage of failed requests
+	SLOErrorRate SLOMetric = "error_rate"
+
+	// SLOQPSRatio - represents the achieved QPS as a percentage of the requested QPS
+	SLOQPSRatio SLOMetric = "qps_ratio"
+
+	// SLOPrometheusMax - represents the maximum value of a Prometheus query over the test window
+	SLOPrometheusMax SLOMetric = "prometheus_max"
+
+	// SLOPrometheusAvg - represents the average value of a Prometheus query over the test window
+	SLOPrometheusAvg SLOMetric = "prometheus_avg"
+)
+
+// SLOAssertion - represents a threshold the results of a load test are checked against
+type SLOAssertion struct {
+	Expr       string    `json:"expr"`
+	Metric     SLOMetric `json:"metric"`
+	Percentile float64   `json:"percentile,omitempty"`
+	Query      string    `json:"query,omitempty"`
+	Operator   string    `json:"operator"`
+	Threshold  float64   `json:"threshold"`
+}
+
+// SLOAssertionResult - represents the outcome of an SLO assertion
+type SLOAssertionResult struct {
+	*SLOAssertion
+	Value  float64 `json:"value"`
+	Passed bool    `json:"passed"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// SLOVerdict - represents the outcome of all the SLO assertions of a load test, it only passes when
+// all the assertions pass
+type SLOVerdict struct {
+	Passed  bool                  `json:"passed"`
+	Results []*SLOAssertionResult `json:"results"`
+}
+
+// Check - returns true if the value satisfies the assertion
+func (a *SLOAssertion) Check(value float64) bool {
+	switch a.Operator {
+	case "<":
+		return value < a.Threshold
+	case "<=":
+		return value <= a.Threshold
+	case ">":
+		return value > a.Threshold
+	case ">=":
+		return value >= a.Threshold
+	}
+	return false
+}
+
+// ParseSLOAssertion - parses an assertion written as "<metric> <operator> <threshold>", where the metric is one of
+// p<N> (eg. p99), avg and max for latencies in milliseconds, error_rate and qps_ratio in percent, or
+// prom_max(<query>) and prom_avg(<query>) for Prometheus queries evaluated over the test window.
+// eg. "p99 < 200ms", "error_rate < 0.1%", "qps_ratio >= 95", "prom_max(sum(rate(container_cpu_usage_seconds_total[1m]))) < 2"
+func ParseSLOAssertion(expr string) (*SLOAssertion, error) {
+	expr = strings.TrimSpace(expr)
+	// the operator is the last one outside of parentheses, as prometheus queries can hold operators too
+	opIdx, depth := -1, 0
+	for i, c := range expr {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '<', '>':
+			if depth == 0 {
+				opIdx = i
+			}
+		}
+	}
+	if opIdx <= 0 {
+		return nil, fmt.Errorf("invalid SLO assertion %q, expecting <metric> <operator> <threshold>", expr)
+	}
+	a := &SLOAssertion{
+		Expr:     expr,
+		Operator: expr[opIdx : opIdx+1],
+	}
+	rhs := expr[opIdx+1:]
+	if strings.HasPrefix(rhs, "=") {
+		a.Operator += "="
+		rhs = rhs[1:]
+	}
+	metric := strings.TrimSpace(expr[:opIdx])
+	rhs = strings.TrimSpace(rhs)
+
+	lower := strings.ToLower(metric)
+	switch {
+	case lower == "avg":
+		a.Metric = SLOLatencyAvg
+	case lower == "max":
+		a.Metric = SLOLatencyMax
+	case lower == "error_rate":
+		a.Metric = SLOErrorRate
+	case lower == "qps_ratio":
+		a.Metric = SLOQPSRatio
+	case strings.HasPrefix(lower, "prom_max(") && strings.HasSuffix(lower, ")"):
+		a.Metric = SLOPrometheusMax
+		a.Query = strings.TrimSpace(metric[len("prom_max(") : len(metric)-1])
+	case strings.HasPrefix(lower, "prom_avg(") && strings.HasSuffix(lower, ")"):
+		a.Metric = SLOPrometheusAvg
+		a.Query = strings.TrimSpace(metric[len("prom_avg(") : len(metric)-1])
+	case strings.HasPrefix(lower, "p"):
+		p, err := strconv.ParseFloat(lower[1:], 64)
+		if err != nil || p <= 0 || p >= 100 {
+			return nil, fmt.Errorf("invalid percentile in SLO assertion %q", expr)
+		}
+		a.Metric = SLOLatencyPercentile
+		a.Percentile = p
+	default:
+		return nil, fmt.Errorf("unknown metric %q in SLO assertion %q", metric, expr)
+	}
+	if (a.Metric == SLOPrometheusMax || a.Metric == SLOPrometheusAvg) && a.Query == "" {
+		return nil, fmt.Errorf("missing Prometheus query in SLO assertion %q", expr)
+	}
+
+	var err error
+	switch a.Metric {
+	case SLOLatencyPercentile, SLOLatencyAvg, SLOLatencyMax:
+		// latencies are given either in milliseconds or as durations
+		if a.Threshold, err = strconv.ParseFloat(rhs, 64); err != nil {
+			var d time.Duration
+			if d, err = time.ParseDuration(rhs); err == nil {
+				a.Threshold = float64(d) / float64(time.Millisecond)
+			}
+		}
+	case SLOErrorRate, SLOQPSRatio:
+		a.Threshold, err = strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(rhs, "%")), 64)
+	default:
+		a.Threshold, err = strconv.ParseFloat(rhs, 64)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid threshold in SLO assertion %q", expr)
+	}
+	return a, nil
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// LoadTestWorkersTrackerInterface defines the methods a type should implement to track load test workers
+type LoadTestWorkersTrackerInterface interface {
+	// AddWorker - puts back in use one of the workers configured by the administrator, any other is rejected
+	AddWorker(ctx context.Context, workerURL string) error
+	RemoveWorker(ctx context.Context, workerURL string)
+	GetWorkers(ctx context.Context) []string
+}
+
+// WorkerLoadTestRequest - represents the share of a distributed load test sent to a worker
+type WorkerLoadTestRequest struct {
+	Options *LoadTestOptions `json:"options"`
+	// StartAt is the time at which all the workers start generating load
+	StartAt time.Time `json:"start_at"`
+}
+
+// WorkerLoadTestResponse - represents the results a worker returns to the coordinator
+type WorkerLoadTestResponse struct {
+	Results map[string]interface{} `json:"runner_results"`
+}
@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/gofrs/uuid"
@@ -18,7 +19,7 @@ import (
 
 // MesheryRemoteProvider - represents a local provider
 type MesheryRemoteProvider struct {
-	*BitCaskPreferencePersister
+	PreferencePersister
 
 	SaaSTokenName string
 	SaaSBaseURL   string
@@ -305,6 +306,11 @@ func (l *MesheryRemoteProvider) FetchResults(req *http.Request, page, pageSize,
 	if order != "" {
 		q.Set("order", order)
 	}
+	for _, filter := range ResultsQueryFilters {
+		if v := req.URL.Query().Get(filter); v != "" {
+			q.Set(filter, v)
+		}
+	}
 	saasURL.RawQuery = q.Encode()
 	logrus.Debugf("constructed results url: %s", saasURL.String())
 	cReq, _ := http.NewRequest(http.MethodGet, saasURL.String(), nil)
@@ -384,6 +390,41 @@ func (l *MesheryRemoteProvider) GetResult(req *http.Request, resultID uuid.UUID)
 	return nil, fmt.Errorf("error while getting result - Status code: %d, Body: %s", resp.StatusCode, bdr)
 }
 
+// DeleteResult - deletes the result with the given id from the remote provider
+func (l *MesheryRemoteProvider) DeleteResult(req *http.Request, resultID uuid.UUID) error {
+	logrus.Infof("attempting to delete result from cloud for id: %s", resultID)
+	session, _ := l.GetSession(req)
+
+	tokenVal, _ := session.Values[l.SaaSTokenName].(string)
+
+	saasURL, _ := url.Parse(fmt.Sprintf("%s/result/%s", l.SaaSBaseURL, resultID.String()))
+	cReq, _ := http.NewRequest(http.MethodDelete, saasURL.String(), nil)
+	cReq.AddCookie(&http.Cookie{
+		Name:     l.SaaSTokenName,
+		Value:    tokenVal,
+		Path:     "/",
+		HttpOnly: true,
+		Domain:   saasURL.Hostname(),
+	})
+	c := &http.Client{}
+	resp, err := c.Do(cReq)
+	if err != nil {
+		logrus.Errorf("unable to delete result: %v", err)
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNoContent {
+		logrus.Infof("result successfully deleted from SaaS")
+		return nil
+	}
+	bdr, _ := ioutil.ReadAll(resp.Body)
+	logrus.Errorf("error while deleting result: %s", bdr)
+	return fmt.Errorf("error while deleting result - Status code: %d, Body: %s", resp.StatusCode, bdr)
+}
+
 // PublishResults - publishes results to the provider backend syncronously
 func (l *MesheryRemoteProvider) PublishResults(req *http.Request, result *MesheryResult) (string, error) {
 	data, err := json.Marshal(result)
@@ -485,7 +526,7 @@ func (l *MesheryRemoteProvider) PublishMetrics(tokenVal string, result *MesheryR
 
 // RecordPreferences - records the user preference
 func (l *MesheryRemoteProvider) RecordPreferences(req *http.Request, userID string, data *Preference) error {
-	if err := l.BitCaskPreferencePersister.WriteToPersister(userID, data); err != nil {
+	if err := l.PreferencePersister.WriteToPersister(userID, data); err != nil {
 		return err
 	}
 	tokenVal, _ := l.GetProviderToken(req)
@@ -495,3 +536,101 @@ func (l *MesheryRemoteProvider) RecordPreferences(req *http.Request, userID stri
 	}
 	return nil
 }
+
+// performanceProfilesRequest - sends a request to the performance profiles API of the provider backend and returns the
+// response body, an error is returned when the status code is not the expected one
+func (l *MesheryRemoteProvider) performanceProfilesRequest(req *http.Request, method, path string, query url.Values, body []byte, expectedStatus int) ([]byte, error) {
+	tokenVal, _ := l.GetProviderToken(req)
+
+	saasURL, _ := url.Parse(l.SaaSBaseURL + "/user/performance/profiles" + path)
+	saasURL.RawQuery = query.Encode()
+	logrus.Debugf("constructed performance profiles url: %s", saasURL.String())
+	cReq, _ := http.NewRequest(method, saasURL.String(), bytes.NewBuffer(body))
+	cReq.AddCookie(&http.Cookie{
+		Name:     l.SaaSTokenName,
+		Value:    tokenVal,
+		Path:     "/",
+		HttpOnly: true,
+		Domain:   saasURL.Hostname(),
+	})
+	c := &http.Client{}
+	resp, err := c.Do(cReq)
+	if err != nil {
+		logrus.Errorf("unable to reach the performance profiles API: %v", err)
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	bdr, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		logrus.Errorf("unable to read response body: %v", err)
+		return nil, err
+	}
+	if resp.StatusCode != expectedStatus {
+		logrus.Errorf("error while calling the performance profiles API: %s", bdr)
+		return nil, fmt.Errorf("error while calling the performance profiles API - Status code: %d, Body: %s", resp.StatusCode, bdr)
+	}
+	return bdr, nil
+}
+
+// SavePerformanceProfile - saves the profile as a new version in the provider backend
+func (l *MesheryRemoteProvider) SavePerformanceProfile(req *http.Request, profile *PerformanceProfile) (*PerformanceProfile, error) {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		logrus.Error(errors.Wrap(err, "error - unable to marshal the performance profile"))
+		return nil, err
+	}
+	bdr, err := l.performanceProfilesRequest(req, http.MethodPost, "", url.Values{}, data, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+	saved := &PerformanceProfile{}
+	if err := json.Unmarshal(bdr, saved); err != nil {
+		logrus.Errorf("unable to unmarshal the performance profile: %v", err)
+		return nil, err
+	}
+	return saved, nil
+}
+
+// GetPerformanceProfiles - fetches the latest version of the profiles whose name contains search from the provider backend
+func (l *MesheryRemoteProvider) GetPerformanceProfiles(req *http.Request, search string) ([]*PerformanceProfile, error) {
+	q := url.Values{}
+	if search != "" {
+		q.Set("search", search)
+	}
+	bdr, err := l.performanceProfilesRequest(req, http.MethodGet, "", q, nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	profiles := []*PerformanceProfile{}
+	if err := json.Unmarshal(bdr, &profiles); err != nil {
+		logrus.Errorf("unable to unmarshal the performance profiles: %v", err)
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// GetPerformanceProfile - fetches the given version of a profile from the provider backend, the latest one when version is 0
+func (l *MesheryRemoteProvider) GetPerformanceProfile(req *http.Request, profileID uuid.UUID, version int) (*PerformanceProfile, error) {
+	q := url.Values{}
+	if version > 0 {
+		q.Set("version", strconv.Itoa(version))
+	}
+	bdr, err := l.performanceProfilesRequest(req, http.MethodGet, "/"+profileID.String(), q, nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	profile := &PerformanceProfile{}
+	if err := json.Unmarshal(bdr, profile); err != nil {
+		logrus.Errorf("unable to unmarshal the performance profile: %v", err)
+		return nil, err
+	}
+	return profile, nil
+}
+
+// DeletePerformanceProfile - removes all the versions of a profile from the provider backend
+func (l *MesheryRemoteProvider) DeletePerformanceProfile(req *http.Request, profileID uuid.UUID) error {
+	_, err := l.performanceProfilesRequest(req, http.MethodDelete, "/"+profileID.String(), url.Values{}, nil, http.StatusOK)
+	return err
+}
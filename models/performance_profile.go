@@ -0,0 +1,100 @@
+package models
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// PerformanceProfile - represents a named, reusable load test definition, every update of a profile
+// creates a new version of it so that results can be traced back to the exact definition they were run with
+type PerformanceProfile struct {
+	ID          uuid.UUID         `json:"id"`
+	Version     int               `json:"version"`
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	UserID      string            `json:"user_id,omitempty"`
+
+	// Endpoints are the targets of the profile, a run targets the first one unless another url is given
+	Endpoints     []string `json:"endpoints"`
+	LoadGenerator string   `json:"load_generator,omitempty"`
+	Mesh          string   `json:"mesh,omitempty"`
+
+	ConcurrentRequests int     `json:"concurrent_requests,omitempty"`
+	QPS                float64 `json:"qps,omitempty"`
+	// Duration is given like 30s, 5m or 1h
+	Duration string `json:"duration,omitempty"`
+	// Stages is a multi-stage load profile, in the format of the stages parameter of /api/load-test
+	Stages json.RawMessage `json:"stages,omitempty"`
+
+	// Headers are given in the "Key: Value" form
+	Headers     []string `json:"headers,omitempty"`
+	Method      string   `json:"method,omitempty"`
+	ContentType string   `json:"content_type,omitempty"`
+	Body        string   `json:"body,omitempty"`
+
+	SLOs []string `json:"slos,omitempty"`
+
+	// Options holds any other parameter accepted by /api/load-test, eg. grpc or percentiles
+	Options url.Values `json:"options,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LoadTestParams - returns the profile as parameters of /api/load-test
+func (p *PerformanceProfile) LoadTestParams() url.Values {
+	params := url.Values{}
+	for k, v := range p.Options {
+		params[k] = v
+	}
+	set := func(key, value string) {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	set("name", p.Name)
+	if len(p.Endpoints) > 0 {
+		set("url", p.Endpoints[0])
+	}
+	set("loadGenerator", p.LoadGenerator)
+	set("mesh", p.Mesh)
+	if p.ConcurrentRequests > 0 {
+		set("c", strconv.Itoa(p.ConcurrentRequests))
+	}
+	if p.QPS > 0 {
+		set("qps", strconv.FormatFloat(p.QPS, 'f', -1, 64))
+	}
+	if d, err := time.ParseDuration(p.Duration); err == nil && d > 0 {
+		// the load test handler takes the duration as a number of hours, minutes or seconds
+		switch {
+		case d%time.Hour == 0:
+			set("t", strconv.Itoa(int(d/time.Hour)))
+			set("dur", "h")
+		case d%time.Minute == 0:
+			set("t", strconv.Itoa(int(d/time.Minute)))
+			set("dur", "m")
+		default:
+			set("t", strconv.Itoa(int((d+time.Second-1)/time.Second)))
+			set("dur", "s")
+		}
+	}
+	if len(p.Stages) > 0 && strings.TrimSpace(string(p.Stages)) != "null" {
+		set("stages", string(p.Stages))
+	}
+	if len(p.Headers) > 0 {
+		params["headers"] = p.Headers
+	}
+	set("method", p.Method)
+	set("contentType", p.ContentType)
+	set("body", p.Body)
+	if len(p.SLOs) > 0 {
+		params["slo"] = p.SLOs
+	}
+	return params
+}
@@ -81,6 +81,7 @@ type Preference struct {
 	Grafana              *Grafana             `json:"grafana,omitempty"`
 	Prometheus           *Prometheus          `json:"prometheus,omitempty"`
 	LoadTestPreferences  *LoadTestPreferences `json:"loadTestPrefs,omitempty"`
+	ResultBaselines      map[string]string    `json:"resultBaselines,omitempty"`
 	AnonymousUsageStats  bool                 `json:"anonymousUsageStats"`
 	AnonymousPerfResults bool                 `json:"anonymousPerfResults"`
 	UpdatedAt            time.Time            `json:"updated_at,omitempty"`
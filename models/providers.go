@@ -55,5 +55,14 @@ type Provider interface {
 	PublishResults(req *http.Request, result *MesheryResult) (string, error)
 	PublishMetrics(tokenVal string, data *MesheryResult) error
 	GetResult(*http.Request, uuid.UUID) (*MesheryResult, error)
+	DeleteResult(*http.Request, uuid.UUID) error
 	RecordPreferences(req *http.Request, userID string, data *Preference) error
+
+	// SavePerformanceProfile saves the profile as a new version and returns the saved profile
+	SavePerformanceProfile(req *http.Request, profile *PerformanceProfile) (*PerformanceProfile, error)
+	// GetPerformanceProfiles returns the latest version of the profiles whose name contains search
+	GetPerformanceProfiles(req *http.Request, search string) ([]*PerformanceProfile, error)
+	// GetPerformanceProfile returns the given version of a profile, the latest one when version is 0
+	GetPerformanceProfile(req *http.Request, profileID uuid.UUID, version int) (*PerformanceProfile, error)
+	DeletePerformanceProfile(req *http.Request, profileID uuid.UUID) error
 }
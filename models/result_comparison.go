@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"fortio.org/fortio/stats"
+)
+
+// ResultComparisonOptions - represents the settings used when comparing results
+type ResultComparisonOptions struct {
+	// Threshold is the relative change in percent above which a significant change counts as a regression
+	Threshold float64 `json:"threshold"`
+	// Alpha is the significance level of the statistical tests
+	Alpha float64 `json:"alpha"`
+}
+
+// ResultSummary - represents the figures of a result used for comparisons
+type ResultSummary struct {
+	ID        string           `json:"meshery_id"`
+	Name      string           `json:"name,omitempty"`
+	Mesh      string           `json:"mesh,omitempty"`
+	StartTime time.Time        `json:"start_time"`
+	Duration  string           `json:"duration"`
+	Count     int64            `json:"count"`
+	ActualQPS float64          `json:"actual_qps"`
+	ErrorRate float64          `json:"error_rate"`
+	RetCodes  map[string]int64 `json:"ret_codes"`
+
+	// latencies are in milliseconds
+	AvgMs         float64            `json:"avg_ms"`
+	StdDevMs      float64            `json:"stddev_ms"`
+	MinMs         float64            `json:"min_ms"`
+	MaxMs         float64            `json:"max_ms"`
+	PercentilesMs map[string]float64 `json:"percentiles_ms"`
+
+	Histogram []stats.Bucket `json:"histogram,omitempty"`
+}
+
+// MetricDiff - represents the change of a metric between the baseline and a candidate
+type MetricDiff struct {
+	Metric       string  `json:"metric"`
+	Baseline     float64 `json:"baseline"`
+	Candidate    float64 `json:"candidate"`
+	Delta        float64 `json:"delta"`
+	DeltaPercent float64 `json:"delta_percent"`
+	// Worse is set when the change goes in the unwanted direction, eg. higher latency or lower throughput
+	Worse bool `json:"worse"`
+}
+
+// SignificanceTest - represents the outcome of a statistical test between the baseline and a candidate
+type SignificanceTest struct {
+	Test        string  `json:"test"`
+	Statistic   float64 `json:"statistic"`
+	PValue      float64 `json:"p_value"`
+	Significant bool    `json:"significant"`
+	// DegreesOfFreedom is the one of the t distribution the p-value is given by, for the t-tests
+	DegreesOfFreedom float64 `json:"degrees_of_freedom,omitempty"`
+}
+
+// ResultDiff - represents the comparison of a candidate result with the baseline
+type ResultDiff struct {
+	Candidate *ResultSummary `json:"candidate"`
+
+	Latencies  []*MetricDiff `json:"latencies"`
+	Throughput *MetricDiff   `json:"throughput"`
+	ErrorRate  *MetricDiff   `json:"error_rate"`
+	RetCodes   []*MetricDiff `json:"ret_codes"`
+
+	// LatencyMean compares the mean latencies, HistogramShape the latency distributions and
+	// ErrorRate the proportions of failed requests
+	LatencyMean    *SignificanceTest `json:"latency_mean"`
+	HistogramShape *SignificanceTest `json:"histogram_shape"`
+	ErrorRateTest  *SignificanceTest `json:"error_rate_test"`
+
+	Regression bool     `json:"regression"`
+	Reasons    []string `json:"reasons,omitempty"`
+}
+
+// ResultComparison - represents the comparison of one or more results with a baseline
+type ResultComparison struct {
+	Options    ResultComparisonOptions `json:"options"`
+	Baseline   *ResultSummary          `json:"baseline"`
+	Candidates []*ResultDiff           `json:"candidates"`
+	// Regression is set when any of the candidates regressed
+	Regression bool `json:"regression"`
+}
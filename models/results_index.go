@@ -0,0 +1,342 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// resultIndexEntry is the summary of a result the index searches, filters and sorts on
+type resultIndexEntry struct {
+	ID            uuid.UUID `json:"id"`
+	Name          string    `json:"name,omitempty"`
+	Mesh          string    `json:"mesh,omitempty"`
+	URL           string    `json:"url,omitempty"`
+	LoadGenerator string    `json:"load_generator,omitempty"`
+	Profile       string    `json:"profile,omitempty"`
+	StartTime     time.Time `json:"start_time"`
+	QPS           float64   `json:"qps,omitempty"`
+	P99           float64   `json:"p99,omitempty"`
+	Duration      float64   `json:"duration,omitempty"`
+	// Size is the size of the stored result in bytes
+	Size int64 `json:"size,omitempty"`
+
+	// text is the lower cased name, mesh and url matched by the search terms
+	text string
+}
+
+func newResultIndexEntry(id uuid.UUID, result *MesheryResult, size int) *resultIndexEntry {
+	e := &resultIndexEntry{
+		ID:   id,
+		Name: result.Name,
+		Mesh: result.Mesh,
+		Size: int64(size),
+	}
+	if result.PerformanceProfile != nil {
+		e.Profile = result.PerformanceProfile.String()
+	}
+	r := result.Result
+	if r != nil {
+		e.URL, _ = r["URL"].(string)
+		if e.URL == "" {
+			// grpc results have a destination instead of a url
+			e.URL, _ = r["Destination"].(string)
+		}
+		e.LoadGenerator, _ = r["load-generator"].(string)
+		if startTime, ok := r["StartTime"].(string); ok {
+			e.StartTime, _ = time.Parse(time.RFC3339Nano, startTime)
+		}
+		e.QPS, _ = r["ActualQPS"].(float64)
+		e.Duration, _ = r["ActualDuration"].(float64)
+		histogram, _ := r["DurationHistogram"].(map[string]interface{})
+		percentiles, _ := histogram["Percentiles"].([]interface{})
+		for _, p := range percentiles {
+			p, _ := p.(map[string]interface{})
+			if percentile, _ := p["Percentile"].(float64); percentile == 99 {
+				e.P99, _ = p["Value"].(float64)
+			}
+		}
+	}
+	e.init()
+	return e
+}
+
+func (e *resultIndexEntry) init() {
+	e.text = strings.ToLower(strings.Join([]string{e.Name, e.Mesh, e.URL}, "\n"))
+}
+
+func (e *resultIndexEntry) less(o *resultIndexEntry, orderBy string) bool {
+	switch orderBy {
+	case "name":
+		return strings.ToLower(e.Name) < strings.ToLower(o.Name)
+	case "mesh":
+		return strings.ToLower(e.Mesh) < strings.ToLower(o.Mesh)
+	case "qps":
+		return e.QPS < o.QPS
+	case "p99":
+		return e.P99 < o.P99
+	case "duration":
+		return e.Duration < o.Duration
+	default:
+		return e.StartTime.Before(o.StartTime)
+	}
+}
+
+// resultsIndex keeps the summaries of all the results in memory, along with secondary indexes on the mesh,
+// the load generator and the performance profile and a list ordered by start time, so that searching the
+// results does not require reading them from the store
+type resultsIndex struct {
+	entries     map[uuid.UUID]*resultIndexEntry
+	byMesh      map[string]map[uuid.UUID]*resultIndexEntry
+	byGenerator map[string]map[uuid.UUID]*resultIndexEntry
+	byProfile   map[string]map[uuid.UUID]*resultIndexEntry
+	// byTime is sorted by start time
+	byTime []*resultIndexEntry
+
+	lock sync.RWMutex
+}
+
+func newResultsIndex() *resultsIndex {
+	return &resultsIndex{
+		entries:     map[uuid.UUID]*resultIndexEntry{},
+		byMesh:      map[string]map[uuid.UUID]*resultIndexEntry{},
+		byGenerator: map[string]map[uuid.UUID]*resultIndexEntry{},
+		byProfile:   map[string]map[uuid.UUID]*resultIndexEntry{},
+	}
+}
+
+func addToSecondaryIndex(idx map[string]map[uuid.UUID]*resultIndexEntry, key string, e *resultIndexEntry) {
+	key = strings.ToLower(key)
+	if idx[key] == nil {
+		idx[key] = map[uuid.UUID]*resultIndexEntry{}
+	}
+	idx[key][e.ID] = e
+}
+
+func removeFromSecondaryIndex(idx map[string]map[uuid.UUID]*resultIndexEntry, key string, id uuid.UUID) {
+	key = strings.ToLower(key)
+	delete(idx[key], id)
+	if len(idx[key]) == 0 {
+		delete(idx, key)
+	}
+}
+
+// put adds the entry to the index, replacing the existing entry of the result if any
+func (x *resultsIndex) put(e *resultIndexEntry) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	x.remove(e.ID)
+	x.entries[e.ID] = e
+	addToSecondaryIndex(x.byMesh, e.Mesh, e)
+	addToSecondaryIndex(x.byGenerator, e.LoadGenerator, e)
+	addToSecondaryIndex(x.byProfile, e.Profile, e)
+
+	// results are mostly added in the order they were run
+	i := len(x.byTime)
+	if i > 0 && e.StartTime.Before(x.byTime[i-1].StartTime) {
+		i = sort.Search(len(x.byTime), func(i int) bool {
+			return x.byTime[i].StartTime.After(e.StartTime)
+		})
+	}
+	x.byTime = append(x.byTime, nil)
+	copy(x.byTime[i+1:], x.byTime[i:])
+	x.byTime[i] = e
+}
+
+// delete removes the entry of the result from the index
+func (x *resultsIndex) delete(id uuid.UUID) {
+	x.lock.Lock()
+	defer x.lock.Unlock()
+
+	x.remove(id)
+}
+
+// remove must be called with the lock held
+func (x *resultsIndex) remove(id uuid.UUID) {
+	e, ok := x.entries[id]
+	if !ok {
+		return
+	}
+	delete(x.entries, id)
+	removeFromSecondaryIndex(x.byMesh, e.Mesh, id)
+	removeFromSecondaryIndex(x.byGenerator, e.LoadGenerator, id)
+	removeFromSecondaryIndex(x.byProfile, e.Profile, id)
+	for i := sort.Search(len(x.byTime), func(i int) bool {
+		return !x.byTime[i].StartTime.Before(e.StartTime)
+	}); i < len(x.byTime); i++ {
+		if x.byTime[i].ID == id {
+			x.byTime = append(x.byTime[:i], x.byTime[i+1:]...)
+			break
+		}
+	}
+}
+
+func (x *resultsIndex) len() int {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+
+	return len(x.entries)
+}
+
+// expired returns the IDs of the results to delete to comply with the retention policy, oldest first,
+// the results in keep are never returned
+func (x *resultsIndex) expired(policy ResultsRetention, now time.Time, keep map[uuid.UUID]bool) []uuid.UUID {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+
+	return expiredResults(x.byTime, policy, now, keep)
+}
+
+// expiredResults returns the IDs of the results to delete to comply with the retention policy, the
+// results must be sorted by start time
+func expiredResults(results []*resultIndexEntry, policy ResultsRetention, now time.Time, keep map[uuid.UUID]bool) []uuid.UUID {
+	count := len(results)
+	var size int64
+	for _, e := range results {
+		size += e.Size
+	}
+	ids := []uuid.UUID{}
+	for _, e := range results {
+		if keep[e.ID] {
+			continue
+		}
+		tooMany := policy.MaxCount > 0 && count > policy.MaxCount
+		tooBig := policy.MaxBytes > 0 && size > policy.MaxBytes
+		if !tooMany && !tooBig {
+			// the age of the results without a start time is unknown, they are kept
+			if e.StartTime.IsZero() {
+				continue
+			}
+			if policy.MaxAge <= 0 || !e.StartTime.Before(now.Add(-policy.MaxAge)) {
+				break
+			}
+		}
+		ids = append(ids, e.ID)
+		count--
+		size -= e.Size
+	}
+	return ids
+}
+
+// query returns the IDs of the results of the requested page, along with the number of results matching the query
+func (x *resultsIndex) query(q *ResultsQuery) ([]uuid.UUID, int) {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+
+	// the candidates are taken from the smallest of the applicable indexes
+	var candidates []*resultIndexEntry
+	var set map[uuid.UUID]*resultIndexEntry
+	indexed := false
+	for _, f := range []struct {
+		idx   map[string]map[uuid.UUID]*resultIndexEntry
+		value string
+	}{
+		{x.byMesh, q.Mesh},
+		{x.byGenerator, q.LoadGenerator},
+		{x.byProfile, profileIndexKey(q.Profile)},
+	} {
+		if f.value == "" {
+			continue
+		}
+		s := f.idx[f.value]
+		if !indexed || len(s) < len(set) {
+			set = s
+			indexed = true
+		}
+	}
+	if indexed {
+		candidates = make([]*resultIndexEntry, 0, len(set))
+		for _, e := range set {
+			candidates = append(candidates, e)
+		}
+	} else {
+		start, end := 0, len(x.byTime)
+		if !q.From.IsZero() {
+			start = sort.Search(len(x.byTime), func(i int) bool {
+				return !x.byTime[i].StartTime.Before(q.From)
+			})
+		}
+		if !q.To.IsZero() {
+			end = sort.Search(len(x.byTime), func(i int) bool {
+				return x.byTime[i].StartTime.After(q.To)
+			})
+		}
+		if start < end {
+			candidates = x.byTime[start:end]
+		}
+	}
+
+	matches := make([]*resultIndexEntry, 0, len(candidates))
+	for _, e := range candidates {
+		if x.matches(e, q) {
+			matches = append(matches, e)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if q.Desc {
+			a, b = b, a
+		}
+		if a.less(b, q.OrderBy) {
+			return true
+		}
+		if b.less(a, q.OrderBy) {
+			return false
+		}
+		// ties are broken by start time then id so that the pages are stable
+		if !a.StartTime.Equal(b.StartTime) {
+			return a.StartTime.Before(b.StartTime)
+		}
+		return a.ID.String() < b.ID.String()
+	})
+
+	total := len(matches)
+	start := q.Page * q.PageSize
+	if start >= uint64(total) {
+		return []uuid.UUID{}, total
+	}
+	end := start + q.PageSize
+	if end > uint64(total) {
+		end = uint64(total)
+	}
+	ids := make([]uuid.UUID, 0, end-start)
+	for _, e := range matches[start:end] {
+		ids = append(ids, e.ID)
+	}
+	return ids, total
+}
+
+func (x *resultsIndex) matches(e *resultIndexEntry, q *ResultsQuery) bool {
+	if q.Mesh != "" && strings.ToLower(e.Mesh) != q.Mesh {
+		return false
+	}
+	if q.LoadGenerator != "" && strings.ToLower(e.LoadGenerator) != q.LoadGenerator {
+		return false
+	}
+	if q.Profile != nil && e.Profile != q.Profile.String() {
+		return false
+	}
+	if !q.From.IsZero() && e.StartTime.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && e.StartTime.After(q.To) {
+		return false
+	}
+	for _, term := range q.Search {
+		if !strings.Contains(e.text, term) {
+			return false
+		}
+	}
+	return true
+}
+
+func profileIndexKey(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
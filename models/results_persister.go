@@ -0,0 +1,20 @@
+package models
+
+import "github.com/gofrs/uuid"
+
+// ResultsPersister - represents a store of the results of the local provider
+type ResultsPersister interface {
+	GetResults(q *ResultsQuery) ([]byte, error)
+	GetResult(key uuid.UUID) (*MesheryResult, error)
+	WriteResult(key uuid.UUID, result []byte) error
+	DeleteResult(key uuid.UUID) error
+
+	// EnforceRetention deletes the results exceeding the retention policy, except the ones in keep
+	EnforceRetention(policy ResultsRetention, keep map[uuid.UUID]bool) (int, error)
+	// Compact reclaims the space of the deleted results
+	Compact() error
+	// Size returns the size of the store on disk, in bytes
+	Size() (int64, error)
+
+	CloseResultPersister()
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// ResultsQueryFilters are the parameters of /api/results which filter the results, in addition to search
+var ResultsQueryFilters = []string{"mesh", "loadGenerator", "from", "to", "profile"}
+
+// ResultsQuery - represents a search of the stored results
+type ResultsQuery struct {
+	Page     uint64
+	PageSize uint64
+
+	// Search terms are matched against the name, the mesh and the url of the results, all of them must match
+	Search []string
+
+	// OrderBy is one of name, mesh, test_start_time, qps, p99 and duration
+	OrderBy string
+	Desc    bool
+
+	Mesh          string
+	LoadGenerator string
+	Profile       *uuid.UUID
+	From          time.Time
+	To            time.Time
+}
+
+var resultsOrderColumns = map[string]string{
+	"name":            "name",
+	"mesh":            "mesh",
+	"test_start_time": "test_start_time",
+	"start_time":      "test_start_time",
+	"time":            "test_start_time",
+	"qps":             "qps",
+	"p99":             "p99",
+	"duration":        "duration",
+}
+
+// ParseResultsQuery - parses the parameters of /api/results, order is given as "<column> [asc|desc]" and
+// the results are ordered by start time, most recent first, when it is empty
+func ParseResultsQuery(page, pageSize, search, order string, filters url.Values) (*ResultsQuery, error) {
+	q := &ResultsQuery{
+		OrderBy: "test_start_time",
+		Desc:    true,
+	}
+	var err error
+	if q.Page, err = strconv.ParseUint(page, 10, 32); err != nil {
+		return nil, fmt.Errorf("unable to parse page number: %s", page)
+	}
+	if q.PageSize, err = strconv.ParseUint(pageSize, 10, 32); err != nil {
+		return nil, fmt.Errorf("unable to parse page size: %s", pageSize)
+	}
+	q.Search = strings.Fields(strings.ToLower(search))
+
+	if fields := strings.Fields(order); len(fields) > 0 {
+		column, ok := resultsOrderColumns[strings.ToLower(fields[0])]
+		if !ok || len(fields) > 2 {
+			return nil, fmt.Errorf("invalid order: %s", order)
+		}
+		q.OrderBy = column
+		q.Desc = false
+		if len(fields) == 2 {
+			switch strings.ToLower(fields[1]) {
+			case "asc":
+			case "desc":
+				q.Desc = true
+			default:
+				return nil, fmt.Errorf("invalid order: %s", order)
+			}
+		}
+	}
+
+	q.Mesh = strings.ToLower(strings.TrimSpace(filters.Get("mesh")))
+	q.LoadGenerator = strings.ToLower(strings.TrimSpace(filters.Get("loadGenerator")))
+	if profile := filters.Get("profile"); profile != "" {
+		id := uuid.FromStringOrNil(profile)
+		if id == uuid.Nil {
+			return nil, fmt.Errorf("invalid performance profile id: %s", profile)
+		}
+		q.Profile = &id
+	}
+	if q.From, err = parseResultsQueryTime(filters.Get("from"), false); err != nil {
+		return nil, err
+	}
+	if q.To, err = parseResultsQueryTime(filters.Get("to"), true); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// parseResultsQueryTime accepts RFC 3339 times and dates, a date used as the end of a range includes the whole day
+func parseResultsQueryTime(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date: %s, expecting an RFC 3339 time or a YYYY-MM-DD date", value)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
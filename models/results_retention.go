@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// ResultsRetention - represents the retention policy of the locally stored results, the oldest results are
+// deleted first and a zero value disables a limit
+type ResultsRetention struct {
+	// MaxAge is the age after which a result is deleted, based on the start time of the test
+	MaxAge time.Duration `json:"max_age,omitempty"`
+	// MaxCount is the maximum number of results kept
+	MaxCount int `json:"max_count,omitempty"`
+	// MaxBytes is the maximum size of the results kept
+	MaxBytes int64 `json:"max_bytes,omitempty"`
+}
+
+// Enabled - returns whether any limit is set
+func (r ResultsRetention) Enabled() bool {
+	return r.MaxAge > 0 || r.MaxCount > 0 || r.MaxBytes > 0
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path"
+
+	// registers the sqlite3 database/sql driver
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// sqliteMigrations are the statements of the successive versions of the schema, the version of a database
+// is kept in its user_version and a released migration is never modified, new ones are appended
+var sqliteMigrations = [][]string{
+	{
+		`CREATE TABLE results (
+			id                  TEXT PRIMARY KEY,
+			name                TEXT NOT NULL DEFAULT '',
+			mesh                TEXT NOT NULL DEFAULT '',
+			url                 TEXT NOT NULL DEFAULT '',
+			load_generator      TEXT NOT NULL DEFAULT '',
+			performance_profile TEXT NOT NULL DEFAULT '',
+			start_time          INTEGER NOT NULL DEFAULT 0,
+			qps                 REAL NOT NULL DEFAULT 0,
+			p99                 REAL NOT NULL DEFAULT 0,
+			duration            REAL NOT NULL DEFAULT 0,
+			size                INTEGER NOT NULL DEFAULT 0,
+			data                BLOB NOT NULL
+		)`,
+		`CREATE INDEX results_start_time ON results (start_time)`,
+		`CREATE INDEX results_name ON results (name COLLATE NOCASE)`,
+		`CREATE INDEX results_mesh ON results (mesh COLLATE NOCASE, start_time)`,
+		`CREATE INDEX results_load_generator ON results (load_generator COLLATE NOCASE, start_time)`,
+		`CREATE INDEX results_performance_profile ON results (performance_profile, start_time)`,
+		`CREATE INDEX results_qps ON results (qps)`,
+		`CREATE INDEX results_p99 ON results (p99)`,
+		`CREATE TABLE preferences (
+			user_id    TEXT PRIMARY KEY,
+			data       BLOB NOT NULL,
+			updated_at INTEGER NOT NULL DEFAULT 0
+		)`,
+	},
+}
+
+// OpenSQLiteDatabase opens the meshery.db SQLite database in the given folder, creating it if needed,
+// and migrates its schema to the latest version
+func OpenSQLiteDatabase(folderName string) (*sql.DB, error) {
+	_, err := os.Stat(folderName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = os.MkdirAll(folderName, os.ModePerm)
+			if err != nil {
+				logrus.Errorf("Unable to create the directory '%s' due to error: %v.", folderName, err)
+				return nil, err
+			}
+		} else {
+			logrus.Errorf("Unable to find/stat the folder '%s': %v,", folderName, err)
+			return nil, err
+		}
+	}
+
+	// the write ahead log lets the readers proceed while a result is written, and the busy timeout
+	// makes a writer wait for the others instead of failing
+	dsn := fmt.Sprintf("file:%s?_journal_mode=WAL&_busy_timeout=10000&_synchronous=NORMAL", path.Join(folderName, "meshery.db"))
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		logrus.Errorf("Unable to open database: %v.", err)
+		return nil, err
+	}
+	if err := migrateSQLiteDatabase(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func migrateSQLiteDatabase(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		err = errors.Wrapf(err, "Unable to read the version of the database")
+		logrus.Error(err)
+		return err
+	}
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("the database has version %d which is newer than the supported version %d", version, len(sqliteMigrations))
+	}
+	for ; version < len(sqliteMigrations); version++ {
+		logrus.Infof("Migrating the database to version %d", version+1)
+		tx, err := db.Begin()
+		if err != nil {
+			return errors.Wrapf(err, "Unable to begin the migration to version %d", version+1)
+		}
+		for _, stmt := range sqliteMigrations[version] {
+			if _, err := tx.Exec(stmt); err != nil {
+				_ = tx.Rollback()
+				err = errors.Wrapf(err, "Unable to migrate the database to version %d", version+1)
+				logrus.Error(err)
+				return err
+			}
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version+1)); err != nil {
+			_ = tx.Rollback()
+			return errors.Wrapf(err, "Unable to record the version of the database")
+		}
+		if err := tx.Commit(); err != nil {
+			return errors.Wrapf(err, "Unable to migrate the database to version %d", version+1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"database/sql"
+	"os"
+	"path"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/prologic/bitcask"
+	"github.com/sirupsen/logrus"
+)
+
+// MigrateBitCaskToSQLite copies the results and the preferences of the bitcask stores of the given folder
+// to the SQLite database, existing entries of the database are overwritten so that it can be run again,
+// it returns the number of results and preferences copied
+func MigrateBitCaskToSQLite(folderName string, db *sql.DB) (int, int, error) {
+	results := NewSQLiteResultsPersister(db)
+	resultCount, err := foldBitCask(path.Join(folderName, "resultDB"), func(key, value []byte) error {
+		id, err := uuid.FromBytes(key)
+		if err != nil {
+			logrus.Warnf("Skipping the result with an invalid key: %v", err)
+			return nil
+		}
+		return results.WriteResult(id, value)
+	})
+	if err != nil {
+		return resultCount, 0, errors.Wrapf(err, "Unable to migrate the results")
+	}
+
+	preferenceCount, err := foldBitCask(path.Join(folderName, "db"), func(key, value []byte) error {
+		_, err := db.Exec(`INSERT OR REPLACE INTO preferences (user_id, data) VALUES (?, ?)`, string(key), value)
+		return err
+	})
+	if err != nil {
+		return resultCount, preferenceCount, errors.Wrapf(err, "Unable to migrate the preferences")
+	}
+	return resultCount, preferenceCount, nil
+}
+
+// foldBitCask calls f with every non empty entry of the bitcask store, if it exists, and returns the number of entries
+func foldBitCask(fileName string, f func(key, value []byte) error) (int, error) {
+	if _, err := os.Stat(fileName); os.IsNotExist(err) {
+		logrus.Infof("No bitcask store at '%s', skipping it", fileName)
+		return 0, nil
+	}
+	store, err := bitcask.Open(fileName)
+	if err != nil {
+		logrus.Errorf("Unable to open database: %v.", err)
+		return 0, err
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+
+	keys := [][]byte{}
+	for k := range store.Keys() {
+		keys = append(keys, k)
+	}
+	count := 0
+	for _, k := range keys {
+		value, err := store.Get(k)
+		if err != nil {
+			return count, errors.Wrapf(err, "Unable to read data from bitcask store")
+		}
+		if len(value) == 0 {
+			continue
+		}
+		if err := f(k, value); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// SQLitePreferencePersister assists with persisting the preferences of the users in a SQLite database
+type SQLitePreferencePersister struct {
+	db *sql.DB
+}
+
+// NewSQLitePreferencePersister creates a new SQLitePreferencePersister instance on a database opened with OpenSQLiteDatabase
+func NewSQLitePreferencePersister(db *sql.DB) *SQLitePreferencePersister {
+	return &SQLitePreferencePersister{
+		db: db,
+	}
+}
+
+// ReadFromPersister - reads the session data for the given userID
+func (s *SQLitePreferencePersister) ReadFromPersister(userID string) (*Preference, error) {
+	if userID == "" {
+		return nil, errors.New("User ID is empty.")
+	}
+
+	data := &Preference{
+		AnonymousUsageStats:  true,
+		AnonymousPerfResults: true,
+	}
+	var dataB []byte
+	err := s.db.QueryRow(`SELECT data FROM preferences WHERE user_id = ?`, userID).Scan(&dataB)
+	if err == sql.ErrNoRows {
+		return data, nil
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to read data from the database")
+		logrus.Error(err)
+		return nil, err
+	}
+	if err := json.Unmarshal(dataB, data); err != nil {
+		err = errors.Wrapf(err, "Unable to unmarshal data.")
+		logrus.Error(err)
+		return nil, err
+	}
+	return data, nil
+}
+
+// WriteToPersister persists session for the user
+func (s *SQLitePreferencePersister) WriteToPersister(userID string, data *Preference) error {
+	if userID == "" {
+		return errors.New("User ID is empty.")
+	}
+
+	if data == nil {
+		return errors.New("Given config data is nil.")
+	}
+
+	data.UpdatedAt = time.Now()
+	dataB, err := json.Marshal(data)
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to marshal the user config data.")
+		logrus.Error(err)
+		return err
+	}
+	if _, err := s.db.Exec(`INSERT OR REPLACE INTO preferences (user_id, data, updated_at) VALUES (?, ?, ?)`,
+		userID, dataB, data.UpdatedAt.UnixNano()); err != nil {
+		err = errors.Wrapf(err, "Unable to persist config data.")
+		return err
+	}
+	return nil
+}
+
+// DeleteFromPersister removes the session for the user
+func (s *SQLitePreferencePersister) DeleteFromPersister(userID string) error {
+	if userID == "" {
+		return errors.New("User ID is empty.")
+	}
+
+	if _, err := s.db.Exec(`DELETE FROM preferences WHERE user_id = ?`, userID); err != nil {
+		err = errors.Wrapf(err, "Unable to delete config data for the user: %s.", userID)
+		return err
+	}
+	return nil
+}
+
+// ClosePersister does nothing, the database is closed by its owner
+func (s *SQLitePreferencePersister) ClosePersister() {}
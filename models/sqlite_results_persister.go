@@ -0,0 +1,285 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// SQLiteResultsPersister assists with persisting results in a SQLite database, the metadata of the
+// results are kept in indexed columns to search, filter and sort them
+type SQLiteResultsPersister struct {
+	db *sql.DB
+
+	lock sync.Mutex
+	// deleted is the number of results deleted since the last vacuum
+	deleted int
+}
+
+// NewSQLiteResultsPersister creates a new SQLiteResultsPersister instance on a database opened with OpenSQLiteDatabase
+func NewSQLiteResultsPersister(db *sql.DB) *SQLiteResultsPersister {
+	return &SQLiteResultsPersister{
+		db: db,
+	}
+}
+
+var sqliteResultsOrderColumns = map[string]string{
+	"name":            "name COLLATE NOCASE",
+	"mesh":            "mesh COLLATE NOCASE",
+	"test_start_time": "start_time",
+	"qps":             "qps",
+	"p99":             "p99",
+	"duration":        "duration",
+}
+
+// GetResults - gets the page of results matching the query
+func (s *SQLiteResultsPersister) GetResults(q *ResultsQuery) ([]byte, error) {
+	where := []string{}
+	args := []interface{}{}
+	if q.Mesh != "" {
+		where = append(where, "mesh = ? COLLATE NOCASE")
+		args = append(args, q.Mesh)
+	}
+	if q.LoadGenerator != "" {
+		where = append(where, "load_generator = ? COLLATE NOCASE")
+		args = append(args, q.LoadGenerator)
+	}
+	if q.Profile != nil {
+		where = append(where, "performance_profile = ?")
+		args = append(args, q.Profile.String())
+	}
+	if !q.From.IsZero() {
+		where = append(where, "start_time >= ?")
+		args = append(args, q.From.UnixNano())
+	}
+	if !q.To.IsZero() {
+		where = append(where, "start_time <= ?")
+		args = append(args, q.To.UnixNano())
+	}
+	for _, term := range q.Search {
+		where = append(where, `(name || char(10) || mesh || char(10) || url) LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+sqliteLikeEscaper.Replace(term)+"%")
+	}
+	cond := ""
+	if len(where) > 0 {
+		cond = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM results`+cond, args...).Scan(&total); err != nil {
+		err = errors.Wrapf(err, "Unable to count the results")
+		logrus.Error(err)
+		return nil, err
+	}
+
+	dir := " ASC"
+	if q.Desc {
+		dir = " DESC"
+	}
+	column, ok := sqliteResultsOrderColumns[q.OrderBy]
+	if !ok {
+		column = "start_time"
+	}
+	// ties are broken by start time then id so that the pages are stable
+	order := " ORDER BY " + column + dir + ", start_time" + dir + ", id" + dir
+	rows, err := s.db.Query(`SELECT data FROM results`+cond+order+` LIMIT ? OFFSET ?`,
+		append(args, q.PageSize, q.Page*q.PageSize)...)
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to query the results")
+		logrus.Error(err)
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	results := []*MesheryResult{}
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			err = errors.Wrapf(err, "Unable to read the results")
+			logrus.Error(err)
+			return nil, err
+		}
+		result := &MesheryResult{}
+		if err := json.Unmarshal(data, result); err != nil {
+			err = errors.Wrapf(err, "Unable to unmarshal data.")
+			logrus.Error(err)
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		err = errors.Wrapf(err, "Unable to read the results")
+		logrus.Error(err)
+		return nil, err
+	}
+
+	bd, err := json.Marshal(&MesheryResultPage{
+		Page:       q.Page,
+		PageSize:   q.PageSize,
+		TotalCount: total,
+		Results:    results,
+	})
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to marshal result data.")
+		logrus.Error(err)
+		return nil, err
+	}
+	return bd, nil
+}
+
+var sqliteLikeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// GetResult - gets result for a specific key
+func (s *SQLiteResultsPersister) GetResult(key uuid.UUID) (*MesheryResult, error) {
+	var data []byte
+	err := s.db.QueryRow(`SELECT data FROM results WHERE id = ?`, key.String()).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrResultNotFound
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to fetch result data")
+		logrus.Error(err)
+		return nil, err
+	}
+	result := &MesheryResult{}
+	if err := json.Unmarshal(data, result); err != nil {
+		err = errors.Wrapf(err, "Unable to unmarshal result data.")
+		logrus.Error(err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// WriteResult persists the result
+func (s *SQLiteResultsPersister) WriteResult(key uuid.UUID, result []byte) error {
+	if result == nil {
+		return errors.New("Given result data is nil.")
+	}
+	mr := &MesheryResult{}
+	if err := json.Unmarshal(result, mr); err != nil {
+		err = errors.Wrapf(err, "Unable to unmarshal result data.")
+		logrus.Error(err)
+		return err
+	}
+	e := newResultIndexEntry(key, mr, len(result))
+	var startTime int64
+	if !e.StartTime.IsZero() {
+		startTime = e.StartTime.UnixNano()
+	}
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO results
+		(id, name, mesh, url, load_generator, performance_profile, start_time, qps, p99, duration, size, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		key.String(), e.Name, e.Mesh, e.URL, e.LoadGenerator, e.Profile, startTime, e.QPS, e.P99, e.Duration, e.Size, result)
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to persist result data.")
+		logrus.Error(err)
+		return err
+	}
+	return nil
+}
+
+// DeleteResult removes the result
+func (s *SQLiteResultsPersister) DeleteResult(key uuid.UUID) error {
+	res, err := s.db.Exec(`DELETE FROM results WHERE id = ?`, key.String())
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to delete result data.")
+		logrus.Error(err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrResultNotFound
+	}
+	s.lock.Lock()
+	s.deleted++
+	s.lock.Unlock()
+	return nil
+}
+
+// EnforceRetention deletes the results exceeding the retention policy, except the ones in keep,
+// and returns the number of deleted results
+func (s *SQLiteResultsPersister) EnforceRetention(policy ResultsRetention, keep map[uuid.UUID]bool) (int, error) {
+	if !policy.Enabled() {
+		return 0, nil
+	}
+	rows, err := s.db.Query(`SELECT id, start_time, size FROM results ORDER BY start_time, id`)
+	if err != nil {
+		err = errors.Wrapf(err, "Unable to query the results")
+		logrus.Error(err)
+		return 0, err
+	}
+	results := []*resultIndexEntry{}
+	for rows.Next() {
+		var (
+			id        string
+			startTime int64
+		)
+		e := &resultIndexEntry{}
+		if err := rows.Scan(&id, &startTime, &e.Size); err != nil {
+			_ = rows.Close()
+			err = errors.Wrapf(err, "Unable to read the results")
+			logrus.Error(err)
+			return 0, err
+		}
+		e.ID = uuid.FromStringOrNil(id)
+		if startTime != 0 {
+			e.StartTime = time.Unix(0, startTime)
+		}
+		results = append(results, e)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		err = errors.Wrapf(err, "Unable to read the results")
+		logrus.Error(err)
+		return 0, err
+	}
+
+	deleted := 0
+	for _, id := range expiredResults(results, policy, time.Now(), keep) {
+		if err := s.DeleteResult(id); err != nil && err != ErrResultNotFound {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// Compact vacuums the database to reclaim the space of the deleted results, it does nothing when no
+// result was deleted since the last vacuum
+func (s *SQLiteResultsPersister) Compact() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.deleted == 0 {
+		return nil
+	}
+	if _, err := s.db.Exec(`VACUUM`); err != nil {
+		err = errors.Wrapf(err, "Unable to vacuum the database")
+		logrus.Error(err)
+		return err
+	}
+	s.deleted = 0
+	return nil
+}
+
+// Size returns the size of the database, in bytes
+func (s *SQLiteResultsPersister) Size() (int64, error) {
+	var pages, pageSize int64
+	if err := s.db.QueryRow(`PRAGMA page_count`).Scan(&pages); err != nil {
+		return 0, errors.Wrapf(err, "Unable to get the size of the database")
+	}
+	if err := s.db.QueryRow(`PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return 0, errors.Wrapf(err, "Unable to get the size of the database")
+	}
+	return pages * pageSize, nil
+}
+
+// CloseResultPersister does nothing, the database is closed by its owner
+func (s *SQLiteResultsPersister) CloseResultPersister() {}
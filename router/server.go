@@ -32,10 +32,24 @@ func NewRouter(ctx context.Context, h models.HandlerInterface, port int) *Router
 	mux.Handle("/api/mesh/scan", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.InstalledMeshesHandler))))
 
 	mux.Handle("/api/load-test", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestHandler))))
+	mux.Handle("/api/load-test/", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestJobHandler))))
+	mux.Handle("/api/load-test-queue", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestQueueHandler))))
 	mux.Handle("/api/load-test-smps", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestUsingSMPSHandler))))
+	mux.Handle("/api/load-test-workers", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestWorkersHandler))))
+	mux.HandleFunc("/api/worker/load-test", h.LoadTestWorkerHandler)
+	mux.Handle("/api/load-test-schedules", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestSchedulesHandler))))
+	mux.Handle("/api/load-test-schedules/", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestSchedulesHandler))))
 	mux.Handle("/api/load-test-prefs", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.LoadTestPrefencesHandler))))
 	mux.Handle("/api/results", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.FetchResultsHandler))))
 	mux.Handle("/api/result", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.GetResultHandler))))
+	mux.Handle("/api/results/export", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.ExportResultsHandler))))
+	mux.Handle("/api/results/import", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.ImportResultsHandler))))
+	mux.Handle("/api/experiments", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.ExperimentsHandler))))
+	mux.Handle("/api/experiments/", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.ExperimentsHandler))))
+	mux.Handle("/api/results/compare", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.CompareResultsHandler))))
+	mux.Handle("/api/results/baselines", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.ResultBaselinesHandler))))
+	mux.Handle("/api/performance/profiles", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.PerformanceProfilesHandler))))
+	mux.Handle("/api/performance/profiles/", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.PerformanceProfilesHandler))))
 
 	mux.Handle("/api/mesh/manage", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.MeshAdapterConfigHandler))))
 	mux.Handle("/api/mesh/ops", h.ProviderMiddleware(h.AuthMiddleware(h.SessionInjectorMiddleware(h.MeshOpsHandler))))